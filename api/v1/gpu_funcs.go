@@ -0,0 +1,29 @@
+package v1
+
+import (
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+// unhealthyGPUConditions are health conditions which exclude the GPU from allocation when True
+var unhealthyGPUConditions = []string{
+	constants.ConditionStatusTypeGPUECCError,
+	constants.ConditionStatusTypeGPUXIDError,
+	constants.ConditionStatusTypeGPUOverheating,
+	constants.ConditionStatusTypeGPUDeviceLost,
+}
+
+// IsUnhealthy returns whether any health condition reported by node discovery fails
+func (gpu *GPU) IsUnhealthy() bool {
+	for _, conditionType := range unhealthyGPUConditions {
+		if meta.IsStatusConditionTrue(gpu.Status.Conditions, conditionType) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsHypervisorNotReady returns whether the hypervisor reported in heartbeats that it is not serving the GPU
+func (gpu *GPU) IsHypervisorNotReady() bool {
+	return meta.IsStatusConditionFalse(gpu.Status.Conditions, constants.ConditionStatusTypeGPUHypervisorReady)
+}
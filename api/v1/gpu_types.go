@@ -29,6 +29,14 @@ type GPUStatus struct {
 	Capacity  *Resource `json:"capacity"`
 	Available *Resource `json:"available"`
 
+	// Capacity after applying the pool's oversubscription config, equals to Capacity when oversubscription is disabled
+	// +optional
+	VirtualCapacity *Resource `json:"virtualCapacity,omitempty"`
+
+	// Remaining oversold capacity, only low and medium QoS workloads can be admitted once physical Available is exhausted
+	// +optional
+	VirtualAvailable *Resource `json:"virtualAvailable,omitempty"`
+
 	UUID string `json:"uuid"`
 
 	// The host match selector to schedule worker pods
@@ -39,6 +47,16 @@ type GPUStatus struct {
 
 	// +optional
 	RunningApps []*RunningAppDetail `json:"runningApps,omitempty"`
+
+	// Last reported GPU core temperature in Celsius
+	// +optional
+	Temperature *int32 `json:"temperature,omitempty"`
+
+	// Health conditions reported by node discovery, e.g. ECCError, XIDError, Overheating and Throttled,
+	// GPUs with any of ECCError, XIDError, Overheating or DeviceLost being True are Unhealthy.
+	// HypervisorReady is reported by hypervisor heartbeats
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 type RunningAppDetail struct {
@@ -50,7 +68,7 @@ type RunningAppDetail struct {
 	Count int `json:"count"`
 }
 
-// +kubebuilder:validation:Enum=Pending;Provisioning;Running;Unknown;Destroying;Migrating
+// +kubebuilder:validation:Enum=Pending;Provisioning;Running;Unknown;Destroying;Migrating;Unschedulable;Unhealthy
 type TensorFusionGPUPhase string
 
 const (
@@ -60,6 +78,10 @@ const (
 	TensorFusionGPUPhaseUnknown    TensorFusionGPUPhase = constants.PhaseUnknown
 	TensorFusionGPUPhaseDestroying TensorFusionGPUPhase = constants.PhaseDestroying
 	TensorFusionGPUPhaseMigrating  TensorFusionGPUPhase = constants.PhaseMigrating
+	// GPUs of cordoned nodes keep serving existing workers but are not allocated
+	TensorFusionGPUPhaseUnschedulable TensorFusionGPUPhase = constants.PhaseUnschedulable
+	// GPUs failing health checks are not allocated until they recover
+	TensorFusionGPUPhaseUnhealthy TensorFusionGPUPhase = constants.PhaseUnhealthy
 )
 
 // +kubebuilder:object:root=true
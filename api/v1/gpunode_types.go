@@ -41,6 +41,19 @@ type GPUNodeSpec struct {
 
 	// +optional
 	CloudVendorParam string `json:"cloudVendorParam,omitempty"`
+
+	// cordon the node, GPUs of the node are not allocated to new workers while existing workers keep running
+	// +optional
+	Unschedulable bool `json:"unschedulable,omitempty"`
+
+	// move existing workers to other nodes, the node is cordoned while draining
+	// +optional
+	Drain bool `json:"drain,omitempty"`
+
+	// how long workers can take to be migrated when draining, workers still on the node are evicted after it
+	// +kubebuilder:default="5m"
+	// +optional
+	DrainGracePeriod string `json:"drainGracePeriod,omitempty"`
 }
 
 // +kubebuilder:validation:Enum=Manual;AutoSelect;Provisioned
@@ -77,6 +90,13 @@ type GPUNodeStatus struct {
 	// +optional
 	VirtualAvailableVRAM *resource.Quantity `json:"virtualAvailableVRAM,omitempty"`
 
+	// Average usage of GPUs reported by hypervisor in recent minutes,
+	// allocated percentage is used when usage metrics are not available
+	// +optional
+	UtilizedTFlopsPercent string `json:"utilizedTFlopsPercent,omitempty"`
+	// +optional
+	UtilizedVRAMPercent string `json:"utilizedVRAMPercent,omitempty"`
+
 	// +optional
 	HypervisorStatus NodeHypervisorStatus `json:"hypervisorStatus,omitempty"`
 
@@ -96,6 +116,40 @@ type GPUNodeStatus struct {
 
 	// +optional
 	AllocationInfo []*RunningAppDetail `json:"allocationInfo,omitempty"`
+
+	// +optional
+	// progress of draining workers off the node, empty when not draining
+	Drain *GPUNodeDrainStatus `json:"drain,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=Draining;Drained
+type GPUNodeDrainPhase string
+
+const (
+	GPUNodeDraining GPUNodeDrainPhase = "Draining"
+	GPUNodeDrained  GPUNodeDrainPhase = "Drained"
+)
+
+type GPUNodeDrainStatus struct {
+	Phase GPUNodeDrainPhase `json:"phase"`
+
+	StartTime metav1.Time `json:"startTime"`
+
+	// workers still on the node
+	RemainingWorkers int32 `json:"remainingWorkers"`
+
+	// workers being migrated to other nodes
+	MigratingWorkers int32 `json:"migratingWorkers"`
+
+	// workers deleted since they were not migrated within the grace period
+	EvictedWorkers int32 `json:"evictedWorkers"`
+
+	// +optional
+	// workers labeled do-not-disrupt, the node is not drained until they are moved or removed by their owners
+	BlockedWorkers int32 `json:"blockedWorkers,omitempty"`
+
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
 }
 
 // +kubebuilder:validation:Enum=Pending;Provisioning;Migrating;Running;Succeeded;Failed;Unknown;Destroying
@@ -133,9 +187,17 @@ type GPUNodeInfo struct {
 }
 
 type NodeHypervisorStatus struct {
-	HypervisorState   string      `json:"hypervisorState,omitempty"`
-	HypervisorVersion string      `json:"hypervisorVersion,omitempty"`
+	// State and version reported by the hypervisor in its heartbeats
+	HypervisorState   string `json:"hypervisorState,omitempty"`
+	HypervisorVersion string `json:"hypervisorVersion,omitempty"`
+
+	// Last time the hypervisor sent a heartbeat, the node and its GPUs become Unknown and are excluded
+	// from allocation when heartbeats go stale, until they resume
 	LastHeartbeatTime metav1.Time `json:"lastHeartbeatTime,omitempty"`
+
+	// +optional
+	// multi-process queuing config the running hypervisor is started with, empty when not enabled
+	MultiProcessQueuing *MultiProcessQueuing `json:"multiProcessQueuing,omitempty"`
 }
 
 // +kubebuilder:object:root=true
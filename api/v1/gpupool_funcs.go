@@ -0,0 +1,21 @@
+package v1
+
+import (
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// AllocatedPercent returns the percentage of the capacity allocated, formatted with 2 decimals
+func AllocatedPercent(total, available resource.Quantity) string {
+	totalValue := total.AsApproximateFloat64()
+	if totalValue <= 0 {
+		return FormatFloat(0)
+	}
+	return FormatFloat((totalValue - available.AsApproximateFloat64()) / totalValue * 100)
+}
+
+// FormatFloat formats percentages and costs in status with 2 decimals
+func FormatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
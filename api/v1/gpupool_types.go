@@ -151,6 +151,22 @@ type PeriodicalBudget struct {
 	BudgetExceedStrategy BudgetExceedStrategy `json:"budgetExceedStrategy,omitempty"`
 }
 
+// AccruedCost is the cost of deleted nodes in one budget period
+type AccruedCost struct {
+	// +kubebuilder:validation:Enum=day;month;quarter
+	Period string `json:"period"`
+
+	// Start of the period, costs of previous periods are dropped
+	PeriodStart metav1.Time `json:"periodStart"`
+
+	// Costs in dollars
+	Cost string `json:"cost"`
+
+	// +optional
+	// Deleted nodes whose costs are counted, so that they are not counted twice
+	Nodes []string `json:"nodes,omitempty"`
+}
+
 // +kubebuilder:validation:Enum=AlertOnly;AlertAndTerminateVM
 type BudgetExceedStrategy string
 
@@ -224,16 +240,25 @@ type NodeRollingUpdatePolicy struct {
 	// +kubebuilder:default="10m"
 	BatchInterval string `json:"batchInterval,omitempty"`
 
+	// Max time an update can spend on updating batches, waits between batches and outside maintenance windows
+	// are not counted. The update stops and is marked failed in pool conditions when exceeded, empty means no limit
 	// +optional
-	// +kubebuilder:default="10m"
 	MaxDuration string `json:"maxDuration,omitempty"`
 
 	// +optional
 	MaintenanceWindow MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+
+	// Number of updated pods failing to start that pauses the update, the update resumes when they recover,
+	// or can be rolled back to the previous version with the rollback annotation of the pool
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	FailureThreshold int32 `json:"failureThreshold,omitempty"`
 }
 
 type MaintenanceWindow struct {
-	// crontab syntax.
+	// crontab syntax, a batch only starts in the minutes matching any of the expressions,
+	// e.g. "* 2-4 * * 6" for 02:00 to 04:59 every Saturday, UTC unless prefixed with "CRON_TZ=<zone>"
 	Includes []string `json:"includes,omitempty"`
 }
 
@@ -384,24 +409,31 @@ type GPUPoolStatus struct {
 	// when the progress is 100, the component version or config is fully updated.
 	ComponentStatus PoolComponentStatus `json:"componentStatus"`
 
-	// TODO: calculated every 1h/1d/1w average
+	// Average usage of GPUs reported by hypervisor in recent minutes,
+	// allocated percentage is used when usage metrics are not available
 	UtilizedTFlopsPercent string `json:"utilizedTFlopsPercent,omitempty"`
 	UtilizedVRAMPercent   string `json:"utilizedVRAMPercent,omitempty"`
 
-	// TODO: updated with interval
+	// Percentage of the total capacity allocated to workers
 	AllocatedTFlopsPercent string `json:"allocatedTFlopsPercent,omitempty"`
 	AllocatedVRAMPercent   string `json:"allocatedVRAMPercent,omitempty"`
 
-	// TODO: aggregated with interval
-	SavedCostsPerMonth       string `json:"savedCostsPerMonth,omitempty"`
+	// Monthly costs of GPUs saved by sharing, workers would otherwise occupy dedicated GPUs
+	SavedCostsPerMonth string `json:"savedCostsPerMonth,omitempty"`
+	// Monthly costs of GPU capacity not allocated, which could be saved by compacting workers
 	PotentialSavingsPerMonth string `json:"potentialSavingsPerMonth,omitempty"`
 
 	// +kubebuilder:default=""
-	// If the budget is exceeded, the set value in comma separated string to indicate which period caused the exceeding.
-	// If this field is not empty, scheduler will not schedule new AI workloads and stop scaling-up check.
-	// TODO not implemented yet
+	// If the budget is exceeded, the set value in comma separated string to indicate which period caused the exceeding,
+	// could be day, month and quarter.
+	// If this field is not empty, node provisioner will stop scaling-up check.
 	BudgetExceeded string `json:"budgetExceeded,omitempty"`
 
+	// +optional
+	// Costs of provisioned nodes already deleted in the current budget periods,
+	// nodes still existing are billed from their creation time
+	AccruedCosts []AccruedCost `json:"accruedCosts,omitempty"`
+
 	// +optional
 	LastCompactionTime *metav1.Time `json:"lastCompactionTime,omitempty"`
 }
@@ -435,6 +467,31 @@ type PoolComponentStatus struct {
 	ClientVersion        string `json:"client,omitempty"`
 	ClientConfigSynced   bool   `json:"clientConfigSynced,omitempty"`
 	ClientUpdateProgress int32  `json:"clientUpdateProgress,omitempty"`
+
+	// Rolled out configs of components, keyed by component name: hypervisor, worker and client
+	// +optional
+	Revisions map[string]ComponentRevisions `json:"revisions,omitempty"`
+}
+
+type ComponentRevisions struct {
+	Current ComponentRevision `json:"current"`
+
+	// The revision rolled out before the current one, updates are rolled back to it
+	// +optional
+	Previous *ComponentRevision `json:"previous,omitempty"`
+
+	// Hash of the spec config rolled back, the current revision is rolled out instead of it
+	// until the spec config changes
+	// +optional
+	RolledBackFrom string `json:"rolledBackFrom,omitempty"`
+}
+
+type ComponentRevision struct {
+	// Config hash, same as the pod template hash label of pods rolled out with the config
+	Version string `json:"version"`
+
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Config runtime.RawExtension `json:"config"`
 }
 
 // GPUPool is the Schema for the gpupools API.
@@ -0,0 +1,105 @@
+package v1
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// AppliesTo returns whether workers allocated from the pool are counted by the quota
+func (quota *GPUResourceQuota) AppliesTo(poolName string) bool {
+	return quota.Spec.PoolName == "" || quota.Spec.PoolName == poolName
+}
+
+// Exceeded returns the resources beyond the hard limits once charge is added to used, empty when it fits
+func (quota *GPUResourceQuota) Exceeded(used, charge GPUResourceQuotaAmounts) []string {
+	exceeded := []string{}
+	check := func(name string, hard, used, charge *resource.Quantity) {
+		if hard == nil {
+			return
+		}
+		total := resource.Quantity{}
+		if used != nil {
+			total.Add(*used)
+		}
+		if charge != nil {
+			total.Add(*charge)
+		}
+		if total.Cmp(*hard) > 0 {
+			exceeded = append(exceeded, fmt.Sprintf("%s: %s/%s", name, total.String(), hard.String()))
+		}
+	}
+	hard := quota.Spec.Hard
+	check("requestsTflops", hard.RequestsTflops, used.RequestsTflops, charge.RequestsTflops)
+	check("requestsVram", hard.RequestsVram, used.RequestsVram, charge.RequestsVram)
+	check("limitsTflops", hard.LimitsTflops, used.LimitsTflops, charge.LimitsTflops)
+	check("limitsVram", hard.LimitsVram, used.LimitsVram, charge.LimitsVram)
+	if hard.Workers != nil {
+		total := int32(0)
+		if used.Workers != nil {
+			total += *used.Workers
+		}
+		if charge.Workers != nil {
+			total += *charge.Workers
+		}
+		if total > *hard.Workers {
+			exceeded = append(exceeded, fmt.Sprintf("workers: %d/%d", total, *hard.Workers))
+		}
+	}
+	return exceeded
+}
+
+// NewGPUResourceQuotaAmounts returns the amounts consumed by workers using gpuCount GPUs each with the resources
+func NewGPUResourceQuotaAmounts(resources Resources, gpuCount int64, workers int32) GPUResourceQuotaAmounts {
+	multiply := func(quantity resource.Quantity) *resource.Quantity {
+		total := resource.Quantity{}
+		for range gpuCount {
+			total.Add(quantity)
+		}
+		return &total
+	}
+	return GPUResourceQuotaAmounts{
+		RequestsTflops: multiply(resources.Requests.Tflops),
+		RequestsVram:   multiply(resources.Requests.Vram),
+		LimitsTflops:   multiply(resources.Limits.Tflops),
+		LimitsVram:     multiply(resources.Limits.Vram),
+		Workers:        &workers,
+	}
+}
+
+// Add adds other amounts to the amounts
+func (amounts *GPUResourceQuotaAmounts) Add(other GPUResourceQuotaAmounts) {
+	amounts.merge(other, 1)
+}
+
+// Sub subtracts other amounts from the amounts
+func (amounts *GPUResourceQuotaAmounts) Sub(other GPUResourceQuotaAmounts) {
+	amounts.merge(other, -1)
+}
+
+func (amounts *GPUResourceQuotaAmounts) merge(other GPUResourceQuotaAmounts, sign int32) {
+	merge := func(target **resource.Quantity, quantity *resource.Quantity) {
+		if quantity == nil {
+			return
+		}
+		if *target == nil {
+			*target = &resource.Quantity{}
+		}
+		if sign > 0 {
+			(*target).Add(*quantity)
+		} else {
+			(*target).Sub(*quantity)
+		}
+	}
+	merge(&amounts.RequestsTflops, other.RequestsTflops)
+	merge(&amounts.RequestsVram, other.RequestsVram)
+	merge(&amounts.LimitsTflops, other.LimitsTflops)
+	merge(&amounts.LimitsVram, other.LimitsVram)
+	if other.Workers != nil {
+		workers := sign * *other.Workers
+		if amounts.Workers != nil {
+			workers += *amounts.Workers
+		}
+		amounts.Workers = &workers
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GPUResourceQuotaSpec defines the desired state of GPUResourceQuota.
+type GPUResourceQuotaSpec struct {
+	// Name of the GPU pool the quota applies to, empty means the quota applies to all pools
+	// +optional
+	PoolName string `json:"poolName,omitempty"`
+
+	// Hard limits of GPU resources all workers in the namespace can consume,
+	// resources not set are not limited
+	Hard GPUResourceQuotaAmounts `json:"hard"`
+}
+
+// GPUResourceQuotaAmounts is the amount of GPU resources consumed by workers,
+// requests and limits are summed over every GPU allocated to the workers
+type GPUResourceQuotaAmounts struct {
+	// +optional
+	RequestsTflops *resource.Quantity `json:"requestsTflops,omitempty"`
+
+	// +optional
+	RequestsVram *resource.Quantity `json:"requestsVram,omitempty"`
+
+	// +optional
+	LimitsTflops *resource.Quantity `json:"limitsTflops,omitempty"`
+
+	// +optional
+	LimitsVram *resource.Quantity `json:"limitsVram,omitempty"`
+
+	// Number of worker pods
+	// +optional
+	Workers *int32 `json:"workers,omitempty"`
+}
+
+// GPUResourceQuotaStatus defines the observed state of GPUResourceQuota.
+type GPUResourceQuotaStatus struct {
+	// Resources currently consumed by workers in the namespace
+	// +optional
+	Used GPUResourceQuotaAmounts `json:"used,omitempty"`
+
+	// +optional
+	LastUpdateTime *metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Pool",type="string",JSONPath=".spec.poolName"
+// +kubebuilder:printcolumn:name="Used TFlops",type="string",JSONPath=".status.used.requestsTflops"
+// +kubebuilder:printcolumn:name="Hard TFlops",type="string",JSONPath=".spec.hard.requestsTflops"
+// +kubebuilder:printcolumn:name="Used Workers",type="integer",JSONPath=".status.used.workers"
+// GPUResourceQuota is the Schema for the gpuresourcequotas API.
+type GPUResourceQuota struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GPUResourceQuotaSpec   `json:"spec,omitempty"`
+	Status GPUResourceQuotaStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// GPUResourceQuotaList contains a list of GPUResourceQuota.
+type GPUResourceQuotaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GPUResourceQuota `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GPUResourceQuota{}, &GPUResourceQuotaList{})
+}
@@ -68,16 +68,26 @@ const (
 //
 // example:
 // ```yaml
-// - type: avoidTooMuchConnectionsOnSameGPU
+// - type: avoidTooManyLocalProcesses
 // params:
 //
-//	connectionNum: 150
+//	maxProcesses: 150
 //
-// - type: avoidDifferentZone
+// - type: temperatureCeiling
 // params:
 //
-//	# by default, GPU worker will be scheduled into the same zone as CPU Client Pod to align AZ and improve performance
-//	topologyKey: topology.kubernetes.io/zone
+//	maxTemperature: 85
+//
+// - type: matchLabels
+// params:
+//
+//	labels:
+//	  topology.kubernetes.io/zone: us-west-1a
+//
+// - type: excludeUUIDs
+// params:
+//
+//	uuids: ["gpu-7e5cd0b1-..."]
 //
 // ```
 type GPUFilter struct {
@@ -188,6 +198,8 @@ type ReBalancerConfig struct {
 }
 
 type ReBalanceThreshold struct {
+	// GPUs are hot when any of their average usage in the interval exceeds the threshold,
+	// such as {"computePercent": 90, "vramPercent": 95, "temperature": 85}
 	MatchAny runtime.RawExtension `json:"matchAny,omitempty"`
 }
 
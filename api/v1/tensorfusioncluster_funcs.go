@@ -17,6 +17,8 @@ limitations under the License.
 package v1
 
 import (
+	"strconv"
+
 	"github.com/NexusGPU/tensor-fusion/internal/constants"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -120,6 +122,9 @@ func (tfc *TensorFusionCluster) RefreshStatus(ownedPools []GPUPool) {
 	tfc.Status.VirtualAvailableTFlops = &resource.Quantity{}
 	tfc.Status.VirtualAvailableVRAM = &resource.Quantity{}
 
+	savedCosts, potentialSavings := 0.0, 0.0
+	// pool utilization weighted by pool capacity
+	utilizedTFlops, utilizedVRAM := 0.0, 0.0
 	for i, gpuPool := range ownedPools {
 		if gpuPool.Status.Phase != constants.PhaseRunning {
 			tfc.Status.NotReadyGPUPools = append(tfc.Status.NotReadyGPUPools, gpuPool.Name)
@@ -142,5 +147,29 @@ func (tfc *TensorFusionCluster) RefreshStatus(ownedPools []GPUPool) {
 		if gpuPool.Status.VirtualAvailableVRAM != nil {
 			tfc.Status.VirtualAvailableVRAM.Add(*gpuPool.Status.VirtualAvailableVRAM)
 		}
+
+		if cost, err := strconv.ParseFloat(gpuPool.Status.SavedCostsPerMonth, 64); err == nil {
+			savedCosts += cost
+		}
+		if cost, err := strconv.ParseFloat(gpuPool.Status.PotentialSavingsPerMonth, 64); err == nil {
+			potentialSavings += cost
+		}
+		if percent, err := strconv.ParseFloat(gpuPool.Status.UtilizedTFlopsPercent, 64); err == nil {
+			utilizedTFlops += percent * gpuPool.Status.TotalTFlops.AsApproximateFloat64()
+		}
+		if percent, err := strconv.ParseFloat(gpuPool.Status.UtilizedVRAMPercent, 64); err == nil {
+			utilizedVRAM += percent * gpuPool.Status.TotalVRAM.AsApproximateFloat64()
+		}
+	}
+
+	tfc.Status.AllocatedTFlopsPercent = AllocatedPercent(tfc.Status.TotalTFlops, tfc.Status.AvailableTFlops)
+	tfc.Status.AllocatedVRAMPercent = AllocatedPercent(tfc.Status.TotalVRAM, tfc.Status.AvailableVRAM)
+	if totalTFlops := tfc.Status.TotalTFlops.AsApproximateFloat64(); totalTFlops > 0 {
+		tfc.Status.UtilizedTFlopsPercent = FormatFloat(utilizedTFlops / totalTFlops)
+	}
+	if totalVRAM := tfc.Status.TotalVRAM.AsApproximateFloat64(); totalVRAM > 0 {
+		tfc.Status.UtilizedVRAMPercent = FormatFloat(utilizedVRAM / totalVRAM)
 	}
+	tfc.Status.SavedCostsPerMonth = FormatFloat(savedCosts)
+	tfc.Status.PotentialSavingsPerMonth = FormatFloat(potentialSavings)
 }
@@ -73,16 +73,18 @@ type TensorFusionClusterStatus struct {
 	//
 	RetryCount int64 `json:"retryCount"`
 
-	// TODO: calculated every 1h/1d/1w average
+	// Average usage of GPUs reported by hypervisor in recent minutes,
+	// allocated percentage is used when usage metrics are not available
 	UtilizedTFlopsPercent string `json:"utilizedTFlopsPercent,omitempty"`
 	UtilizedVRAMPercent   string `json:"utilizedVRAMPercent,omitempty"`
 
-	// TODO: updated with interval
+	// Percentage of the total capacity allocated to workers
 	AllocatedTFlopsPercent string `json:"allocatedTFlopsPercent,omitempty"`
 	AllocatedVRAMPercent   string `json:"allocatedVRAMPercent,omitempty"`
 
-	// TODO: aggregated with interval
-	SavedCostsPerMonth       string `json:"savedCostsPerMonth,omitempty"`
+	// Monthly costs of GPUs saved by sharing, workers would otherwise occupy dedicated GPUs
+	SavedCostsPerMonth string `json:"savedCostsPerMonth,omitempty"`
+	// Monthly costs of GPU capacity not allocated, which could be saved by compacting workers
 	PotentialSavingsPerMonth string `json:"potentialSavingsPerMonth,omitempty"`
 
 	CloudVendorConfigHash string `json:"cloudVendorConfigHash,omitempty"`
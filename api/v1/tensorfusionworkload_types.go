@@ -26,6 +26,11 @@ const (
 	WorkerPending WorkerPhase = "Pending"
 	WorkerRunning WorkerPhase = "Running"
 	WorkerFailed  WorkerPhase = "Failed"
+
+	// GPU context of an idle worker is frozen to host memory, GPUs of the worker are released
+	WorkerFrozenToMem WorkerPhase = "FrozenToMem"
+	// GPU context of an idle worker is frozen to disk, GPUs of the worker are released
+	WorkerFrozenToDisk WorkerPhase = "FrozenToDisk"
 )
 
 type WorkerStatus struct {
@@ -39,6 +44,16 @@ type WorkerStatus struct {
 	WorkerPort int `json:"workerPort,omitempty"`
 	// +optional
 	ResourceVersion string `json:"resourceVersion,omitempty"`
+
+	// Workers of the same replica share the same ReplicaID, only set when GPUs are spread over multiple nodes
+	// +optional
+	ReplicaID string `json:"replicaId,omitempty"`
+	// Rank of the worker inside its replica, ordered by node name
+	// +optional
+	Rank int `json:"rank,omitempty"`
+	// Names of GPUs used by the worker
+	// +optional
+	GPUs []string `json:"gpus,omitempty"`
 }
 
 // +kubebuilder:validation:Enum=Pending;Running;Failed;Unknown
@@ -71,6 +86,21 @@ type TensorFusionWorkloadStatus struct {
 
 	// Hash of the pod template used to create worker pods
 	PodTemplateHash string `json:"podTemplateHash,omitempty"`
+
+	// Position of the workload in its pool's pending allocation queue, starts from 1, 0 means not queued
+	// +optional
+	QueuePosition int32 `json:"queuePosition,omitempty"`
+
+	// Requests recommended by auto set requests based on historical usage of workers
+	// +optional
+	Recommendation *ResourceRecommendation `json:"recommendation,omitempty"`
+}
+
+type ResourceRecommendation struct {
+	// Percentile of actual usage plus extra buffer
+	Requests Resource `json:"requests"`
+
+	LastUpdateTime metav1.Time `json:"lastUpdateTime"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,129 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WorkerMigrationSpec defines the desired state of WorkerMigration.
+type WorkerMigrationSpec struct {
+	// Name of the worker pod to migrate, in the same namespace as the migration
+	WorkerName string `json:"workerName"`
+
+	// GPUs to start the new worker on, the count must match the GPU count of the workload,
+	// when empty, the allocator selects GPUs other than the ones the worker is running on
+	// +optional
+	TargetGPUs []string `json:"targetGPUs,omitempty"`
+
+	// GPUs not to start the new worker on besides the ones it is running on, ignored when target GPUs are set
+	// +optional
+	ExcludedGPUs []string `json:"excludedGPUs,omitempty"`
+
+	// GPUNode to start the new worker on, ignored when target GPUs are set
+	// +optional
+	TargetNode string `json:"targetNode,omitempty"`
+
+	// How long the new worker can take to become ready before the migration is rolled back
+	// +kubebuilder:default="5m"
+	// +optional
+	StartTimeout string `json:"startTimeout,omitempty"`
+
+	// How long the old worker is kept after connections are switched, so that in-flight requests can finish
+	// +kubebuilder:default="30s"
+	// +optional
+	DrainTimeout string `json:"drainTimeout,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=Pending;StartingWorker;SwitchingConnections;Draining;Succeeded;RollingBack;Failed
+type WorkerMigrationPhase string
+
+const (
+	WorkerMigrationPending              WorkerMigrationPhase = "Pending"
+	WorkerMigrationStartingWorker       WorkerMigrationPhase = "StartingWorker"
+	WorkerMigrationSwitchingConnections WorkerMigrationPhase = "SwitchingConnections"
+	WorkerMigrationDraining             WorkerMigrationPhase = "Draining"
+	WorkerMigrationSucceeded            WorkerMigrationPhase = "Succeeded"
+	WorkerMigrationRollingBack          WorkerMigrationPhase = "RollingBack"
+	WorkerMigrationFailed               WorkerMigrationPhase = "Failed"
+)
+
+// WorkerMigrationStatus defines the observed state of WorkerMigration.
+type WorkerMigrationStatus struct {
+	// +kubebuilder:default=Pending
+	Phase WorkerMigrationPhase `json:"phase,omitempty"`
+
+	// Reason of the current phase, set when the migration is rolled back
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Name of the workload the worker belongs to
+	// +optional
+	WorkloadName string `json:"workloadName,omitempty"`
+
+	// +optional
+	SourceGPUs []string `json:"sourceGPUs,omitempty"`
+
+	// Name of the new worker pod started on the target GPUs
+	// +optional
+	TargetWorkerName string `json:"targetWorkerName,omitempty"`
+
+	// +optional
+	TargetGPUs []string `json:"targetGPUs,omitempty"`
+
+	// Connections switched from the old worker to the new one, switched back when rolling back
+	// +optional
+	SwitchedConnections []string `json:"switchedConnections,omitempty"`
+
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// Time the current phase is entered
+	// +optional
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Worker",type="string",JSONPath=".spec.workerName"
+// +kubebuilder:printcolumn:name="Target Worker",type="string",JSONPath=".status.targetWorkerName"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// WorkerMigration is the Schema for the workermigrations API.
+type WorkerMigration struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WorkerMigrationSpec   `json:"spec,omitempty"`
+	Status WorkerMigrationStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// WorkerMigrationList contains a list of WorkerMigration.
+type WorkerMigrationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WorkerMigration `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WorkerMigration{}, &WorkerMigrationList{})
+}
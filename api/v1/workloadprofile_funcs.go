@@ -0,0 +1,7 @@
+package v1
+
+// EmbeddedWorker returns whether the vGPU worker runs as a sidecar of each client pod
+// instead of standalone worker pods, only available in local GPU mode
+func (s *WorkloadProfileSpec) EmbeddedWorker() bool {
+	return s.IsLocalGPU && !s.StandaloneWorkerMode
+}
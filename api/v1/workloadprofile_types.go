@@ -60,7 +60,19 @@ type WorkloadProfileSpec struct {
 	GPUCount uint `json:"gpuCount,omitempty"`
 
 	// +optional
-	// This mode is only available when `is-local-gpu` set to true, in this mode, TensorFusion will also inject vGPU worker into init container, so that to achieve best performance, trade-off is user might by-pass the vGPU worker and using physical GPU directly
+	// Allow GPUs of one replica to be spread over multiple nodes when GPUCount exceeds GPUs of a single node,
+	// one worker will be started on each node, default to false
+	CrossNodeGPUs bool `json:"crossNodeGPUs,omitempty"`
+
+	// +optional
+	// All-or-nothing scheduling, GPUs of all replicas are allocated at once or none of them,
+	// workload stays Pending until the whole gang fits, default to false
+	GangScheduling bool `json:"gangScheduling,omitempty"`
+
+	// +optional
+	// Run the vGPU worker as standalone worker pods, set by the pod webhook and default to true.
+	// When false, which is only available when `is-local-gpu` set to true, TensorFusion allocates GPUs for each client pod
+	// and injects the vGPU worker into it as a sidecar, so that to achieve best performance, trade-off is user might by-pass the vGPU worker and using physical GPU directly
 	StandaloneWorkerMode bool `json:"standaloneWorkerMode,omitempty"`
 
 	// +optional
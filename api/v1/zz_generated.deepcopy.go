@@ -26,6 +26,27 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccruedCost) DeepCopyInto(out *AccruedCost) {
+	*out = *in
+	in.PeriodStart.DeepCopyInto(&out.PeriodStart)
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccruedCost.
+func (in *AccruedCost) DeepCopy() *AccruedCost {
+	if in == nil {
+		return nil
+	}
+	out := new(AccruedCost)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AlertConfig) DeepCopyInto(out *AlertConfig) {
 	*out = *in
@@ -231,6 +252,43 @@ func (in *ComponentConfig) DeepCopy() *ComponentConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComponentRevision) DeepCopyInto(out *ComponentRevision) {
+	*out = *in
+	in.Config.DeepCopyInto(&out.Config)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComponentRevision.
+func (in *ComponentRevision) DeepCopy() *ComponentRevision {
+	if in == nil {
+		return nil
+	}
+	out := new(ComponentRevision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComponentRevisions) DeepCopyInto(out *ComponentRevisions) {
+	*out = *in
+	in.Current.DeepCopyInto(&out.Current)
+	if in.Previous != nil {
+		in, out := &in.Previous, &out.Previous
+		*out = new(ComponentRevision)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComponentRevisions.
+func (in *ComponentRevisions) DeepCopy() *ComponentRevisions {
+	if in == nil {
+		return nil
+	}
+	out := new(ComponentRevisions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ComputingVendorConfig) DeepCopyInto(out *ComputingVendorConfig) {
 	*out = *in
@@ -576,6 +634,26 @@ func (in *GPUNodeClassStatus) DeepCopy() *GPUNodeClassStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GPUNodeDrainStatus) DeepCopyInto(out *GPUNodeDrainStatus) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GPUNodeDrainStatus.
+func (in *GPUNodeDrainStatus) DeepCopy() *GPUNodeDrainStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GPUNodeDrainStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GPUNodeInfo) DeepCopyInto(out *GPUNodeInfo) {
 	*out = *in
@@ -698,6 +776,11 @@ func (in *GPUNodeStatus) DeepCopyInto(out *GPUNodeStatus) {
 			}
 		}
 	}
+	if in.Drain != nil {
+		in, out := &in.Drain, &out.Drain
+		*out = new(GPUNodeDrainStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GPUNodeStatus.
@@ -875,7 +958,14 @@ func (in *GPUPoolStatus) DeepCopyInto(out *GPUPoolStatus) {
 		x := (*in).DeepCopy()
 		*out = &x
 	}
-	out.ComponentStatus = in.ComponentStatus
+	in.ComponentStatus.DeepCopyInto(&out.ComponentStatus)
+	if in.AccruedCosts != nil {
+		in, out := &in.AccruedCosts, &out.AccruedCosts
+		*out = make([]AccruedCost, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.LastCompactionTime != nil {
 		in, out := &in.LastCompactionTime, &out.LastCompactionTime
 		*out = (*in).DeepCopy()
@@ -907,6 +997,141 @@ func (in *GPUResourcePricingUnit) DeepCopy() *GPUResourcePricingUnit {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GPUResourceQuota) DeepCopyInto(out *GPUResourceQuota) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GPUResourceQuota.
+func (in *GPUResourceQuota) DeepCopy() *GPUResourceQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(GPUResourceQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GPUResourceQuota) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GPUResourceQuotaAmounts) DeepCopyInto(out *GPUResourceQuotaAmounts) {
+	*out = *in
+	if in.RequestsTflops != nil {
+		in, out := &in.RequestsTflops, &out.RequestsTflops
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.RequestsVram != nil {
+		in, out := &in.RequestsVram, &out.RequestsVram
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.LimitsTflops != nil {
+		in, out := &in.LimitsTflops, &out.LimitsTflops
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.LimitsVram != nil {
+		in, out := &in.LimitsVram, &out.LimitsVram
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Workers != nil {
+		in, out := &in.Workers, &out.Workers
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GPUResourceQuotaAmounts.
+func (in *GPUResourceQuotaAmounts) DeepCopy() *GPUResourceQuotaAmounts {
+	if in == nil {
+		return nil
+	}
+	out := new(GPUResourceQuotaAmounts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GPUResourceQuotaList) DeepCopyInto(out *GPUResourceQuotaList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GPUResourceQuota, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GPUResourceQuotaList.
+func (in *GPUResourceQuotaList) DeepCopy() *GPUResourceQuotaList {
+	if in == nil {
+		return nil
+	}
+	out := new(GPUResourceQuotaList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GPUResourceQuotaList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GPUResourceQuotaSpec) DeepCopyInto(out *GPUResourceQuotaSpec) {
+	*out = *in
+	in.Hard.DeepCopyInto(&out.Hard)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GPUResourceQuotaSpec.
+func (in *GPUResourceQuotaSpec) DeepCopy() *GPUResourceQuotaSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GPUResourceQuotaSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GPUResourceQuotaStatus) DeepCopyInto(out *GPUResourceQuotaStatus) {
+	*out = *in
+	in.Used.DeepCopyInto(&out.Used)
+	if in.LastUpdateTime != nil {
+		in, out := &in.LastUpdateTime, &out.LastUpdateTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GPUResourceQuotaStatus.
+func (in *GPUResourceQuotaStatus) DeepCopy() *GPUResourceQuotaStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GPUResourceQuotaStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GPUResourceUnit) DeepCopyInto(out *GPUResourceUnit) {
 	*out = *in
@@ -937,6 +1162,16 @@ func (in *GPUStatus) DeepCopyInto(out *GPUStatus) {
 		*out = new(Resource)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.VirtualCapacity != nil {
+		in, out := &in.VirtualCapacity, &out.VirtualCapacity
+		*out = new(Resource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VirtualAvailable != nil {
+		in, out := &in.VirtualAvailable, &out.VirtualAvailable
+		*out = new(Resource)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.NodeSelector != nil {
 		in, out := &in.NodeSelector, &out.NodeSelector
 		*out = make(map[string]string, len(*in))
@@ -955,6 +1190,18 @@ func (in *GPUStatus) DeepCopyInto(out *GPUStatus) {
 			}
 		}
 	}
+	if in.Temperature != nil {
+		in, out := &in.Temperature, &out.Temperature
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GPUStatus.
@@ -1185,6 +1432,11 @@ func (in *NodeDiscoveryConfig) DeepCopy() *NodeDiscoveryConfig {
 func (in *NodeHypervisorStatus) DeepCopyInto(out *NodeHypervisorStatus) {
 	*out = *in
 	in.LastHeartbeatTime.DeepCopyInto(&out.LastHeartbeatTime)
+	if in.MultiProcessQueuing != nil {
+		in, out := &in.MultiProcessQueuing, &out.MultiProcessQueuing
+		*out = new(MultiProcessQueuing)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeHypervisorStatus.
@@ -1396,6 +1648,13 @@ func (in *PlacementConfig) DeepCopy() *PlacementConfig {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PoolComponentStatus) DeepCopyInto(out *PoolComponentStatus) {
 	*out = *in
+	if in.Revisions != nil {
+		in, out := &in.Revisions, &out.Revisions
+		*out = make(map[string]ComponentRevisions, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolComponentStatus.
@@ -1574,6 +1833,23 @@ func (in *Resource) DeepCopy() *Resource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceRecommendation) DeepCopyInto(out *ResourceRecommendation) {
+	*out = *in
+	in.Requests.DeepCopyInto(&out.Requests)
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceRecommendation.
+func (in *ResourceRecommendation) DeepCopy() *ResourceRecommendation {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceRecommendation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Resources) DeepCopyInto(out *Resources) {
 	*out = *in
@@ -2104,6 +2380,11 @@ func (in *TensorFusionWorkloadStatus) DeepCopyInto(out *TensorFusionWorkloadStat
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Recommendation != nil {
+		in, out := &in.Recommendation, &out.Recommendation
+		*out = new(ResourceRecommendation)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TensorFusionWorkloadStatus.
@@ -2136,6 +2417,132 @@ func (in *WorkerConfig) DeepCopy() *WorkerConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkerMigration) DeepCopyInto(out *WorkerMigration) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkerMigration.
+func (in *WorkerMigration) DeepCopy() *WorkerMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkerMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WorkerMigration) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkerMigrationList) DeepCopyInto(out *WorkerMigrationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]WorkerMigration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkerMigrationList.
+func (in *WorkerMigrationList) DeepCopy() *WorkerMigrationList {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkerMigrationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WorkerMigrationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkerMigrationSpec) DeepCopyInto(out *WorkerMigrationSpec) {
+	*out = *in
+	if in.TargetGPUs != nil {
+		in, out := &in.TargetGPUs, &out.TargetGPUs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludedGPUs != nil {
+		in, out := &in.ExcludedGPUs, &out.ExcludedGPUs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkerMigrationSpec.
+func (in *WorkerMigrationSpec) DeepCopy() *WorkerMigrationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkerMigrationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkerMigrationStatus) DeepCopyInto(out *WorkerMigrationStatus) {
+	*out = *in
+	if in.SourceGPUs != nil {
+		in, out := &in.SourceGPUs, &out.SourceGPUs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TargetGPUs != nil {
+		in, out := &in.TargetGPUs, &out.TargetGPUs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SwitchedConnections != nil {
+		in, out := &in.SwitchedConnections, &out.SwitchedConnections
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkerMigrationStatus.
+func (in *WorkerMigrationStatus) DeepCopy() *WorkerMigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkerMigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkerStatus) DeepCopyInto(out *WorkerStatus) {
 	*out = *in
@@ -2146,6 +2553,11 @@ func (in *WorkerStatus) DeepCopyInto(out *WorkerStatus) {
 			(*out)[key] = val
 		}
 	}
+	if in.GPUs != nil {
+		in, out := &in.GPUs, &out.GPUs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkerStatus.
@@ -21,8 +21,10 @@ import (
 	"crypto/tls"
 	"flag"
 	"fmt"
+	"maps"
 	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
@@ -44,13 +46,17 @@ import (
 
 	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
 	"github.com/NexusGPU/tensor-fusion/internal/alert"
+	"github.com/NexusGPU/tensor-fusion/internal/autoscaler"
 	"github.com/NexusGPU/tensor-fusion/internal/config"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
 	"github.com/NexusGPU/tensor-fusion/internal/controller"
 	"github.com/NexusGPU/tensor-fusion/internal/gpuallocator"
 	"github.com/NexusGPU/tensor-fusion/internal/metrics"
 	"github.com/NexusGPU/tensor-fusion/internal/portallocator"
+	"github.com/NexusGPU/tensor-fusion/internal/rebalancer"
 	"github.com/NexusGPU/tensor-fusion/internal/server"
 	"github.com/NexusGPU/tensor-fusion/internal/server/router"
+	"github.com/NexusGPU/tensor-fusion/internal/utilization"
 	"github.com/NexusGPU/tensor-fusion/internal/utils"
 	"github.com/NexusGPU/tensor-fusion/internal/version"
 	webhookcorev1 "github.com/NexusGPU/tensor-fusion/internal/webhook/v1"
@@ -79,10 +85,14 @@ var nodeLevelPortRange string
 var clusterLevelPortRange string
 var enableAlert bool
 var alertManagerAddr string
+var nodeDiscoveryInterval time.Duration
 var timeSeriesDB *metrics.TimeSeriesDB
 var dynamicConfigPath string
 var globalConfig config.GlobalConfig
 var alertEvaluator *alert.AlertEvaluator
+var autoScaler *autoscaler.Autoscaler
+var reBalancer *rebalancer.ReBalancer
+var utilizationUpdater *utilization.Updater
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
@@ -115,6 +125,8 @@ func main() {
 	flag.BoolVar(&enableAlert, "enable-alert", false, "if turn on alert, "+
 		"TensorFusion will generate alerts with built-in rules, alert rules are managed in"+
 		" configMap `tensor-fusion-alert-rules` of TensorFusion system namespace")
+	flag.DurationVar(&nodeDiscoveryInterval, "node-discovery-interval", constants.DefaultNodeDiscoveryInterval,
+		"interval of node discovery to rediscover GPUs and report their health")
 	flag.StringVar(&alertManagerAddr, "alert-manager-addr",
 		"alertmanager.tensor-fusion-sys.svc.cluster.local:9093",
 		"specify the alert manager address, TensorFusion will generate alerts with "+
@@ -153,7 +165,8 @@ func main() {
 	})
 
 	gpuInfos := make([]config.GpuInfo, 0)
-	gpuPricingMap := make(map[string]float64)
+	gpuPricingMap := &atomic.Pointer[map[string]float64]{}
+	gpuPricingMap.Store(&map[string]float64{})
 	startWatchGPUInfoChanges(ctx, &gpuInfos, gpuPricingMap)
 
 	metricsServerOptions := metricsserver.Options{
@@ -192,15 +205,6 @@ func main() {
 		os.Exit(1)
 	}
 
-	// global config includes metrics table ttl / alert rules
-	// when changed, handle with different functions
-	go setupTimeSeriesAndWatchGlobalConfigChanges(ctx, mgr)
-
-	if autoScaleEnabled {
-		// TODO init auto scale module
-		setupLog.Info("auto scale enabled")
-	}
-
 	metricsRecorder := metrics.MetricsRecorder{
 		MetricsOutputPath:  metricsPath,
 		HourlyUnitPriceMap: gpuPricingMap,
@@ -219,6 +223,15 @@ func main() {
 		os.Exit(1)
 	}
 
+	// auto scale module starts after time series db is ready
+	autoScaler = autoscaler.NewAutoscaler(mgr.GetClient(), mgr.GetEventRecorderFor("Autoscaler"), allocator)
+	reBalancer = rebalancer.NewReBalancer(mgr.GetClient(), mgr.GetEventRecorderFor("ReBalancer"))
+	utilizationUpdater = utilization.NewUpdater(mgr.GetClient())
+
+	// global config includes metrics table ttl / alert rules
+	// when changed, handle with different functions
+	go setupTimeSeriesAndWatchGlobalConfigChanges(ctx, mgr)
+
 	// Initialize Port allocator and set up watches
 	portAllocator, err := portallocator.NewPortAllocator(ctx, mgr.GetClient(), nodeLevelPortRange, clusterLevelPortRange)
 	if err != nil {
@@ -266,6 +279,8 @@ func main() {
 		Client:   mgr.GetClient(),
 		Scheme:   mgr.GetScheme(),
 		Recorder: mgr.GetEventRecorderFor("GPUPool"),
+
+		GPUPricingMap: gpuPricingMap,
 	}
 	if err = GPUPoolReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "GPUPool")
@@ -273,9 +288,10 @@ func main() {
 	}
 
 	if err = (&controller.GPUNodeReconciler{
-		Client:   mgr.GetClient(),
-		Scheme:   mgr.GetScheme(),
-		Recorder: mgr.GetEventRecorderFor("GPUNode"),
+		Client:                mgr.GetClient(),
+		Scheme:                mgr.GetScheme(),
+		Recorder:              mgr.GetEventRecorderFor("GPUNode"),
+		NodeDiscoveryInterval: nodeDiscoveryInterval,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "GPUNode")
 		os.Exit(1)
@@ -306,6 +322,9 @@ func main() {
 		Client:        mgr.GetClient(),
 		Scheme:        mgr.GetScheme(),
 		PortAllocator: portAllocator,
+		Allocator:     allocator,
+		Recorder:      mgr.GetEventRecorderFor("pod"),
+		GpuInfos:      &gpuInfos,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Pod")
 		os.Exit(1)
@@ -337,6 +356,25 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "TensorFusionWorkload")
 		os.Exit(1)
 	}
+	if err = (&controller.GPUResourceQuotaReconciler{
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Allocator: allocator,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GPUResourceQuota")
+		os.Exit(1)
+	}
+	if err = (&controller.WorkerMigrationReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		Recorder:      mgr.GetEventRecorderFor("workermigration"),
+		Allocator:     allocator,
+		PortAllocator: portAllocator,
+		GpuInfos:      &gpuInfos,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "WorkerMigration")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
@@ -363,7 +401,12 @@ func main() {
 		setupLog.Error(err, "failed to create assign host port router")
 		os.Exit(1)
 	}
-	httpServer := server.NewHTTPServer(connectionRouter, assignHostPortRouter)
+	heartbeatRouter, err := router.NewHeartbeatRouter(ctx, client)
+	if err != nil {
+		setupLog.Error(err, "failed to create heartbeat router")
+		os.Exit(1)
+	}
+	httpServer := server.NewHTTPServer(connectionRouter, assignHostPortRouter, heartbeatRouter)
 	go func() {
 		err := httpServer.Run()
 		if err != nil {
@@ -406,10 +449,18 @@ func setupTimeSeriesAndWatchGlobalConfigChanges(ctx context.Context, mgr manager
 			autoScaleEnabled = true
 			alertCanBeEnabled = true
 
+			go autoScaler.Start(ctx, timeSeriesDB)
+			setupLog.Info("auto scale enabled")
+
+			go reBalancer.Start(ctx, timeSeriesDB)
+
 			setupLog.Info("time series db setup successfully.")
 		}
 	}
 
+	// utilization falls back to allocated percentage when time series db is not available
+	go utilizationUpdater.Start(ctx, timeSeriesDB)
+
 	alertEvaluator = alert.NewAlertEvaluator(ctx, timeSeriesDB, globalConfig.AlertRules, alertManagerAddr)
 
 	ch, err := utils.WatchConfigFileChanges(ctx, dynamicConfigPath)
@@ -459,7 +510,7 @@ func startMetricsRecorder(enableLeaderElection bool, mgr manager.Manager, metric
 	}
 }
 
-func startWatchGPUInfoChanges(ctx context.Context, gpuInfos *[]config.GpuInfo, gpuPricingMap map[string]float64) {
+func startWatchGPUInfoChanges(ctx context.Context, gpuInfos *[]config.GpuInfo, gpuPricingMap *atomic.Pointer[map[string]float64]) {
 	ch, err := utils.WatchConfigFileChanges(ctx, gpuInfoConfig)
 	if err != nil {
 		ctrl.Log.Error(err, "unable to watch gpuInfo file, "+
@@ -476,9 +527,12 @@ func startWatchGPUInfoChanges(ctx context.Context, gpuInfos *[]config.GpuInfo, g
 				continue
 			}
 			*gpuInfos = updatedGpuInfos
+			// the pricing map is read by controllers and the metrics recorder, swap in a copy instead of writing in place
+			updatedPricingMap := maps.Clone(*gpuPricingMap.Load())
 			for _, gpuInfo := range updatedGpuInfos {
-				gpuPricingMap[gpuInfo.FullModelName] = gpuInfo.CostPerHour
+				updatedPricingMap[gpuInfo.FullModelName] = gpuInfo.CostPerHour
 			}
+			gpuPricingMap.Store(&updatedPricingMap)
 		}
 	}()
 }
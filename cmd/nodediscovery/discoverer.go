@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+)
+
+const (
+	DiscoveryBackendNVML = "nvml"
+	DiscoveryBackendROCm = "rocm"
+	DiscoveryBackendFake = "fake"
+)
+
+// Device is a GPU card found on the host
+type Device struct {
+	// index of the card on the host, the one gpuCardIndices of GPUNode refers to
+	Index int    `json:"index"`
+	UUID  string `json:"uuid"`
+	// full model name, matched against fullModelName of the GPU info config
+	Model string `json:"model"`
+	// total VRAM in bytes
+	VRAM uint64 `json:"vram"`
+
+	// health of the card, fields not supported by the backend or the card are left empty
+	Temperature *int32 `json:"temperature,omitempty"`
+	// uncorrected ECC errors since the driver was loaded
+	ECCErrors uint64 `json:"eccErrors,omitempty"`
+	// critical XID errors seen since discovery started
+	XIDErrors []uint64 `json:"xidErrors,omitempty"`
+	// reasons of clocks being slowed down, e.g. HwThermalSlowdown
+	ThrottleReasons []string `json:"throttleReasons,omitempty"`
+
+	// set when the card is found but can not be read, e.g. it has fallen off the bus, only Index and
+	// UUID are known then, UUID is empty when even it can not be read
+	ReadError string `json:"readError,omitempty"`
+}
+
+// DeviceDiscoverer lists GPU cards and their health through vendor libraries or tools,
+// devices failing to be read are listed with ReadError set
+type DeviceDiscoverer interface {
+	Init() error
+	Shutdown() error
+	Devices() ([]Device, error)
+}
+
+// defaultMaxTemperature returns the temperature in Celsius at which GPUs of the backend are Overheating,
+// AMD GPUs report the junction temperature, the hottest spot of the die, which runs hotter than the
+// GPU core temperature NVIDIA GPUs report
+func defaultMaxTemperature(backend string) int {
+	if backend == DiscoveryBackendROCm {
+		return defaultMaxJunctionTemperature
+	}
+	return defaultMaxCoreTemperature
+}
+
+// NewDeviceDiscoverer returns the discoverer of the backend, fakeDevicesFile is only used by the fake backend
+func NewDeviceDiscoverer(backend string, fakeDevicesFile string) (DeviceDiscoverer, error) {
+	switch backend {
+	case DiscoveryBackendNVML, "":
+		return &nvmlDiscoverer{}, nil
+	case DiscoveryBackendROCm:
+		return newROCmDiscoverer(), nil
+	case DiscoveryBackendFake:
+		if fakeDevicesFile == "" {
+			return nil, fmt.Errorf("fake discovery backend requires a devices file")
+		}
+		return &fakeDiscoverer{file: fakeDevicesFile}, nil
+	default:
+		return nil, fmt.Errorf("unknown discovery backend %q", backend)
+	}
+}
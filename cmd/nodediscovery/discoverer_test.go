@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestParseROCmSMIDevices(t *testing.T) {
+	out := []byte(`{
+		"card1": {"card series": "AMD Radeon RX 7900 XTX", "Unique ID": "0xABC", "VRAM Total Memory (B)": "25753026560"},
+		"card0": {"Card Series": "AMD Instinct MI300X", "Unique ID": "0x18f68e602b8a790f", "VRAM Total Memory (B)": "205822885888"},
+		"system": {"Driver version": "6.8.5"}
+	}`)
+	devices, err := parseROCmSMIDevices(out)
+	require.NoError(t, err)
+	assert.Equal(t, []Device{
+		{Index: 0, UUID: "0x18f68e602b8a790f", Model: "AMD Instinct MI300X", VRAM: 205822885888},
+		{Index: 1, UUID: "0xABC", Model: "AMD Radeon RX 7900 XTX", VRAM: 25753026560},
+	}, devices)
+
+	devices, err = parseROCmSMIDevices([]byte(`{
+		"card0": {"Card Series": "AMD Instinct MI300X"},
+		"card1": {"Unique ID": "0xABC", "Temperature (Sensor edge) (C)": "45.0", "Temperature (Sensor junction) (C)": "101.0"}
+	}`))
+	require.NoError(t, err)
+	require.Len(t, devices, 2)
+	assert.Equal(t, Device{Index: 0, ReadError: "missing unique id of card0"}, devices[0])
+	assert.Equal(t, "0xABC", devices[1].UUID)
+	assert.NotEmpty(t, devices[1].ReadError, "unreadable cards are listed to be kept as unhealthy")
+
+	devices, err = parseROCmSMIDevices([]byte(`{"card0": {"Unique ID": "0xABC", "VRAM Total Memory (B)": "25753026560",
+		"Temperature (Sensor edge) (C)": "45.0", "Temperature (Sensor junction) (C)": "101.0"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, ptr.To(int32(101)), devices[0].Temperature, "junction temperature is reported")
+	assert.Equal(t, defaultMaxJunctionTemperature, defaultMaxTemperature(DiscoveryBackendROCm))
+	assert.Equal(t, defaultMaxCoreTemperature, defaultMaxTemperature(DiscoveryBackendNVML))
+
+	discoverer := &rocmDiscoverer{runSMI: func(args ...string) ([]byte, error) {
+		assert.Contains(t, args, "--json")
+		return out, nil
+	}}
+	devices, err = discoverer.Devices()
+	require.NoError(t, err)
+	assert.Len(t, devices, 2)
+}
+
+func TestNewDeviceDiscoverer(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "devices.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+- index: 0
+  uuid: GPU-0
+  model: NVIDIA A100-SXM4-80GB
+  vram: 85899345920
+`), 0o644))
+
+	discoverer, err := NewDeviceDiscoverer(DiscoveryBackendFake, file)
+	require.NoError(t, err)
+	require.NoError(t, discoverer.Init())
+	devices, err := discoverer.Devices()
+	require.NoError(t, err)
+	assert.Equal(t, []Device{{Index: 0, UUID: "GPU-0", Model: "NVIDIA A100-SXM4-80GB", VRAM: 85899345920}}, devices)
+
+	_, err = NewDeviceDiscoverer(DiscoveryBackendFake, "")
+	assert.Error(t, err)
+	_, err = NewDeviceDiscoverer("unknown", "")
+	assert.Error(t, err)
+	discoverer, err = NewDeviceDiscoverer("", "")
+	require.NoError(t, err)
+	assert.IsType(t, &nvmlDiscoverer{}, discoverer, "defaults to NVML")
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/NexusGPU/tensor-fusion/internal/utils"
+)
+
+// fakeDiscoverer reads devices from a YAML or JSON file, for testing discovery on hosts without GPUs,
+// e.g. [{"index": 0, "uuid": "gpu-0", "model": "NVIDIA A100-SXM4-80GB", "vram": 85899345920}]
+type fakeDiscoverer struct {
+	file string
+}
+
+func (d *fakeDiscoverer) Init() error {
+	return nil
+}
+
+func (d *fakeDiscoverer) Shutdown() error {
+	return nil
+}
+
+func (d *fakeDiscoverer) Devices() ([]Device, error) {
+	devices := make([]Device, 0)
+	if err := utils.LoadConfigFromFile(d.file, &devices); err != nil {
+		return nil, fmt.Errorf("load fake devices from %s: %w", d.file, err)
+	}
+	return devices, nil
+}
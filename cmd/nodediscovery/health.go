@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// most data center GPUs start to slow down at 85-90 Celsius
+	defaultMaxCoreTemperature = 90
+	// AMD Instinct GPUs start to slow down at around 100 Celsius junction temperature
+	defaultMaxJunctionTemperature = 100
+)
+
+// healthConditions returns health conditions of the device, True means something is wrong
+func healthConditions(device Device, maxTemperature int32) []metav1.Condition {
+	conditions := []metav1.Condition{
+		{
+			Type:   constants.ConditionStatusTypeGPUECCError,
+			Status: metav1.ConditionFalse,
+			Reason: "NoUncorrectedECCErrors",
+		},
+		{
+			Type:   constants.ConditionStatusTypeGPUXIDError,
+			Status: metav1.ConditionFalse,
+			Reason: "NoCriticalXIDErrors",
+		},
+		{
+			Type:   constants.ConditionStatusTypeGPUOverheating,
+			Status: metav1.ConditionFalse,
+			Reason: "TemperatureNormal",
+		},
+		{
+			Type:   constants.ConditionStatusTypeGPUThrottled,
+			Status: metav1.ConditionFalse,
+			Reason: "ClocksNotThrottled",
+		},
+		{
+			Type:   constants.ConditionStatusTypeGPUDeviceLost,
+			Status: metav1.ConditionFalse,
+			Reason: "DeviceFound",
+		},
+	}
+	if device.ECCErrors > 0 {
+		conditions[0].Status = metav1.ConditionTrue
+		conditions[0].Reason = "UncorrectedECCErrors"
+		conditions[0].Message = fmt.Sprintf("%d uncorrected ECC errors", device.ECCErrors)
+	}
+	if len(device.XIDErrors) > 0 {
+		conditions[1].Status = metav1.ConditionTrue
+		conditions[1].Reason = "CriticalXIDErrors"
+		conditions[1].Message = fmt.Sprintf("critical XID errors: %s", strings.Join(lo.Map(lo.Uniq(device.XIDErrors),
+			func(xid uint64, _ int) string {
+				return fmt.Sprint(xid)
+			}), ","))
+	}
+	if device.Temperature != nil && *device.Temperature >= maxTemperature {
+		conditions[2].Status = metav1.ConditionTrue
+		conditions[2].Reason = "TemperatureTooHigh"
+		conditions[2].Message = fmt.Sprintf("temperature %d°C reaches the limit %d°C", *device.Temperature, maxTemperature)
+	}
+	if len(device.ThrottleReasons) > 0 {
+		conditions[3].Status = metav1.ConditionTrue
+		conditions[3].Reason = "ClocksThrottled"
+		conditions[3].Message = fmt.Sprintf("clocks throttled by %s", strings.Join(device.ThrottleReasons, ","))
+	}
+	if device.ReadError != "" {
+		conditions[4].Status = metav1.ConditionTrue
+		conditions[4].Reason = "DeviceUnreadable"
+		conditions[4].Message = device.ReadError
+	}
+	return conditions
+}
+
+// updateGPUHealth records health conditions and temperature in GPU status, Running GPUs becoming unhealthy are
+// switched to Unhealthy to be excluded from allocation, and back to Running once recovered
+func updateGPUHealth(
+	ctx context.Context, k8sClient client.Client, gpu *tfv1.GPU,
+	conditions []metav1.Condition, temperature *int32,
+) error {
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(gpu), gpu); err != nil {
+			return err
+		}
+		oldStatus := gpu.Status.DeepCopy()
+
+		if temperature != nil {
+			gpu.Status.Temperature = temperature
+		}
+		for _, condition := range conditions {
+			meta.SetStatusCondition(&gpu.Status.Conditions, condition)
+		}
+		switch {
+		case gpu.IsUnhealthy() && gpu.Status.Phase == tfv1.TensorFusionGPUPhaseRunning:
+			gpu.Status.Phase = tfv1.TensorFusionGPUPhaseUnhealthy
+		case !gpu.IsUnhealthy() && gpu.Status.Phase == tfv1.TensorFusionGPUPhaseUnhealthy:
+			gpu.Status.Phase = tfv1.TensorFusionGPUPhaseRunning
+		}
+
+		if equality.Semantic.DeepEqual(oldStatus, &gpu.Status) {
+			return nil
+		}
+		return k8sClient.Status().Update(ctx, gpu)
+	})
+	if err != nil {
+		return fmt.Errorf("update health of GPU %s: %w", gpu.Name, err)
+	}
+	return nil
+}
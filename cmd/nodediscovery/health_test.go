@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestHealthConditions(t *testing.T) {
+	conditions := healthConditions(Device{
+		Temperature:     ptr.To(int32(92)),
+		XIDErrors:       []uint64{79, 48, 79},
+		ThrottleReasons: []string{"HwThermalSlowdown"},
+	}, 90)
+	assert.False(t, meta.IsStatusConditionTrue(conditions, constants.ConditionStatusTypeGPUECCError))
+	assert.True(t, meta.IsStatusConditionTrue(conditions, constants.ConditionStatusTypeGPUOverheating))
+	assert.True(t, meta.IsStatusConditionTrue(conditions, constants.ConditionStatusTypeGPUThrottled))
+	xid := meta.FindStatusCondition(conditions, constants.ConditionStatusTypeGPUXIDError)
+	require.NotNil(t, xid)
+	assert.Equal(t, metav1.ConditionTrue, xid.Status)
+	assert.Equal(t, "critical XID errors: 79,48", xid.Message)
+
+	conditions = healthConditions(Device{Temperature: ptr.To(int32(60)), ThrottleReasons: []string{"SwPowerCap"}}, 90)
+	gpu := &tfv1.GPU{Status: tfv1.GPUStatus{Conditions: conditions}}
+	assert.False(t, gpu.IsUnhealthy(), "throttling alone is not unhealthy")
+
+	conditions = healthConditions(Device{UUID: "GPU-0", ReadError: "unable to get name of device 0: GPU is lost"}, 90)
+	lost := meta.FindStatusCondition(conditions, constants.ConditionStatusTypeGPUDeviceLost)
+	require.NotNil(t, lost)
+	assert.Equal(t, metav1.ConditionTrue, lost.Status)
+	assert.Equal(t, "DeviceUnreadable", lost.Reason)
+}
+
+func TestUpdateGPUHealth(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	_ = tfv1.AddToScheme(scheme)
+	gpu := &tfv1.GPU{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpu"},
+		Status:     tfv1.GPUStatus{Phase: tfv1.TensorFusionGPUPhaseRunning},
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&tfv1.GPU{}).WithObjects(gpu).Build()
+
+	require.NoError(t, updateGPUHealth(ctx, k8sClient, gpu, healthConditions(Device{ECCErrors: 2}, 90), ptr.To(int32(70))))
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(gpu), gpu))
+	assert.Equal(t, tfv1.TensorFusionGPUPhaseUnhealthy, gpu.Status.Phase)
+	assert.Equal(t, int32(70), *gpu.Status.Temperature)
+
+	require.NoError(t, updateGPUHealth(ctx, k8sClient, gpu, healthConditions(Device{}, 90), nil))
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(gpu), gpu))
+	assert.Equal(t, tfv1.TensorFusionGPUPhaseRunning, gpu.Status.Phase, "recovered GPUs are allocated again")
+	assert.Equal(t, int32(70), *gpu.Status.Temperature, "temperature is kept when not reported")
+
+	// GPUs out of allocation for other reasons keep their phase
+	gpu.Status.Phase = tfv1.TensorFusionGPUPhaseUnschedulable
+	require.NoError(t, k8sClient.Status().Update(ctx, gpu))
+	require.NoError(t, updateGPUHealth(ctx, k8sClient, gpu, healthConditions(Device{ECCErrors: 1}, 90), nil))
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(gpu), gpu))
+	assert.Equal(t, tfv1.TensorFusionGPUPhaseUnschedulable, gpu.Status.Phase)
+}
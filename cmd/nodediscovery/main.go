@@ -13,12 +13,12 @@ import (
 
 	"github.com/shirou/gopsutil/mem"
 
-	"github.com/NVIDIA/go-nvml/pkg/nvml"
 	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
 	"github.com/NexusGPU/tensor-fusion/internal/config"
 	"github.com/NexusGPU/tensor-fusion/internal/constants"
 	"github.com/NexusGPU/tensor-fusion/internal/utils"
 	"github.com/samber/lo"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -45,8 +45,20 @@ func main() {
 	var k8sNodeName string
 
 	var gpuInfoConfig string
+	var discoveryBackend string
+	var fakeDevicesFile string
+	var interval time.Duration
+	var maxTemperature int
 	flag.StringVar(&k8sNodeName, "hostname", "", "hostname")
 	flag.StringVar(&gpuInfoConfig, "gpu-info-config", "", "specify the path to gpuInfoConfig file")
+	flag.StringVar(&discoveryBackend, "discovery-backend", DiscoveryBackendNVML,
+		"GPU discovery backend, one of nvml, rocm or fake")
+	flag.StringVar(&fakeDevicesFile, "fake-devices-file", "", "specify the path to devices file of the fake discovery backend")
+	flag.DurationVar(&interval, "discovery-interval", 0,
+		"rediscover GPUs and report their health periodically, discover once and exit when 0")
+	flag.IntVar(&maxTemperature, "max-temperature", 0,
+		"GPUs at or above the temperature in Celsius are unhealthy, "+
+			"defaults to 90 for the GPU core temperature of NVIDIA GPUs and 100 for the junction temperature of AMD GPUs")
 
 	if k8sNodeName == "" {
 		k8sNodeName = os.Getenv("HOSTNAME")
@@ -70,6 +82,9 @@ func main() {
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	if maxTemperature <= 0 {
+		maxTemperature = defaultMaxTemperature(discoveryBackend)
+	}
 
 	gpuInfo := make([]config.GpuInfo, 0)
 	err = utils.LoadConfigFromFile(gpuInfoConfig, &gpuInfo)
@@ -78,34 +93,78 @@ func main() {
 		os.Exit(1)
 	}
 
-	ret := nvml.Init()
-	if ret != nvml.SUCCESS {
-		ctrl.Log.Error(errors.New(nvml.ErrorString(ret)), "unable to initialize NVML")
+	discoverer, err := NewDeviceDiscoverer(discoveryBackend, fakeDevicesFile)
+	if err != nil {
+		ctrl.Log.Error(err, "unable to create device discoverer")
+		os.Exit(1)
+	}
+	if err := discoverer.Init(); err != nil {
+		ctrl.Log.Error(err, "unable to initialize device discoverer", "backend", discoveryBackend)
 		os.Exit(1)
 	}
 	defer func() {
-		ret := nvml.Shutdown()
-		if ret != nvml.SUCCESS {
-			ctrl.Log.Error(errors.New(nvml.ErrorString(ret)), "unable to shutdown NVML")
+		if err := discoverer.Shutdown(); err != nil {
+			ctrl.Log.Error(err, "unable to shutdown device discoverer", "backend", discoveryBackend)
 			os.Exit(1)
 		}
 	}()
 
-	count, ret := nvml.DeviceGetCount()
-	if ret != nvml.SUCCESS {
-		ctrl.Log.Error(errors.New(nvml.ErrorString(ret)), "unable to get device count")
-		os.Exit(1)
+	d := &nodeDiscovery{
+		client:         k8sClient,
+		discoverer:     discoverer,
+		gpuInfo:        gpuInfo,
+		k8sNodeName:    k8sNodeName,
+		gpuNodeName:    gpuNodeName,
+		maxTemperature: int32(maxTemperature),
 	}
-
 	ctx := context.Background()
+	if interval <= 0 {
+		if err := d.discover(ctx); err != nil {
+			ctrl.Log.Error(err, "node discovery failed")
+			os.Exit(1)
+		}
+		return
+	}
+
+	// keep discovering to report health and GPUs added or removed at runtime
+	ctrl.Log.Info("running node discovery continuously", "interval", interval)
+	for {
+		if err := d.discover(ctx); err != nil {
+			ctrl.Log.Error(err, "node discovery failed, retry in next round")
+		}
+		time.Sleep(interval)
+	}
+}
+
+type nodeDiscovery struct {
+	client      client.Client
+	discoverer  DeviceDiscoverer
+	gpuInfo     []config.GpuInfo
+	k8sNodeName string
+	gpuNodeName string
+	// GPUs at or above the temperature in Celsius are Overheating
+	maxTemperature int32
+	// whether the GPUNode status has been reported, the phase is left to the GPUNode controller afterwards
+	reported bool
+}
+
+// discover registers GPUs of the node and reports their health, the GPUNode phase is only set until the status
+// is reported to leave it to the GPUNode controller afterwards. GPUs failing to be registered are reported in the returned error
+// after the rest of the node is updated, they are retried in the next round
+func (d *nodeDiscovery) discover(ctx context.Context) error {
+	initial := !d.reported
+	devices, err := d.discoverer.Devices()
+	if err != nil {
+		return fmt.Errorf("discover devices: %w", err)
+	}
+
 	gpunode := &tfv1.GPUNode{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: gpuNodeName,
+			Name: d.gpuNodeName,
 		},
 	}
-	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(gpunode), gpunode); err != nil {
-		ctrl.Log.Error(err, "unable to get gpuNode")
-		os.Exit(1)
+	if err := d.client.Get(ctx, client.ObjectKeyFromObject(gpunode), gpunode); err != nil {
+		return fmt.Errorf("get gpuNode: %w", err)
 	}
 
 	totalTFlops := resource.Quantity{}
@@ -113,52 +172,69 @@ func main() {
 	availableTFlops := resource.Quantity{}
 	availableVRAM := resource.Quantity{}
 
-	allDeviceIDs := make([]string, 0)
-
-	for i := range count {
-		device, ret := nvml.DeviceGetHandleByIndex(i)
-		if ret != nvml.SUCCESS {
-			ctrl.Log.Error(errors.New(nvml.ErrorString(ret)), "unable to get device", "index", i)
-			os.Exit(1)
+	discoveredDeviceIDs := make([]string, 0, len(devices))
+	managedDeviceIDs := make([]string, 0)
+	// GPUs not found may be the ones whose UUID can not be read, they are neither deleted nor marked as lost
+	unidentified := false
+	var errs []error
+
+	for _, device := range devices {
+		if device.UUID == "" {
+			ctrl.Log.Info("unable to identify GPU", "index", device.Index, "error", device.ReadError)
+			unidentified = true
+			continue
 		}
-
-		uuid, ret := device.GetUUID()
-		if ret != nvml.SUCCESS {
-			ctrl.Log.Error(errors.New(nvml.ErrorString(ret)), "unable to get uuid of device", "index", i)
-			os.Exit(1)
-		}
-		uuid = strings.ToLower(uuid)
-		deviceName, ret := device.GetName()
-		if ret != nvml.SUCCESS {
-			ctrl.Log.Error(errors.New(nvml.ErrorString(ret)), "unable to get name of device", "index", i)
-			os.Exit(1)
+		uuid := strings.ToLower(device.UUID)
+		discoveredDeviceIDs = append(discoveredDeviceIDs, uuid)
+		if !isManagedGPU(gpunode, device.Index) {
+			ctrl.Log.V(4).Info("skip GPU not listed in gpuCardIndices", "index", device.Index)
+			continue
 		}
+		deviceName := device.Model
+		managedDeviceIDs = append(managedDeviceIDs, uuid)
+
+		var gpu *tfv1.GPU
+		if device.ReadError != "" {
+			// keep the registered GPU with its capacity and mark it unhealthy until it can be read again
+			gpu = &tfv1.GPU{ObjectMeta: metav1.ObjectMeta{Name: uuid}}
+			if err := d.client.Get(ctx, client.ObjectKeyFromObject(gpu), gpu); err != nil {
+				if !apierrors.IsNotFound(err) {
+					errs = append(errs, fmt.Errorf("get unreadable GPU %s: %w", uuid, err))
+				}
+				continue
+			}
+		} else {
+			info, ok := lo.Find(d.gpuInfo, func(info config.GpuInfo) bool {
+				return info.FullModelName == deviceName
+			})
+			tflops := info.Fp16TFlops
+			if !ok {
+				ctrl.Log.Info(
+					"[Error] Unknown GPU model, please update `gpu-public-gpu-info` configMap "+
+						" to match your GPU model name in `nvidia-smi` or `rocm-smi`, this may cause you workload stuck, "+
+						"refer this doc to resolve it in detail: "+
+						"https://tensor-fusion.ai/guide/troubleshooting/handbook"+
+						"#pod-stuck-in-starting-status-after-enabling-tensorfusion",
+					"deviceName", deviceName, "uuid", uuid)
+				errs = append(errs, fmt.Errorf("unknown model %q of GPU %s", deviceName, uuid))
+				continue
+			} else if initial {
+				ctrl.Log.Info("found GPU info from config", "deviceName", deviceName, "FP16 TFlops", tflops, "uuid", uuid)
+			}
 
-		allDeviceIDs = append(allDeviceIDs, uuid)
-
-		memInfo, ret := device.GetMemoryInfo_v2()
-		if ret != nvml.SUCCESS {
-			ctrl.Log.Error(errors.New(nvml.ErrorString(ret)), "unable to get memory info of device", "index", i)
-			os.Exit(1)
+			gpu, err = createOrUpdateTensorFusionGPU(d.client, ctx, d.k8sNodeName, gpunode, uuid, deviceName, device.VRAM, tflops)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
 		}
-		info, ok := lo.Find(gpuInfo, func(info config.GpuInfo) bool {
-			return info.FullModelName == deviceName
-		})
-		tflops := info.Fp16TFlops
-		if !ok {
-			ctrl.Log.Info(
-				"[Error] Unknown GPU model, please update `gpu-public-gpu-info` configMap "+
-					" to match your GPU model name in `nvidia-smi`, this may cause you workload stuck, "+
-					"refer this doc to resolve it in detail: "+
-					"https://tensor-fusion.ai/guide/troubleshooting/handbook"+
-					"#pod-stuck-in-starting-status-after-enabling-tensorfusion",
-				"deviceName", deviceName, "uuid", uuid)
-			os.Exit(1)
-		} else {
-			ctrl.Log.Info("found GPU info from config", "deviceName", deviceName, "FP16 TFlops", tflops, "uuid", uuid)
+		if err := updateGPUHealth(ctx, d.client, gpu, healthConditions(device, d.maxTemperature), device.Temperature); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if gpu.Status.Capacity == nil || gpu.Status.Available == nil {
+			continue
 		}
-
-		gpu := createOrUpdateTensorFusionGPU(k8sClient, ctx, k8sNodeName, gpunode, uuid, deviceName, memInfo, tflops)
 
 		totalTFlops.Add(gpu.Status.Capacity.Tflops)
 		totalVRAM.Add(gpu.Status.Capacity.Vram)
@@ -166,37 +242,43 @@ func main() {
 		availableVRAM.Add(gpu.Status.Available.Vram)
 	}
 
-	ns := nodeStatus(k8sNodeName)
-	ns.TotalTFlops = totalTFlops
-	ns.TotalVRAM = totalVRAM
-	ns.AvailableTFlops = availableTFlops
-	ns.AvailableVRAM = availableVRAM
-	ns.TotalGPUs = int32(count)
-	ns.ManagedGPUs = int32(count)
-	ns.ManagedGPUDeviceIDs = allDeviceIDs
-	ns.NodeInfo.RAMSize = *resource.NewQuantity(getTotalHostRAM(), resource.DecimalSI)
-	ns.NodeInfo.DataDiskSize = *resource.NewQuantity(getDiskInfo(constants.TFDataPath), resource.DecimalSI)
-	gpunode.Status = *ns
+	if err := cleanupUnmanagedGPUs(ctx, d.client, gpunode, discoveredDeviceIDs, managedDeviceIDs, unidentified); err != nil {
+		return fmt.Errorf("clean up unmanaged GPUs: %w", err)
+	}
 
 	err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 		currentGPUNode := &tfv1.GPUNode{}
-		if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(gpunode), currentGPUNode); err != nil {
+		if err := d.client.Get(ctx, client.ObjectKeyFromObject(gpunode), currentGPUNode); err != nil {
 			return err
 		}
 
-		currentGPUNode.Status = *ns
-
-		return k8sClient.Status().Update(ctx, currentGPUNode)
+		ns := &currentGPUNode.Status
+		ns.KubernetesNodeName = d.k8sNodeName
+		if initial {
+			ns.Phase = tfv1.TensorFusionGPUNodePhaseRunning
+		}
+		ns.TotalTFlops = totalTFlops
+		ns.TotalVRAM = totalVRAM
+		ns.AvailableTFlops = availableTFlops
+		ns.AvailableVRAM = availableVRAM
+		ns.TotalGPUs = int32(len(devices))
+		ns.ManagedGPUs = int32(len(managedDeviceIDs))
+		ns.ManagedGPUDeviceIDs = managedDeviceIDs
+		ns.NodeInfo.RAMSize = *resource.NewQuantity(getTotalHostRAM(), resource.DecimalSI)
+		ns.NodeInfo.DataDiskSize = *resource.NewQuantity(getDiskInfo(constants.TFDataPath), resource.DecimalSI)
+
+		return d.client.Status().Update(ctx, currentGPUNode)
 	})
 	if err != nil {
-		ctrl.Log.Error(err, "failed to update status of GPUNode after retries")
-		os.Exit(1)
+		return fmt.Errorf("update status of GPUNode after retries: %w", err)
 	}
+	d.reported = true
+	return errors.Join(errs...)
 }
 
 func createOrUpdateTensorFusionGPU(
 	k8sClient client.Client, ctx context.Context, k8sNodeName string, gpunode *tfv1.GPUNode,
-	uuid string, deviceName string, memInfo nvml.Memory_v2, tflops resource.Quantity) *tfv1.GPU {
+	uuid string, deviceName string, vram uint64, tflops resource.Quantity) (*tfv1.GPU, error) {
 	gpu := &tfv1.GPU{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: uuid,
@@ -212,13 +294,15 @@ func createOrUpdateTensorFusionGPU(
 		return true // Retry on all errors for now
 	}, func() error {
 		_, err := controllerutil.CreateOrUpdate(ctx, k8sClient, gpu, func() error {
-			// Set metadata fields
-			gpu.Labels = map[string]string{
-				constants.LabelKeyOwner: gpunode.Name,
+			// Set metadata fields, labels and annotations added by controllers such as the pool are kept
+			if gpu.Labels == nil {
+				gpu.Labels = make(map[string]string)
 			}
-			gpu.Annotations = map[string]string{
-				constants.GPULastReportTimeAnnotationKey: time.Now().Format(time.RFC3339),
+			gpu.Labels[constants.LabelKeyOwner] = gpunode.Name
+			if gpu.Annotations == nil {
+				gpu.Annotations = make(map[string]string)
 			}
+			gpu.Annotations[constants.GPULastReportTimeAnnotationKey] = time.Now().Format(time.RFC3339)
 
 			if !metav1.IsControlledBy(gpu, gpunode) {
 				// Create a new controller ref.
@@ -241,8 +325,7 @@ func createOrUpdateTensorFusionGPU(
 		return err
 	})
 	if err != nil {
-		ctrl.Log.Error(err, "failed to create or update GPU after retries", "gpu", gpu)
-		os.Exit(1)
+		return nil, fmt.Errorf("create or update GPU %s after retries: %w", uuid, err)
 	}
 
 	err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
@@ -250,41 +333,83 @@ func createOrUpdateTensorFusionGPU(
 			return err
 		}
 
-		newStatus := tfv1.GPUStatus{
-			Phase: tfv1.TensorFusionGPUPhaseRunning,
-			Capacity: &tfv1.Resource{
-				Vram:   resource.MustParse(fmt.Sprintf("%dKi", memInfo.Total/1024)),
-				Tflops: tflops,
-			},
-			UUID:     uuid,
-			GPUModel: deviceName,
-			NodeSelector: map[string]string{
-				"kubernetes.io/hostname": k8sNodeName,
-			},
-			RunningApps: []*tfv1.RunningAppDetail{},
+		// phase, allocation and health are kept for GPUs already discovered
+		capacity := &tfv1.Resource{
+			Vram:   resource.MustParse(fmt.Sprintf("%dKi", vram/1024)),
+			Tflops: tflops,
+		}
+		gpu.Status.Capacity = capacity
+		gpu.Status.UUID = uuid
+		gpu.Status.GPUModel = deviceName
+		gpu.Status.NodeSelector = map[string]string{
+			"kubernetes.io/hostname": k8sNodeName,
+		}
+		if gpu.Status.Phase == "" {
+			gpu.Status.Phase = tfv1.TensorFusionGPUPhaseRunning
+		}
+		if gpu.Status.RunningApps == nil {
+			gpu.Status.RunningApps = []*tfv1.RunningAppDetail{}
 		}
-
 		if gpu.Status.Available == nil {
-			newStatus.Available = newStatus.Capacity
-		} else {
-			newStatus.Available = gpu.Status.Available
+			gpu.Status.Available = capacity
 		}
-		gpu.Status = newStatus
 		return k8sClient.Status().Update(ctx, gpu)
 	})
 	if err != nil {
-		ctrl.Log.Error(err, "failed to update status of GPU after retries", "gpu", gpu)
-		os.Exit(1)
+		return nil, fmt.Errorf("update status of GPU %s after retries: %w", uuid, err)
 	}
 
-	return gpu
+	return gpu, nil
+}
+
+// isManagedGPU returns whether the GPU card at index should be onboarded, all cards are managed when
+// gpuCardIndices is empty
+func isManagedGPU(gpunode *tfv1.GPUNode, index int) bool {
+	return len(gpunode.Spec.GPUCardIndices) == 0 || lo.Contains(gpunode.Spec.GPUCardIndices, index)
 }
 
-func nodeStatus(k8sNodeName string) *tfv1.GPUNodeStatus {
-	return &tfv1.GPUNodeStatus{
-		KubernetesNodeName: k8sNodeName,
-		Phase:              tfv1.TensorFusionGPUNodePhaseRunning,
+// cleanupUnmanagedGPUs deletes GPUs of the node that are no longer found or fall out of gpuCardIndices,
+// GPUs still used by workers are kept until the workers are gone, the ones no longer found are marked as lost.
+// GPUs not found are left untouched when some devices could not be identified, they may be one of them
+func cleanupUnmanagedGPUs(
+	ctx context.Context, k8sClient client.Client, gpunode *tfv1.GPUNode,
+	discoveredDeviceIDs []string, managedDeviceIDs []string, unidentified bool,
+) error {
+	gpuList := &tfv1.GPUList{}
+	if err := k8sClient.List(ctx, gpuList, client.MatchingLabels{constants.LabelKeyOwner: gpunode.Name}); err != nil {
+		return fmt.Errorf("list GPUs of node %s: %w", gpunode.Name, err)
+	}
+	for i := range gpuList.Items {
+		gpu := &gpuList.Items[i]
+		if lo.Contains(managedDeviceIDs, gpu.Name) {
+			continue
+		}
+		if unidentified && !lo.Contains(discoveredDeviceIDs, gpu.Name) {
+			ctrl.Log.Info("GPU is not found while some devices can not be identified, skip cleaning up", "gpu", gpu.Name)
+			continue
+		}
+		if len(gpu.Status.RunningApps) > 0 {
+			if lo.Contains(discoveredDeviceIDs, gpu.Name) {
+				ctrl.Log.Info("GPU is no longer managed but still in use, skip deleting", "gpu", gpu.Name)
+				continue
+			}
+			ctrl.Log.Info("GPU is lost but still in use, skip deleting", "gpu", gpu.Name)
+			if err := updateGPUHealth(ctx, k8sClient, gpu, []metav1.Condition{{
+				Type:    constants.ConditionStatusTypeGPUDeviceLost,
+				Status:  metav1.ConditionTrue,
+				Reason:  "DeviceNotFound",
+				Message: "GPU is not found on the node, it may have fallen off the bus",
+			}}, nil); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := k8sClient.Delete(ctx, gpu); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("delete unmanaged GPU %s: %w", gpu.Name, err)
+		}
+		ctrl.Log.Info("deleted GPU that is no longer managed", "gpu", gpu.Name)
 	}
+	return nil
 }
 
 func kubeClient() (client.Client, error) {
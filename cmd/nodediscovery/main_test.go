@@ -5,9 +5,9 @@ import (
 	"testing"
 	"time"
 
-	"github.com/NVIDIA/go-nvml/pkg/nvml"
 	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
 	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -19,7 +19,7 @@ func TestCreateOrUpdateTensorFusionGPU(t *testing.T) {
 	// Setup test data
 	ctx := context.Background()
 	uuid := "test-uuid"
-	memInfo := nvml.Memory_v2{Total: 16 * 1024 * 1024 * 1024} // 16 GiB
+	vram := uint64(16 * 1024 * 1024 * 1024) // 16 GiB
 	tflops := resource.MustParse("100")
 	deviceName := "NVIDIA-Test-GPU"
 	k8sNodeName := "test-node"
@@ -36,7 +36,8 @@ func TestCreateOrUpdateTensorFusionGPU(t *testing.T) {
 
 	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&tfv1.GPU{}).Build()
 
-	gpu := createOrUpdateTensorFusionGPU(k8sClient, ctx, k8sNodeName, gpuNode, uuid, deviceName, memInfo, tflops)
+	gpu, err := createOrUpdateTensorFusionGPU(k8sClient, ctx, k8sNodeName, gpuNode, uuid, deviceName, vram, tflops)
+	assert.NoError(t, err)
 
 	// Assertions
 	assert.NotNil(t, gpu, "GPU object should not be nil")
@@ -53,9 +54,19 @@ func TestCreateOrUpdateTensorFusionGPU(t *testing.T) {
 	assert.Equal(t, map[string]string{constants.LabelKeyOwner: gpuNodeName}, gpu.Labels, "GPU labels should match")
 	assert.Contains(t, gpu.Annotations, constants.GPULastReportTimeAnnotationKey,
 		"GPU annotations should contain last report time")
-	_, err := time.Parse(time.RFC3339, gpu.Annotations[constants.GPULastReportTimeAnnotationKey])
+	_, err = time.Parse(time.RFC3339, gpu.Annotations[constants.GPULastReportTimeAnnotationKey])
 	assert.NoError(t, err, "Last report time annotation should be a valid RFC3339 timestamp")
 
+	// Verify labels added by the gpu controller survive the next discovery round
+	gpu.Labels[constants.GpuPoolKey] = "pool-a"
+	err = k8sClient.Update(ctx, gpu)
+	assert.NoError(t, err)
+	rediscoveredGpu, err := createOrUpdateTensorFusionGPU(k8sClient, ctx, k8sNodeName, gpuNode, uuid, deviceName, vram, tflops)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{constants.LabelKeyOwner: gpuNodeName, constants.GpuPoolKey: "pool-a"},
+		rediscoveredGpu.Labels, "GPU pool label should be kept")
+	gpu = rediscoveredGpu
+
 	// Verify the Available field does not change after the update
 	gpu.Status.Available.Tflops.Sub(resource.MustParse("1000"))
 	gpu.Status.Available.Vram.Sub(resource.MustParse("2000Mi"))
@@ -63,7 +74,8 @@ func TestCreateOrUpdateTensorFusionGPU(t *testing.T) {
 	assert.NoError(t, err)
 
 	tflops.Add(resource.MustParse("100"))
-	updatedGpu := createOrUpdateTensorFusionGPU(k8sClient, ctx, k8sNodeName, gpuNode, uuid, deviceName, memInfo, tflops)
+	updatedGpu, err := createOrUpdateTensorFusionGPU(k8sClient, ctx, k8sNodeName, gpuNode, uuid, deviceName, vram, tflops)
+	assert.NoError(t, err)
 	assert.NotEqual(t, updatedGpu.Status.Capacity, gpu.Status.Capacity, "GPU capacity should not match")
 	assert.Equal(t, updatedGpu.Status.Available.Tflops, gpu.Status.Available.Tflops, "GPU TFlops should match")
 	assert.Equal(t, updatedGpu.Status.Available.Vram, gpu.Status.Available.Vram, "GPU VRAM should match")
@@ -73,7 +85,7 @@ func TestGPUControllerReference(t *testing.T) {
 	// Setup test data
 	ctx := context.Background()
 	uuid := "test-uuid"
-	memInfo := nvml.Memory_v2{Total: 16 * 1024 * 1024 * 1024} // 16 GiB
+	vram := uint64(16 * 1024 * 1024 * 1024) // 16 GiB
 	tflops := resource.MustParse("100")
 	deviceName := "NVIDIA-Test-GPU"
 	k8sNodeName := "test-node"
@@ -91,7 +103,8 @@ func TestGPUControllerReference(t *testing.T) {
 
 	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&tfv1.GPU{}).Build()
 
-	gpu := createOrUpdateTensorFusionGPU(k8sClient, ctx, k8sNodeName, gpuNode, uuid, deviceName, memInfo, tflops)
+	gpu, err := createOrUpdateTensorFusionGPU(k8sClient, ctx, k8sNodeName, gpuNode, uuid, deviceName, vram, tflops)
+	assert.NoError(t, err)
 	assert.True(t, metav1.IsControlledBy(gpu, gpuNode))
 
 	newGpuNode := &tfv1.GPUNode{
@@ -101,9 +114,63 @@ func TestGPUControllerReference(t *testing.T) {
 		},
 	}
 
-	gpu = createOrUpdateTensorFusionGPU(k8sClient, ctx, k8sNodeName, newGpuNode, uuid, deviceName, memInfo, tflops)
+	gpu, err = createOrUpdateTensorFusionGPU(k8sClient, ctx, k8sNodeName, newGpuNode, uuid, deviceName, vram, tflops)
+	assert.NoError(t, err)
 	assert.NotNil(t, gpu.OwnerReferences[0].Kind)
 	assert.NotNil(t, gpu.OwnerReferences[0].APIVersion)
 	assert.True(t, metav1.IsControlledBy(gpu, newGpuNode))
 	assert.False(t, metav1.IsControlledBy(gpu, gpuNode))
 }
+
+func TestCleanupUnmanagedGPUs(t *testing.T) {
+	ctx := context.Background()
+	gpuNode := &tfv1.GPUNode{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-gpu-node"},
+		Spec:       tfv1.GPUNodeSpec{GPUCardIndices: []int{1}},
+	}
+	assert.False(t, isManagedGPU(gpuNode, 0))
+	assert.True(t, isManagedGPU(gpuNode, 1))
+	assert.True(t, isManagedGPU(&tfv1.GPUNode{}, 0), "all GPUs are managed without indices")
+
+	newGPU := func(name, owner string, apps ...*tfv1.RunningAppDetail) *tfv1.GPU {
+		return &tfv1.GPU{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{constants.LabelKeyOwner: owner}},
+			Status:     tfv1.GPUStatus{Phase: tfv1.TensorFusionGPUPhaseRunning, RunningApps: apps},
+		}
+	}
+
+	scheme := runtime.NewScheme()
+	_ = tfv1.AddToScheme(scheme)
+	inUse := &tfv1.RunningAppDetail{Name: "worker", Count: 1}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&tfv1.GPU{}).WithObjects(
+		newGPU("managed", gpuNode.Name),
+		newGPU("unmanaged", gpuNode.Name),
+		newGPU("unmanaged-in-use", gpuNode.Name, inUse),
+		newGPU("lost", gpuNode.Name),
+		newGPU("lost-in-use", gpuNode.Name, inUse),
+		newGPU("other-node", "other-gpu-node"),
+	).Build()
+
+	// GPUs not found are kept while some devices can not be identified
+	err := cleanupUnmanagedGPUs(ctx, k8sClient, gpuNode,
+		[]string{"managed", "unmanaged", "unmanaged-in-use"}, []string{"managed"}, true)
+	assert.NoError(t, err)
+	gpuList := &tfv1.GPUList{}
+	assert.NoError(t, k8sClient.List(ctx, gpuList))
+	assert.Len(t, gpuList.Items, 5)
+
+	err = cleanupUnmanagedGPUs(ctx, k8sClient, gpuNode,
+		[]string{"managed", "unmanaged", "unmanaged-in-use"}, []string{"managed"}, false)
+	assert.NoError(t, err)
+
+	assert.NoError(t, k8sClient.List(ctx, gpuList))
+	gpus := lo.KeyBy(gpuList.Items, func(gpu tfv1.GPU) string {
+		return gpu.Name
+	})
+	assert.ElementsMatch(t, []string{"managed", "unmanaged-in-use", "lost-in-use", "other-node"}, lo.Keys(gpus))
+	unmanaged := gpus["unmanaged-in-use"]
+	assert.False(t, unmanaged.IsUnhealthy())
+	lost := gpus["lost-in-use"]
+	assert.True(t, lost.IsUnhealthy(), "lost GPUs in use are kept as unhealthy")
+	assert.Equal(t, tfv1.TensorFusionGPUPhaseUnhealthy, lost.Status.Phase)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// throttleReasons are NVML clocks throttle reasons reported in the Throttled condition,
+// idle and application clock settings are expected and left out
+var throttleReasons = []struct {
+	mask uint64
+	name string
+}{
+	{nvml.ClocksThrottleReasonSwPowerCap, "SwPowerCap"},
+	{nvml.ClocksThrottleReasonHwSlowdown, "HwSlowdown"},
+	{nvml.ClocksThrottleReasonSwThermalSlowdown, "SwThermalSlowdown"},
+	{nvml.ClocksThrottleReasonHwThermalSlowdown, "HwThermalSlowdown"},
+	{nvml.ClocksThrottleReasonHwPowerBrakeSlowdown, "HwPowerBrakeSlowdown"},
+}
+
+// applicationXIDs are XID errors caused by applications rather than the GPU, e.g. 13 graphics engine exception,
+// 31 GPU memory page fault, 43 GPU stopped processing, 45 preemptive cleanup, 68 video processor exception,
+// 109 context switch timeout, same as the NVIDIA device plugin skips
+var applicationXIDs = []uint64{13, 31, 43, 45, 68, 109}
+
+// nvmlDiscoverer discovers NVIDIA GPUs through NVML
+type nvmlDiscoverer struct {
+	// critical XID events of all devices, nil when events are not supported
+	eventSet nvml.EventSet
+	// devices registered to the event set by UUID
+	registered map[string]bool
+	// XID errors seen by UUID since Init, XID errors need the GPU to be reset, so they are kept until restart
+	xidErrors map[string][]uint64
+}
+
+func (d *nvmlDiscoverer) Init() error {
+	if ret := nvml.Init(); ret != nvml.SUCCESS {
+		return fmt.Errorf("unable to initialize NVML: %w", errors.New(nvml.ErrorString(ret)))
+	}
+	d.registered = map[string]bool{}
+	d.xidErrors = map[string][]uint64{}
+	eventSet, ret := nvml.EventSetCreate()
+	if ret != nvml.SUCCESS {
+		ctrl.Log.Info("XID events not supported", "error", nvml.ErrorString(ret))
+		return nil
+	}
+	d.eventSet = eventSet
+	return nil
+}
+
+func (d *nvmlDiscoverer) Shutdown() error {
+	if d.eventSet != nil {
+		if ret := d.eventSet.Free(); ret != nvml.SUCCESS {
+			ctrl.Log.Info("unable to free NVML event set", "error", nvml.ErrorString(ret))
+		}
+	}
+	if ret := nvml.Shutdown(); ret != nvml.SUCCESS {
+		return fmt.Errorf("unable to shutdown NVML: %w", errors.New(nvml.ErrorString(ret)))
+	}
+	return nil
+}
+
+func (d *nvmlDiscoverer) Devices() ([]Device, error) {
+	count, ret := nvml.DeviceGetCount()
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("unable to get device count: %w", errors.New(nvml.ErrorString(ret)))
+	}
+
+	d.collectXIDErrors()
+	devices := make([]Device, 0, count)
+	for i := range count {
+		device, err := d.device(i)
+		if err != nil {
+			// e.g. the GPU has fallen off the bus, it's kept as unhealthy rather than lost
+			ctrl.Log.Error(err, "unable to read device, report it as unhealthy", "index", i)
+			device.ReadError = err.Error()
+		}
+		devices = append(devices, *device)
+	}
+	return devices, nil
+}
+
+// device reads the card at index, the device read so far is returned along with the error
+func (d *nvmlDiscoverer) device(index int) (*Device, error) {
+	result := &Device{Index: index}
+	device, ret := nvml.DeviceGetHandleByIndex(index)
+	if ret != nvml.SUCCESS {
+		return result, fmt.Errorf("unable to get device %d: %w", index, errors.New(nvml.ErrorString(ret)))
+	}
+	uuid, ret := device.GetUUID()
+	if ret != nvml.SUCCESS {
+		return result, fmt.Errorf("unable to get uuid of device %d: %w", index, errors.New(nvml.ErrorString(ret)))
+	}
+	result.UUID = uuid
+	name, ret := device.GetName()
+	if ret != nvml.SUCCESS {
+		return result, fmt.Errorf("unable to get name of device %d: %w", index, errors.New(nvml.ErrorString(ret)))
+	}
+	memInfo, ret := device.GetMemoryInfo_v2()
+	if ret != nvml.SUCCESS {
+		return result, fmt.Errorf("unable to get memory info of device %d: %w", index, errors.New(nvml.ErrorString(ret)))
+	}
+	result.Model = name
+	result.VRAM = memInfo.Total
+	result.XIDErrors = d.xidErrors[uuid]
+
+	if temperature, ret := device.GetTemperature(nvml.TEMPERATURE_GPU); ret == nvml.SUCCESS {
+		result.Temperature = ptr.To(int32(temperature))
+	}
+	// not supported by cards without ECC memory
+	if eccErrors, ret := device.GetTotalEccErrors(nvml.MEMORY_ERROR_TYPE_UNCORRECTED, nvml.VOLATILE_ECC); ret == nvml.SUCCESS {
+		result.ECCErrors = eccErrors
+	}
+	if reasons, ret := device.GetCurrentClocksThrottleReasons(); ret == nvml.SUCCESS {
+		for _, reason := range throttleReasons {
+			if reasons&reason.mask != 0 {
+				result.ThrottleReasons = append(result.ThrottleReasons, reason.name)
+			}
+		}
+	}
+
+	if d.eventSet != nil && !d.registered[uuid] {
+		if ret := device.RegisterEvents(nvml.EventTypeXidCriticalError, d.eventSet); ret == nvml.SUCCESS {
+			d.registered[uuid] = true
+		} else {
+			ctrl.Log.Info("unable to watch XID events of device", "index", index, "error", nvml.ErrorString(ret))
+		}
+	}
+	return result, nil
+}
+
+// collectXIDErrors drains XID events received since the last call without blocking
+func (d *nvmlDiscoverer) collectXIDErrors() {
+	if d.eventSet == nil {
+		return
+	}
+	for {
+		event, ret := d.eventSet.Wait(0)
+		if ret != nvml.SUCCESS {
+			// ERROR_TIMEOUT when there is no more event
+			return
+		}
+		if event.EventType != nvml.EventTypeXidCriticalError || event.Device == nil ||
+			slices.Contains(applicationXIDs, event.EventData) {
+			continue
+		}
+		uuid, ret := event.Device.GetUUID()
+		if ret != nvml.SUCCESS {
+			continue
+		}
+		ctrl.Log.Info("XID error received", "uuid", uuid, "xid", event.EventData)
+		d.xidErrors[uuid] = append(d.xidErrors[uuid], event.EventData)
+	}
+}
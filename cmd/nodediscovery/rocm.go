@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/utils/ptr"
+)
+
+const rocmSMITimeout = 30 * time.Second
+
+// rocmDiscoverer discovers AMD GPUs through rocm-smi, both CDNA(Instinct) and RDNA(Radeon) architectures,
+// only the junction temperature is reported for health
+type rocmDiscoverer struct {
+	// runs rocm-smi with args and returns stdout, replaced in tests
+	runSMI func(args ...string) ([]byte, error)
+}
+
+func newROCmDiscoverer() *rocmDiscoverer {
+	return &rocmDiscoverer{
+		runSMI: func(args ...string) ([]byte, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), rocmSMITimeout)
+			defer cancel()
+			return exec.CommandContext(ctx, "rocm-smi", args...).Output()
+		},
+	}
+}
+
+func (d *rocmDiscoverer) Init() error {
+	if _, err := exec.LookPath("rocm-smi"); err != nil {
+		return fmt.Errorf("unable to find rocm-smi: %w", err)
+	}
+	return nil
+}
+
+func (d *rocmDiscoverer) Shutdown() error {
+	return nil
+}
+
+func (d *rocmDiscoverer) Devices() ([]Device, error) {
+	out, err := d.runSMI("--showproductname", "--showuniqueid", "--showmeminfo", "vram", "--showtemp", "--json")
+	if err != nil {
+		return nil, fmt.Errorf("run rocm-smi: %w", err)
+	}
+	return parseROCmSMIDevices(out)
+}
+
+// parseROCmSMIDevices parses rocm-smi JSON output keyed by card, e.g.
+// {"card0": {"Card Series": "AMD Instinct MI300X", "Unique ID": "0x18f68e602b8a790f", "VRAM Total Memory (B)": "205822885888"}},
+// field names are matched case-insensitively since they vary between ROCm versions,
+// cards missing required fields are listed with ReadError set
+func parseROCmSMIDevices(out []byte) ([]Device, error) {
+	cards := map[string]map[string]string{}
+	if err := json.Unmarshal(out, &cards); err != nil {
+		return nil, fmt.Errorf("parse rocm-smi output: %w", err)
+	}
+
+	devices := make([]Device, 0, len(cards))
+	for card, fields := range cards {
+		index, err := strconv.Atoi(strings.TrimPrefix(card, "card"))
+		if err != nil {
+			// not a card, e.g. "system"
+			continue
+		}
+		field := func(names ...string) string {
+			for _, name := range names {
+				for key, value := range fields {
+					if strings.EqualFold(key, name) && value != "" && value != "N/A" {
+						return strings.TrimSpace(value)
+					}
+				}
+			}
+			return ""
+		}
+
+		device := Device{Index: index, UUID: field("Unique ID")}
+		if device.UUID == "" {
+			device.ReadError = fmt.Sprintf("missing unique id of %s", card)
+			devices = append(devices, device)
+			continue
+		}
+		vram, err := strconv.ParseUint(field("VRAM Total Memory (B)"), 10, 64)
+		if err != nil {
+			device.ReadError = fmt.Sprintf("invalid VRAM of %s: %v", card, err)
+			devices = append(devices, device)
+			continue
+		}
+		device.Model = field("Card Series", "Card Model")
+		device.VRAM = vram
+		// compared against the junction temperature limit, the edge temperature is not reported by all cards
+		if temperature, err := strconv.ParseFloat(field("Temperature (Sensor junction) (C)"), 64); err == nil {
+			device.Temperature = ptr.To(int32(temperature))
+		}
+		devices = append(devices, device)
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].Index < devices[j].Index
+	})
+	return devices, nil
+}
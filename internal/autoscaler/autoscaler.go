@@ -0,0 +1,185 @@
+// Package autoscaler adjusts replicas and resources of TensorFusionWorkloads based on usage in time series db
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/gpuallocator"
+	"github.com/NexusGPU/tensor-fusion/internal/metrics"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// interval between two evaluations of all workloads
+const DefaultEvaluationInterval = time.Minute
+
+// Autoscaler periodically evaluates every workload with auto-scaling enabled,
+// auto-scaling config of the workload overrides the one of its pool's scheduling config template
+type Autoscaler struct {
+	client.Client
+	Recorder  record.EventRecorder
+	Allocator *gpuallocator.GpuAllocator
+	DB        *metrics.TimeSeriesDB
+
+	Interval time.Duration
+	now      func() time.Time
+}
+
+func NewAutoscaler(client client.Client, recorder record.EventRecorder, allocator *gpuallocator.GpuAllocator) *Autoscaler {
+	return &Autoscaler{
+		Client:    client,
+		Recorder:  recorder,
+		Allocator: allocator,
+		Interval:  DefaultEvaluationInterval,
+		now:       time.Now,
+	}
+}
+
+// Start evaluates workloads until ctx is done, it should only run on the leader
+func (a *Autoscaler) Start(ctx context.Context, db *metrics.TimeSeriesDB) {
+	log := log.FromContext(ctx)
+	a.DB = db
+	ticker := time.NewTicker(a.Interval)
+	defer ticker.Stop()
+
+	log.Info("Starting autoscaler", "interval", a.Interval)
+	for {
+		select {
+		case <-ticker.C:
+			a.Evaluate(ctx)
+		case <-ctx.Done():
+			log.Info("Stopping autoscaler")
+			return
+		}
+	}
+}
+
+// Evaluate runs one round of auto-scaling for all workloads
+func (a *Autoscaler) Evaluate(ctx context.Context) {
+	log := log.FromContext(ctx)
+	workloads := &tfv1.TensorFusionWorkloadList{}
+	if err := a.List(ctx, workloads); err != nil {
+		log.Error(err, "failed to list workloads for auto-scaling")
+		return
+	}
+
+	for i := range workloads.Items {
+		workload := &workloads.Items[i]
+		if !workload.DeletionTimestamp.IsZero() {
+			continue
+		}
+		config, err := a.resolveConfig(ctx, workload)
+		if err != nil {
+			log.Error(err, "failed to resolve auto-scaling config", "workload", workload.Name, "namespace", workload.Namespace)
+			continue
+		}
+		if config.AutoSetLimits.Enable {
+			if err := a.updateLimits(ctx, workload, &config.AutoSetLimits); err != nil {
+				log.Error(err, "failed to auto set limits", "workload", workload.Name, "namespace", workload.Namespace)
+			}
+		}
+		if config.AutoSetReplicas.Enable {
+			if err := a.scaleReplicas(ctx, workload, &config.AutoSetReplicas); err != nil {
+				log.Error(err, "failed to auto set replicas", "workload", workload.Name, "namespace", workload.Namespace)
+			}
+		}
+		if freezeConfig := autoFreezeFor(config.ScaleToZero.AutoFreeze, workload.Spec.Qos); freezeConfig != nil {
+			if err := a.freezeIdleWorkers(ctx, workload, freezeConfig); err != nil {
+				log.Error(err, "failed to auto freeze workers", "workload", workload.Name, "namespace", workload.Namespace)
+			}
+		}
+		// requests are recommended for all workloads, and only applied when enabled
+		if err := a.updateRequests(ctx, workload, &config.AutoSetRequests); err != nil {
+			log.Error(err, "failed to auto set requests", "workload", workload.Name, "namespace", workload.Namespace)
+		}
+	}
+}
+
+// resolveConfig returns the auto-scaling config of the workload, features not enabled
+// on the workload fall back to the pool's scheduling config template
+func (a *Autoscaler) resolveConfig(ctx context.Context, workload *tfv1.TensorFusionWorkload) (*tfv1.AutoScalingConfig, error) {
+	config := workload.Spec.AutoScalingConfig.DeepCopy()
+
+	pool := &tfv1.GPUPool{}
+	if err := a.Get(ctx, client.ObjectKey{Name: workload.Spec.PoolName}, pool); err != nil {
+		return nil, fmt.Errorf("get pool %s: %w", workload.Spec.PoolName, err)
+	}
+	if pool.Spec.SchedulingConfigTemplate == nil {
+		return config, nil
+	}
+	template := &tfv1.SchedulingConfigTemplate{}
+	if err := a.Get(ctx, client.ObjectKey{Name: *pool.Spec.SchedulingConfigTemplate}, template); err != nil {
+		return nil, fmt.Errorf("get scheduling config template %s: %w", *pool.Spec.SchedulingConfigTemplate, err)
+	}
+	if template.Spec.AutoScaling == nil {
+		return config, nil
+	}
+
+	if !config.AutoSetLimits.Enable {
+		config.AutoSetLimits = *template.Spec.AutoScaling.AutoSetLimits.DeepCopy()
+	}
+	if len(config.ScaleToZero.AutoFreeze) == 0 {
+		config.ScaleToZero = *template.Spec.AutoScaling.ScaleToZero.DeepCopy()
+	}
+	if !config.AutoSetReplicas.Enable {
+		config.AutoSetReplicas = template.Spec.AutoScaling.AutoSetReplicas
+	}
+	if !config.AutoSetRequests.Enable {
+		config.AutoSetRequests = *template.Spec.AutoScaling.AutoSetRequests.DeepCopy()
+	}
+	return config, nil
+}
+
+// parsePercent parses values like "80" or "80%" to 0.8, empty value means the default
+func parsePercent(value string, defaultValue float64) (float64, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse percent %q: %w", value, err)
+	}
+	return percent / 100, nil
+}
+
+// parseRatio parses values like "0.1" or "10%" to 0.1, empty value means the default
+func parseRatio(value string, defaultValue float64) (float64, error) {
+	if strings.HasSuffix(value, "%") {
+		return parsePercent(value, defaultValue)
+	}
+	if value == "" {
+		return defaultValue, nil
+	}
+	ratio, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse ratio %q: %w", value, err)
+	}
+	return ratio, nil
+}
+
+// parseStep parses a step of absolute replicas like "2" or a percentage of current replicas like "50%",
+// a step is at least 1
+func parseStep(value string, current int32, defaultValue int32) (int32, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	if strings.HasSuffix(value, "%") {
+		percent, err := parsePercent(value, 0)
+		if err != nil {
+			return 0, err
+		}
+		return max(int32(math.Ceil(float64(current)*percent)), 1), nil
+	}
+	step, err := strconv.ParseInt(strings.TrimSpace(value), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("parse step %q: %w", value, err)
+	}
+	return max(int32(step), 1), nil
+}
@@ -0,0 +1,146 @@
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/NexusGPU/tensor-fusion/internal/metrics"
+	"github.com/NexusGPU/tensor-fusion/internal/utils"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// usage samples older than this are considered lagging, workers are not frozen until samples are fresh again
+const freezeMaxSampleLag = 2 * time.Minute
+
+// autoFreezeFor returns the enabled auto freeze config of the QoS level,
+// a config without QoS applies to all QoS levels not configured explicitly
+func autoFreezeFor(configs []tfv1.AutoFreeze, qos tfv1.QoSLevel) *tfv1.AutoFreeze {
+	config, ok := lo.Find(configs, func(config tfv1.AutoFreeze) bool {
+		return config.Qos == qos
+	})
+	if !ok {
+		config, ok = lo.Find(configs, func(config tfv1.AutoFreeze) bool {
+			return config.Qos == ""
+		})
+	}
+	if !ok || !ptr.Deref(config.Enable, false) {
+		return nil
+	}
+	return &config
+}
+
+// freezeLevel returns where the GPU context of a worker idle for the given duration should be frozen to,
+// empty when the worker should keep running
+func freezeLevel(config *tfv1.AutoFreeze, idle time.Duration) (string, error) {
+	toMemTTL, err := utils.ParseDuration(config.FreezeToMemTTL, 0)
+	if err != nil {
+		return "", err
+	}
+	toDiskTTL, err := utils.ParseDuration(config.FreezeToDiskTTL, 0)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case toDiskTTL > 0 && idle >= toDiskTTL:
+		return constants.FrozenToDisk, nil
+	case toMemTTL > 0 && idle >= toMemTTL:
+		return constants.FrozenToMem, nil
+	default:
+		return "", nil
+	}
+}
+
+// idleDuration returns how long the worker has been idle according to its usage samples, the worker is only
+// known to be idle for the time covered by samples up to now, false when samples lag behind
+func idleDuration(activity metrics.WorkerActivity, now time.Time) (time.Duration, bool) {
+	if now.Sub(activity.LastSample) > freezeMaxSampleLag {
+		return 0, false
+	}
+	idleSince := activity.FirstSample
+	if activity.LastActive != nil && activity.LastActive.After(idleSince) {
+		idleSince = *activity.LastActive
+	}
+	return now.Sub(idleSince), true
+}
+
+// freezeIdleWorkers freezes GPU context of idle workers to host memory and then to disk, GPUs of frozen
+// workers are released by the workload controller once the worker confirms, they are allocated again
+// when a connection wakes the worker up
+func (a *Autoscaler) freezeIdleWorkers(ctx context.Context, workload *tfv1.TensorFusionWorkload, config *tfv1.AutoFreeze) error {
+	log := log.FromContext(ctx)
+	if a.DB == nil {
+		return nil
+	}
+	toMemTTL, err := utils.ParseDuration(config.FreezeToMemTTL, 0)
+	if err != nil {
+		return err
+	}
+	toDiskTTL, err := utils.ParseDuration(config.FreezeToDiskTTL, 0)
+	if err != nil {
+		return err
+	}
+	lookBack := max(toMemTTL, toDiskTTL)
+	if lookBack == 0 {
+		return nil
+	}
+
+	now := a.now()
+	windowStart := now.Add(-lookBack - a.Interval)
+	activities, err := a.DB.FindWorkerActivity(tfv1.NameNamespace{Namespace: workload.Namespace, Name: workload.Name}, workload.Spec.PoolName, windowStart)
+	if err != nil {
+		return fmt.Errorf("find activity of workers: %w", err)
+	}
+	activityByWorker := lo.SliceToMap(activities, func(item metrics.WorkerActivity) (string, metrics.WorkerActivity) {
+		return item.WorkerName, item
+	})
+
+	pods := &corev1.PodList{}
+	if err := a.List(ctx, pods, client.InNamespace(workload.Namespace),
+		client.MatchingLabels{constants.WorkloadKey: workload.Name}); err != nil {
+		return fmt.Errorf("list workers: %w", err)
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		current := pod.Annotations[constants.WorkerFrozenAnnotation]
+		if !pod.DeletionTimestamp.IsZero() || pod.Status.Phase != corev1.PodRunning || pod.Status.StartTime == nil ||
+			pod.Annotations[constants.GpuKey] == "" || pod.Annotations[constants.WorkerWakeUpAnnotation] != "" ||
+			current == constants.FrozenToDisk {
+			continue
+		}
+
+		// workers without samples are unknown rather than idle, usage may not be reported yet or the DB lags
+		activity, ok := activityByWorker[pod.Name]
+		if !ok {
+			continue
+		}
+		idle, ok := idleDuration(activity, now)
+		if !ok {
+			continue
+		}
+		level, err := freezeLevel(config, idle)
+		if err != nil {
+			return err
+		}
+		if level == "" || level == current {
+			continue
+		}
+
+		patch := client.MergeFrom(pod.DeepCopy())
+		pod.Annotations[constants.WorkerFrozenAnnotation] = level
+		if err := a.Patch(ctx, pod, patch); err != nil {
+			return fmt.Errorf("freeze worker %s: %w", pod.Name, err)
+		}
+
+		a.Recorder.Eventf(workload, corev1.EventTypeNormal, "WorkerFrozen",
+			"Worker %s idle for %s is frozen to %s", pod.Name, idle.Round(time.Second), level)
+		log.Info("auto freeze worker", "worker", pod.Name, "namespace", pod.Namespace, "frozenTo", level)
+	}
+	return nil
+}
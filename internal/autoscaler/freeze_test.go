@@ -0,0 +1,74 @@
+package autoscaler
+
+import (
+	"testing"
+	"time"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/NexusGPU/tensor-fusion/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestAutoFreezeFor(t *testing.T) {
+	configs := []tfv1.AutoFreeze{
+		{Qos: tfv1.QoSLow, FreezeToMemTTL: "5m", Enable: ptr.To(true)},
+		{Qos: tfv1.QoSCritical, FreezeToMemTTL: "5m", Enable: ptr.To(false)},
+		{FreezeToMemTTL: "30m", Enable: ptr.To(true)},
+	}
+	assert.Equal(t, "5m", autoFreezeFor(configs, tfv1.QoSLow).FreezeToMemTTL)
+	assert.Equal(t, "30m", autoFreezeFor(configs, tfv1.QoSMedium).FreezeToMemTTL)
+	assert.Nil(t, autoFreezeFor(configs, tfv1.QoSCritical))
+	assert.Nil(t, autoFreezeFor(nil, tfv1.QoSLow))
+}
+
+func TestFreezeLevel(t *testing.T) {
+	config := &tfv1.AutoFreeze{FreezeToMemTTL: "5m", FreezeToDiskTTL: "1h"}
+	tests := []struct {
+		idle     time.Duration
+		expected string
+	}{
+		{idle: time.Minute, expected: ""},
+		{idle: 10 * time.Minute, expected: constants.FrozenToMem},
+		{idle: 2 * time.Hour, expected: constants.FrozenToDisk},
+	}
+	for _, tt := range tests {
+		level, err := freezeLevel(config, tt.idle)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, level, "idle %s", tt.idle)
+	}
+
+	// freeze to disk directly when memory TTL is not set
+	level, err := freezeLevel(&tfv1.AutoFreeze{FreezeToDiskTTL: "1h"}, 2*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, constants.FrozenToDisk, level)
+}
+
+func TestIdleDuration(t *testing.T) {
+	now := time.Now()
+
+	// idle since the first sample when no TFlops were used
+	idle, ok := idleDuration(metrics.WorkerActivity{FirstSample: now.Add(-time.Hour), LastSample: now.Add(-30 * time.Second)}, now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Hour, idle)
+
+	// idle since the last active sample
+	idle, ok = idleDuration(metrics.WorkerActivity{
+		FirstSample: now.Add(-time.Hour),
+		LastSample:  now.Add(-30 * time.Second),
+		LastActive:  ptr.To(now.Add(-10 * time.Minute)),
+	}, now)
+	assert.True(t, ok)
+	assert.Equal(t, 10*time.Minute, idle)
+
+	// a short history does not cover the freeze TTL
+	idle, ok = idleDuration(metrics.WorkerActivity{FirstSample: now.Add(-time.Minute), LastSample: now}, now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, idle)
+
+	// lagging samples do not tell whether the worker is still idle
+	_, ok = idleDuration(metrics.WorkerActivity{FirstSample: now.Add(-time.Hour), LastSample: now.Add(-10 * time.Minute)}, now)
+	assert.False(t, ok)
+}
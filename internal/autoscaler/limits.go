@@ -0,0 +1,181 @@
+package autoscaler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/NexusGPU/tensor-fusion/internal/gpuallocator"
+	"github.com/NexusGPU/tensor-fusion/internal/metrics"
+	"github.com/NexusGPU/tensor-fusion/internal/utils"
+	"github.com/NexusGPU/tensor-fusion/internal/worker"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	defaultLimitsEvaluation         = 5 * time.Minute
+	defaultExtraTFlopsBufferRatio   = 0.2
+	defaultLimitsIgnoredDeltaRange  = 0.05
+	defaultLimitsScaleUpStep        = 1.2
+	defaultLimitsMaxRatioToRequests = 2.0
+)
+
+// limitsInput is everything needed to decide the TFlops limit of one GPU of a worker
+type limitsInput struct {
+	request float64
+	current float64
+	// peak TFlops used on one GPU in evaluation period
+	usedTflops float64
+	throttled  int64
+	// TFlops not allocated on the least available GPU of the worker
+	headroom float64
+}
+
+// computeDesiredLimit raises the limit of throttled workers by scale up step, and lowers the limit
+// towards peak usage plus buffer otherwise, bounded by requests, MaxRatioToRequests and GPU headroom
+func computeDesiredLimit(config *tfv1.AutoSetLimits, in limitsInput) (float64, error) {
+	buffer, err := parseRatio(config.ExtraTFlopsBufferRatio, defaultExtraTFlopsBufferRatio)
+	if err != nil {
+		return 0, err
+	}
+	ignoredDelta, err := parseRatio(config.IgnoredDeltaRange, defaultLimitsIgnoredDeltaRange)
+	if err != nil {
+		return 0, err
+	}
+	scaleUpStep, err := parseRatio(config.ScaleUpStep, defaultLimitsScaleUpStep)
+	if err != nil {
+		return 0, err
+	}
+	maxRatio, err := parseRatio(config.MaxRatioToRequests, defaultLimitsMaxRatioToRequests)
+	if err != nil {
+		return 0, err
+	}
+
+	desired := in.usedTflops * (1 + buffer)
+	if in.throttled > 0 {
+		desired = max(desired, in.current*scaleUpStep)
+	}
+	desired = max(desired, in.request)
+	desired = min(desired, in.request*maxRatio, in.request+in.headroom)
+	// limits are never lower than requests, even when GPU is oversold
+	desired = max(desired, in.request)
+
+	if in.current > 0 && math.Abs(desired-in.current)/in.current <= ignoredDelta {
+		return in.current, nil
+	}
+	return desired, nil
+}
+
+// updateLimits adjusts TFlops limits of running workers of the workload, limits are pushed to
+// worker pod annotations which are mounted into workers, thus workers are not restarted
+func (a *Autoscaler) updateLimits(ctx context.Context, workload *tfv1.TensorFusionWorkload, config *tfv1.AutoSetLimits) error {
+	if a.DB == nil || a.Allocator == nil {
+		return nil
+	}
+	// VRAM limits can not be changed for running workers
+	if config.TargetResource == targetResourceVram {
+		return nil
+	}
+	evaluationPeriod, err := utils.ParseDuration(config.EvaluationPeriod, defaultLimitsEvaluation)
+	if err != nil {
+		return err
+	}
+
+	usages, err := a.DB.FindWorkerThrottling(tfv1.NameNamespace{Namespace: workload.Namespace, Name: workload.Name}, workload.Spec.PoolName, a.now().Add(-evaluationPeriod))
+	if err != nil {
+		return fmt.Errorf("find worker throttling: %w", err)
+	}
+	if len(usages) == 0 {
+		return nil
+	}
+	usageByWorker := lo.SliceToMap(usages, func(usage metrics.WorkerUsageSummary) (string, metrics.WorkerUsageSummary) {
+		return usage.WorkerName, usage
+	})
+
+	pods := &corev1.PodList{}
+	if err := a.List(ctx, pods, client.InNamespace(workload.Namespace),
+		client.MatchingLabels{constants.WorkloadKey: workload.Name}); err != nil {
+		return fmt.Errorf("list workers: %w", err)
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		usage, ok := usageByWorker[pod.Name]
+		// frozen workers hold no GPUs
+		if !ok || !pod.DeletionTimestamp.IsZero() || pod.Annotations[constants.GpuKey] == "" ||
+			pod.Annotations[constants.WorkerFrozenAnnotation] != "" {
+			continue
+		}
+		if err := a.updateWorkerLimit(ctx, workload, pod, config, usage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *Autoscaler) updateWorkerLimit(
+	ctx context.Context,
+	workload *tfv1.TensorFusionWorkload,
+	pod *corev1.Pod,
+	config *tfv1.AutoSetLimits,
+	usage metrics.WorkerUsageSummary,
+) error {
+	log := log.FromContext(ctx)
+	gpus := lo.Map(strings.Split(pod.Annotations[constants.GpuKey], ","), func(gpuName string, _ int) types.NamespacedName {
+		return types.NamespacedName{Name: gpuName}
+	})
+	headroom, err := a.Allocator.TflopsHeadroom(gpus)
+	if err != nil {
+		return fmt.Errorf("get headroom of worker %s: %w", pod.Name, err)
+	}
+
+	// limits are set per GPU, usage is reported for the whole worker
+	resources := worker.WorkerResources(pod)
+	desired, err := computeDesiredLimit(config, limitsInput{
+		request:    resources.Requests.Tflops.AsApproximateFloat64(),
+		current:    resources.Limits.Tflops.AsApproximateFloat64(),
+		usedTflops: usage.ComputeTflops / float64(len(gpus)),
+		throttled:  usage.ThrottledCount,
+		headroom:   headroom.AsApproximateFloat64(),
+	})
+	if err != nil {
+		return err
+	}
+	newLimit := *resources.Limits.DeepCopy()
+	newLimit.Tflops = *resource.NewMilliQuantity(ceilUnits(desired, 0.001), resource.DecimalSI)
+	if newLimit.Tflops.Cmp(resources.Limits.Tflops) == 0 {
+		return nil
+	}
+
+	if err := a.Allocator.UpdateLimits(ctx, pod.Namespace, gpus, resources.Limits, newLimit); err != nil {
+		if errors.Is(err, gpuallocator.ErrQuotaExceeded) {
+			a.Recorder.Eventf(workload, corev1.EventTypeWarning, "QuotaExceeded",
+				"Can not raise TFlops limit of worker %s to %s: %v", pod.Name, newLimit.Tflops.String(), err)
+			return nil
+		}
+		return fmt.Errorf("update limits of worker %s: %w", pod.Name, err)
+	}
+	patch := client.MergeFrom(pod.DeepCopy())
+	pod.Annotations[constants.TFLOPSLimitAnnotation] = newLimit.Tflops.String()
+	if err := a.Patch(ctx, pod, patch); err != nil {
+		// keep quota usage consistent with limits of the running worker
+		_ = a.Allocator.UpdateLimits(ctx, pod.Namespace, gpus, newLimit, resources.Limits)
+		return fmt.Errorf("push limits to worker %s: %w", pod.Name, err)
+	}
+
+	a.Recorder.Eventf(workload, corev1.EventTypeNormal, "LimitsUpdated",
+		"Updated TFlops limit of worker %s from %s to %s, throttled %d times",
+		pod.Name, resources.Limits.Tflops.String(), newLimit.Tflops.String(), usage.ThrottledCount)
+	log.Info("auto set limits", "worker", pod.Name, "namespace", pod.Namespace,
+		"from", resources.Limits.Tflops.String(), "to", newLimit.Tflops.String(), "throttled", usage.ThrottledCount)
+	return nil
+}
@@ -0,0 +1,63 @@
+package autoscaler
+
+import (
+	"testing"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeDesiredLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   tfv1.AutoSetLimits
+		input    limitsInput
+		expected float64
+	}{
+		{
+			name:     "raise limit of throttled worker by step",
+			config:   tfv1.AutoSetLimits{ScaleUpStep: "1.5"},
+			input:    limitsInput{request: 20, current: 20, usedTflops: 20, throttled: 10, headroom: 100},
+			expected: 30,
+		},
+		{
+			name:     "bounded by max ratio to requests",
+			config:   tfv1.AutoSetLimits{ScaleUpStep: "2", MaxRatioToRequests: "1.5"},
+			input:    limitsInput{request: 20, current: 20, usedTflops: 20, throttled: 10, headroom: 100},
+			expected: 30,
+		},
+		{
+			name:     "bounded by GPU headroom",
+			config:   tfv1.AutoSetLimits{ScaleUpStep: "2"},
+			input:    limitsInput{request: 20, current: 20, usedTflops: 20, throttled: 10, headroom: 5},
+			expected: 25,
+		},
+		{
+			name:     "lower limit to peak usage plus buffer",
+			config:   tfv1.AutoSetLimits{ExtraTFlopsBufferRatio: "0.1", MaxRatioToRequests: "5"},
+			input:    limitsInput{request: 10, current: 40, usedTflops: 20, headroom: 100},
+			expected: 22,
+		},
+		{
+			name:     "never lower than requests",
+			config:   tfv1.AutoSetLimits{},
+			input:    limitsInput{request: 20, current: 40, usedTflops: 1, headroom: 0},
+			expected: 20,
+		},
+		{
+			name:     "ignore small changes",
+			config:   tfv1.AutoSetLimits{ExtraTFlopsBufferRatio: "0", IgnoredDeltaRange: "10%", MaxRatioToRequests: "5"},
+			input:    limitsInput{request: 10, current: 40, usedTflops: 38, headroom: 100},
+			expected: 40,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desired, err := computeDesiredLimit(&tt.config, tt.input)
+			require.NoError(t, err)
+			assert.InDelta(t, tt.expected, desired, 0.0001)
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/NexusGPU/tensor-fusion/internal/metrics"
+	"github.com/NexusGPU/tensor-fusion/internal/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	defaultTargetTFlopsOfLimits  = 0.8
+	defaultReplicasEvaluation    = 5 * time.Minute
+	defaultScaleUpCoolDownTime   = 3 * time.Minute
+	defaultScaleDownCoolDownTime = 10 * time.Minute
+	defaultScaleStep             = 1
+)
+
+// replicasInput is everything needed to decide the replicas of a workload
+type replicasInput struct {
+	current int32
+	// sum of average TFlops used by all workers in evaluation period
+	usedTflops float64
+	// TFlops limit of one replica, all GPUs of the replica included
+	replicaLimitTflops float64
+
+	lastScaleUp   time.Time
+	lastScaleDown time.Time
+	now           time.Time
+}
+
+// computeDesiredReplicas returns the replicas that keep the TFlops usage of each replica
+// around targetTFlopsOfLimits, limited by scale steps and cool down time
+func computeDesiredReplicas(config *tfv1.AutoSetReplicas, in replicasInput) (int32, error) {
+	target, err := parsePercent(config.TargetTFlopsOfLimits, defaultTargetTFlopsOfLimits)
+	if err != nil {
+		return 0, err
+	}
+	scaleUpCoolDown, err := utils.ParseDuration(config.ScaleUpCoolDownTime, defaultScaleUpCoolDownTime)
+	if err != nil {
+		return 0, err
+	}
+	scaleDownCoolDown, err := utils.ParseDuration(config.ScaleDownCoolDownTime, defaultScaleDownCoolDownTime)
+	if err != nil {
+		return 0, err
+	}
+	scaleUpStep, err := parseStep(config.ScaleUpStep, in.current, defaultScaleStep)
+	if err != nil {
+		return 0, err
+	}
+	scaleDownStep, err := parseStep(config.ScaleDownStep, in.current, defaultScaleStep)
+	if err != nil {
+		return 0, err
+	}
+	if target <= 0 || in.replicaLimitTflops <= 0 {
+		return in.current, nil
+	}
+
+	desired := max(int32(math.Ceil(in.usedTflops/(in.replicaLimitTflops*target))), 1)
+	switch {
+	case desired > in.current:
+		if in.now.Sub(in.lastScaleUp) < scaleUpCoolDown {
+			return in.current, nil
+		}
+		return min(desired, in.current+scaleUpStep), nil
+	case desired < in.current:
+		// scaling down right after scaling up causes flapping, cool down after both
+		lastScale := in.lastScaleDown
+		if in.lastScaleUp.After(lastScale) {
+			lastScale = in.lastScaleUp
+		}
+		if in.now.Sub(lastScale) < scaleDownCoolDown {
+			return in.current, nil
+		}
+		return max(desired, in.current-scaleDownStep), nil
+	default:
+		return in.current, nil
+	}
+}
+
+// scaleReplicas updates replicas of the workload based on TFlops usage of its workers
+func (a *Autoscaler) scaleReplicas(ctx context.Context, workload *tfv1.TensorFusionWorkload, config *tfv1.AutoSetReplicas) error {
+	log := log.FromContext(ctx)
+	if a.DB == nil {
+		return nil
+	}
+	evaluationPeriod, err := utils.ParseDuration(config.EvaluationPeriod, defaultReplicasEvaluation)
+	if err != nil {
+		return err
+	}
+
+	now := a.now()
+	usages, err := a.DB.FindWorkerUsage(tfv1.NameNamespace{Namespace: workload.Namespace, Name: workload.Name}, workload.Spec.PoolName, now.Add(-evaluationPeriod))
+	if err != nil {
+		return fmt.Errorf("find worker usage: %w", err)
+	}
+	// no usage reported yet, workers may be still starting
+	if len(usages) == 0 {
+		return nil
+	}
+	usedTflops := 0.0
+	for _, usage := range usages {
+		usedTflops += usage.ComputeTflops
+	}
+
+	current := ptr.Deref(workload.Spec.Replicas, 1)
+	desired, err := computeDesiredReplicas(config, replicasInput{
+		current:            current,
+		usedTflops:         usedTflops,
+		replicaLimitTflops: workload.Spec.Resources.Limits.Tflops.AsApproximateFloat64() * float64(max(workload.Spec.GPUCount, 1)),
+		lastScaleUp:        parseAnnotationTime(workload, constants.LastScaleUpTimeAnnotation),
+		lastScaleDown:      parseAnnotationTime(workload, constants.LastScaleDownTimeAnnotation),
+		now:                now,
+	})
+	if err != nil {
+		return err
+	}
+	if desired == current {
+		return nil
+	}
+
+	isScaleUp := desired > current
+	patch := client.MergeFrom(workload.DeepCopy())
+	workload.Spec.Replicas = ptr.To(desired)
+	if workload.Annotations == nil {
+		workload.Annotations = map[string]string{}
+	}
+	if isScaleUp {
+		workload.Annotations[constants.LastScaleUpTimeAnnotation] = now.Format(time.RFC3339)
+	} else {
+		workload.Annotations[constants.LastScaleDownTimeAnnotation] = now.Format(time.RFC3339)
+	}
+	if err := a.Patch(ctx, workload, patch); err != nil {
+		return fmt.Errorf("update replicas of workload %s: %w", workload.Name, err)
+	}
+
+	metrics.SetAutoscalingMetrics(workload.Spec.PoolName, isScaleUp)
+	reason := "ScaledDown"
+	if isScaleUp {
+		reason = "ScaledUp"
+	}
+	a.Recorder.Eventf(workload, corev1.EventTypeNormal, reason,
+		"Scaled replicas from %d to %d, used TFlops %.2f", current, desired, usedTflops)
+	log.Info("auto set replicas", "workload", workload.Name, "namespace", workload.Namespace,
+		"from", current, "to", desired, "usedTflops", usedTflops)
+	return nil
+}
+
+func parseAnnotationTime(workload *tfv1.TensorFusionWorkload, key string) time.Time {
+	value, ok := workload.Annotations[key]
+	if !ok {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
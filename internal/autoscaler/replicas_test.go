@@ -0,0 +1,76 @@
+package autoscaler
+
+import (
+	"testing"
+	"time"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeDesiredReplicas(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		config   tfv1.AutoSetReplicas
+		input    replicasInput
+		expected int32
+	}{
+		{
+			name:     "scale up by one step",
+			config:   tfv1.AutoSetReplicas{TargetTFlopsOfLimits: "50"},
+			input:    replicasInput{current: 2, usedTflops: 400, replicaLimitTflops: 100, now: now},
+			expected: 3,
+		},
+		{
+			name:     "scale up by percentage step",
+			config:   tfv1.AutoSetReplicas{TargetTFlopsOfLimits: "50%", ScaleUpStep: "100%"},
+			input:    replicasInput{current: 2, usedTflops: 400, replicaLimitTflops: 100, now: now},
+			expected: 4,
+		},
+		{
+			name:     "scale up in cool down",
+			config:   tfv1.AutoSetReplicas{ScaleUpCoolDownTime: "5m"},
+			input:    replicasInput{current: 1, usedTflops: 400, replicaLimitTflops: 100, lastScaleUp: now.Add(-time.Minute), now: now},
+			expected: 1,
+		},
+		{
+			name:     "scale down by step",
+			config:   tfv1.AutoSetReplicas{ScaleDownStep: "2"},
+			input:    replicasInput{current: 5, usedTflops: 10, replicaLimitTflops: 100, now: now},
+			expected: 3,
+		},
+		{
+			name:     "scale down cools down after scale up",
+			config:   tfv1.AutoSetReplicas{ScaleDownCoolDownTime: "10m"},
+			input:    replicasInput{current: 5, usedTflops: 10, replicaLimitTflops: 100, lastScaleUp: now.Add(-5 * time.Minute), now: now},
+			expected: 5,
+		},
+		{
+			name:     "keep at least one replica",
+			config:   tfv1.AutoSetReplicas{ScaleDownStep: "10"},
+			input:    replicasInput{current: 3, usedTflops: 0, replicaLimitTflops: 100, now: now},
+			expected: 1,
+		},
+		{
+			name:     "usage around target",
+			config:   tfv1.AutoSetReplicas{TargetTFlopsOfLimits: "80"},
+			input:    replicasInput{current: 2, usedTflops: 150, replicaLimitTflops: 100, now: now},
+			expected: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desired, err := computeDesiredReplicas(&tt.config, tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, desired)
+		})
+	}
+}
+
+func TestComputeDesiredReplicasInvalidConfig(t *testing.T) {
+	_, err := computeDesiredReplicas(&tfv1.AutoSetReplicas{ScaleUpStep: "two"}, replicasInput{current: 1})
+	assert.Error(t, err)
+}
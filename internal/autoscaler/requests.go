@@ -0,0 +1,162 @@
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/NexusGPU/tensor-fusion/internal/metrics"
+	"github.com/NexusGPU/tensor-fusion/internal/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	defaultRequestsPercentile  = 0.95
+	defaultRequestsBufferRatio = 0.1
+	defaultRequestsEvaluation  = 7 * 24 * time.Hour
+	defaultRequestsAggregation = 5 * time.Minute
+
+	// applying requests restarts all workers, skip recommendations close to current requests
+	requestsIgnoredDeltaRatio = 0.1
+
+	targetResourceTflops = "tflops"
+	targetResourceVram   = "vram"
+)
+
+// recommendRequests returns the percentile of usage samples plus buffer,
+// resources not targeted keep the current requests
+func recommendRequests(config *tfv1.AutoSetRequests, current tfv1.Resource, samples []metrics.WorkerUsageSummary) (tfv1.Resource, error) {
+	p, err := parsePercent(config.PercentileForAutoRequests, defaultRequestsPercentile)
+	if err != nil {
+		return tfv1.Resource{}, err
+	}
+	buffer, err := parseRatio(config.ExtraBufferRatio, defaultRequestsBufferRatio)
+	if err != nil {
+		return tfv1.Resource{}, err
+	}
+
+	recommended := *current.DeepCopy()
+	if config.TargetResource != targetResourceVram {
+		tflops := percentile(samples, p, func(sample metrics.WorkerUsageSummary) float64 {
+			return sample.ComputeTflops
+		}) * (1 + buffer)
+		recommended.Tflops = *resource.NewMilliQuantity(ceilUnits(tflops, 0.001), resource.DecimalSI)
+	}
+	if config.TargetResource != targetResourceTflops {
+		vram := percentile(samples, p, func(sample metrics.WorkerUsageSummary) float64 {
+			return sample.VRAMBytes
+		}) * (1 + buffer)
+		// round up to MiB to keep requests readable
+		recommended.Vram = *resource.NewQuantity(ceilUnits(vram, 1<<20)<<20, resource.BinarySI)
+	}
+	return recommended, nil
+}
+
+// ceilUnits returns how many units are needed to hold the value, ignoring float rounding errors
+func ceilUnits(value float64, unit float64) int64 {
+	return int64(math.Ceil(math.Round(value/unit*1e6) / 1e6))
+}
+
+// percentile returns the nearest-rank percentile of the samples
+func percentile(samples []metrics.WorkerUsageSummary, p float64, value func(metrics.WorkerUsageSummary) float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	values := make([]float64, 0, len(samples))
+	for _, sample := range samples {
+		values = append(values, value(sample))
+	}
+	slices.Sort(values)
+	rank := int(math.Ceil(p*float64(len(values)))) - 1
+	return values[min(max(rank, 0), len(values)-1)]
+}
+
+// significantlyChanged reports whether any resource changed by more than requestsIgnoredDeltaRatio
+func significantlyChanged(current, recommended tfv1.Resource) bool {
+	changed := func(current, recommended resource.Quantity) bool {
+		currentValue := current.AsApproximateFloat64()
+		if currentValue == 0 {
+			return !recommended.IsZero()
+		}
+		return math.Abs(recommended.AsApproximateFloat64()-currentValue)/currentValue > requestsIgnoredDeltaRatio
+	}
+	return changed(current.Tflops, recommended.Tflops) || changed(current.Vram, recommended.Vram)
+}
+
+// updateRequests writes recommended requests to workload status,
+// and applies them to workload spec when auto set requests is enabled, changed spec rolls all workers
+func (a *Autoscaler) updateRequests(ctx context.Context, workload *tfv1.TensorFusionWorkload, config *tfv1.AutoSetRequests) error {
+	log := log.FromContext(ctx)
+	if a.DB == nil {
+		return nil
+	}
+	evaluationPeriod, err := utils.ParseDuration(config.EvaluationPeriod, defaultRequestsEvaluation)
+	if err != nil {
+		return err
+	}
+	aggregationPeriod, err := utils.ParseDuration(config.AggregationPeriod, defaultRequestsAggregation)
+	if err != nil {
+		return err
+	}
+
+	now := a.now()
+	recommendation := workload.Status.Recommendation
+	// samples only change once per aggregation period
+	if recommendation == nil || now.Sub(recommendation.LastUpdateTime.Time) >= aggregationPeriod {
+		samples, err := a.DB.FindWorkerUsageSamples(tfv1.NameNamespace{Namespace: workload.Namespace, Name: workload.Name}, workload.Spec.PoolName, now.Add(-evaluationPeriod), aggregationPeriod)
+		if err != nil {
+			return fmt.Errorf("find worker usage samples: %w", err)
+		}
+		if len(samples) == 0 {
+			return nil
+		}
+		requests, err := recommendRequests(config, workload.Spec.Resources.Requests, samples)
+		if err != nil {
+			return err
+		}
+		patch := client.MergeFrom(workload.DeepCopy())
+		recommendation = &tfv1.ResourceRecommendation{Requests: requests, LastUpdateTime: metav1.NewTime(now)}
+		workload.Status.Recommendation = recommendation
+		if err := a.Status().Patch(ctx, workload, patch); err != nil {
+			return fmt.Errorf("update recommendation of workload %s: %w", workload.Name, err)
+		}
+	}
+
+	current := workload.Spec.Resources.Requests
+	if !config.Enable || !significantlyChanged(current, recommendation.Requests) {
+		return nil
+	}
+
+	patch := client.MergeFrom(workload.DeepCopy())
+	workload.Spec.Resources.Requests = *recommendation.Requests.DeepCopy()
+	// limits can not be lower than requests
+	if workload.Spec.Resources.Limits.Tflops.Cmp(recommendation.Requests.Tflops) < 0 {
+		workload.Spec.Resources.Limits.Tflops = recommendation.Requests.Tflops.DeepCopy()
+	}
+	if workload.Spec.Resources.Limits.Vram.Cmp(recommendation.Requests.Vram) < 0 {
+		workload.Spec.Resources.Limits.Vram = recommendation.Requests.Vram.DeepCopy()
+	}
+	if workload.Annotations == nil {
+		workload.Annotations = map[string]string{}
+	}
+	workload.Annotations[constants.LastRequestsUpdateTimeAnnotation] = now.Format(time.RFC3339)
+	if err := a.Patch(ctx, workload, patch); err != nil {
+		return fmt.Errorf("update requests of workload %s: %w", workload.Name, err)
+	}
+
+	a.Recorder.Eventf(workload, corev1.EventTypeNormal, "RequestsUpdated",
+		"Updated requests from %s TFlops/%s VRAM to %s TFlops/%s VRAM, workers will be restarted",
+		current.Tflops.String(), current.Vram.String(),
+		recommendation.Requests.Tflops.String(), recommendation.Requests.Vram.String())
+	log.Info("auto set requests", "workload", workload.Name, "namespace", workload.Namespace,
+		"tflops", recommendation.Requests.Tflops.String(), "vram", recommendation.Requests.Vram.String())
+	return nil
+}
@@ -0,0 +1,48 @@
+package autoscaler
+
+import (
+	"testing"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestRecommendRequests(t *testing.T) {
+	samples := make([]metrics.WorkerUsageSummary, 0, 100)
+	for i := 1; i <= 100; i++ {
+		samples = append(samples, metrics.WorkerUsageSummary{
+			WorkerName:    "worker-1",
+			ComputeTflops: float64(i),
+			VRAMBytes:     float64(i) * (1 << 20),
+		})
+	}
+	current := tfv1.Resource{Tflops: resource.MustParse("10"), Vram: resource.MustParse("1Gi")}
+
+	recommended, err := recommendRequests(&tfv1.AutoSetRequests{
+		PercentileForAutoRequests: "90",
+		ExtraBufferRatio:          "10%",
+	}, current, samples)
+	require.NoError(t, err)
+	assert.Equal(t, "99", recommended.Tflops.String())
+	assert.Equal(t, "99Mi", recommended.Vram.String())
+
+	// only tflops targeted, vram keeps current requests
+	recommended, err = recommendRequests(&tfv1.AutoSetRequests{
+		TargetResource:            "tflops",
+		PercentileForAutoRequests: "50%",
+		ExtraBufferRatio:          "0",
+	}, current, samples)
+	require.NoError(t, err)
+	assert.Equal(t, "50", recommended.Tflops.String())
+	assert.Equal(t, "1Gi", recommended.Vram.String())
+}
+
+func TestSignificantlyChanged(t *testing.T) {
+	current := tfv1.Resource{Tflops: resource.MustParse("100"), Vram: resource.MustParse("10Gi")}
+	assert.False(t, significantlyChanged(current, tfv1.Resource{Tflops: resource.MustParse("105"), Vram: resource.MustParse("10Gi")}))
+	assert.True(t, significantlyChanged(current, tfv1.Resource{Tflops: resource.MustParse("80"), Vram: resource.MustParse("10Gi")}))
+	assert.True(t, significantlyChanged(current, tfv1.Resource{Tflops: resource.MustParse("100"), Vram: resource.MustParse("20Gi")}))
+}
@@ -0,0 +1,183 @@
+package common
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	BudgetPeriodDay     = "day"
+	BudgetPeriodMonth   = "month"
+	BudgetPeriodQuarter = "quarter"
+)
+
+// BudgetUsage is the virtual billing of GPU nodes in one accounting period, periods start at UTC midnight
+type BudgetUsage struct {
+	Period string
+	Budget float64
+	// Costs accrued by nodes since the start of the period
+	Spent float64
+	// Costs per hour of nodes still running
+	CostPerHour float64
+	// Time left until the end of the period
+	Remaining time.Duration
+}
+
+// Exceeded returns whether the accrued costs are over budget
+func (u BudgetUsage) Exceeded() bool {
+	return u.Spent > u.Budget
+}
+
+// WouldExceed returns whether running existing nodes and nodes costing extraCostPerHour until the end of
+// the period would go over budget
+func (u BudgetUsage) WouldExceed(extraCostPerHour float64) bool {
+	return u.Spent+(u.CostPerHour+extraCostPerHour)*u.Remaining.Hours() > u.Budget
+}
+
+// FitsBudget returns whether running nodes, except the ones costing removedCostPerHour in total,
+// until the end of each period stay within budget
+func FitsBudget(usages []BudgetUsage, removedCostPerHour float64) bool {
+	return !lo.SomeBy(usages, func(usage BudgetUsage) bool {
+		return usage.WouldExceed(-removedCostPerHour)
+	})
+}
+
+type budgetPeriod struct {
+	name       string
+	start, end time.Time
+}
+
+// budgetPeriods returns the accounting periods containing now
+func budgetPeriods(now time.Time) []budgetPeriod {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	quarter := time.Date(now.Year(), (now.Month()-1)/3*3+1, 1, 0, 0, 0, 0, time.UTC)
+	return []budgetPeriod{
+		{BudgetPeriodDay, today, today.AddDate(0, 0, 1)},
+		{BudgetPeriodMonth, month, month.AddDate(0, 1, 0)},
+		{BudgetPeriodQuarter, quarter, quarter.AddDate(0, 3, 0)},
+	}
+}
+
+// nodeCost returns the costs of the node from its creation or the period start until the end time
+func nodeCost(node *tfv1.GPUNode, periodStart, end time.Time) (float64, error) {
+	costPerHour, err := NodeCostPerHour(node)
+	if err != nil {
+		return 0, err
+	}
+	start := node.CreationTimestamp.UTC()
+	if start.Before(periodStart) {
+		start = periodStart
+	}
+	if !end.After(start) {
+		return 0, nil
+	}
+	return costPerHour * end.Sub(start).Hours(), nil
+}
+
+// EvaluateBudget sums GPUNode costs per hour over each accounting period with a budget set.
+// Existing nodes are billed since creation, costs of nodes already deleted are taken from accrued costs
+func EvaluateBudget(budget *tfv1.PeriodicalBudget, nodes []tfv1.GPUNode, accrued []tfv1.AccruedCost, now time.Time) ([]BudgetUsage, error) {
+	now = now.UTC()
+	budgets := map[string]string{
+		BudgetPeriodDay:     budget.BudgetPerDay,
+		BudgetPeriodMonth:   budget.BudgetPerMonth,
+		BudgetPeriodQuarter: budget.BudgetPerQuarter,
+	}
+
+	usages := make([]BudgetUsage, 0, len(budgets))
+	for _, period := range budgetPeriods(now) {
+		if budgets[period.name] == "" {
+			continue
+		}
+		amount, err := strconv.ParseFloat(budgets[period.name], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid budget per %s %q: %w", period.name, budgets[period.name], err)
+		}
+		usage := BudgetUsage{
+			Period:    period.name,
+			Budget:    amount,
+			Remaining: period.end.Sub(now),
+		}
+		var counted []string
+		if cost, ok := lo.Find(accrued, func(cost tfv1.AccruedCost) bool {
+			return cost.Period == period.name && cost.PeriodStart.Time.Equal(period.start)
+		}); ok {
+			spent, err := strconv.ParseFloat(cost.Cost, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid accrued cost per %s %q: %w", period.name, cost.Cost, err)
+			}
+			usage.Spent = spent
+			counted = cost.Nodes
+		}
+		for i := range nodes {
+			node := &nodes[i]
+			if slices.Contains(counted, node.Name) {
+				continue
+			}
+			spent, err := nodeCost(node, period.start, now)
+			if err != nil {
+				return nil, err
+			}
+			usage.Spent += spent
+			if node.DeletionTimestamp.IsZero() {
+				costPerHour, _ := NodeCostPerHour(node)
+				usage.CostPerHour += costPerHour
+			}
+		}
+		usages = append(usages, usage)
+	}
+	return usages, nil
+}
+
+// AccrueNodeCost adds costs of the deleted node until its deletion to the accrued costs of each accounting period,
+// costs of previous periods are dropped, nodes already counted are skipped
+func AccrueNodeCost(accrued []tfv1.AccruedCost, node *tfv1.GPUNode, now time.Time) ([]tfv1.AccruedCost, error) {
+	now = now.UTC()
+	end := now
+	if !node.DeletionTimestamp.IsZero() {
+		end = node.DeletionTimestamp.UTC()
+	}
+
+	result := make([]tfv1.AccruedCost, 0, len(accrued))
+	for _, period := range budgetPeriods(now) {
+		cost, ok := lo.Find(accrued, func(cost tfv1.AccruedCost) bool {
+			return cost.Period == period.name && cost.PeriodStart.Time.Equal(period.start)
+		})
+		if !ok {
+			cost = tfv1.AccruedCost{Period: period.name, PeriodStart: metav1.NewTime(period.start), Cost: "0"}
+		}
+		if !slices.Contains(cost.Nodes, node.Name) {
+			spent, err := strconv.ParseFloat(cost.Cost, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid accrued cost per %s %q: %w", period.name, cost.Cost, err)
+			}
+			nodeSpent, err := nodeCost(node, period.start, end)
+			if err != nil {
+				return nil, err
+			}
+			cost.Cost = strconv.FormatFloat(spent+nodeSpent, 'f', -1, 64)
+			cost.Nodes = append(slices.Clone(cost.Nodes), node.Name)
+		}
+		result = append(result, cost)
+	}
+	return result, nil
+}
+
+// NodeCostPerHour returns the cost per hour of the node, 0 when not set
+func NodeCostPerHour(node *tfv1.GPUNode) (float64, error) {
+	if node.Spec.CostPerHour == "" {
+		return 0, nil
+	}
+	cost, err := strconv.ParseFloat(node.Spec.CostPerHour, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cost per hour %q of node %s: %w", node.Spec.CostPerHour, node.Name, err)
+	}
+	return cost, nil
+}
@@ -0,0 +1,117 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestEvaluateBudget(t *testing.T) {
+	now := time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+	newNode := func(name, costPerHour string, created time.Time) tfv1.GPUNode {
+		return tfv1.GPUNode{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(created)},
+			Spec:       tfv1.GPUNodeSpec{CostPerHour: costPerHour},
+		}
+	}
+	nodes := []tfv1.GPUNode{
+		// running since the previous day, billed since the start of each period
+		newNode("a", "2", now.Add(-36*time.Hour)),
+		newNode("b", "1.5", now.Add(-2*time.Hour)),
+		newNode("manual", "", now.Add(-time.Hour)),
+	}
+
+	usages, err := EvaluateBudget(&tfv1.PeriodicalBudget{
+		BudgetPerDay:     "30",
+		BudgetPerQuarter: "5000",
+	}, nodes, nil, now)
+	require.NoError(t, err)
+	require.Len(t, usages, 2)
+
+	day := usages[0]
+	assert.Equal(t, BudgetPeriodDay, day.Period)
+	assert.InDelta(t, 2*12+1.5*2, day.Spent, 1e-9)
+	assert.InDelta(t, 3.5, day.CostPerHour, 1e-9)
+	assert.Equal(t, 12*time.Hour, day.Remaining)
+	// projected to 27+3.5*12 by the end of the day, only fits without both nodes
+	assert.False(t, FitsBudget(usages[:1], 0))
+	assert.False(t, FitsBudget(usages[:1], 2))
+	assert.True(t, FitsBudget(usages[:1], 3.5))
+	assert.False(t, day.Exceeded())
+	assert.True(t, day.WouldExceed(0), "27 spent and 42 more until midnight")
+
+	quarter := usages[1]
+	assert.Equal(t, BudgetPeriodQuarter, quarter.Period)
+	assert.InDelta(t, 2*36+1.5*2, quarter.Spent, 1e-9)
+	assert.Equal(t, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC).Sub(now), quarter.Remaining)
+	assert.False(t, quarter.WouldExceed(0))
+	assert.True(t, quarter.WouldExceed(10))
+
+	usages, err = EvaluateBudget(&tfv1.PeriodicalBudget{BudgetPerDay: "20"}, nodes, nil, now)
+	require.NoError(t, err)
+	assert.True(t, usages[0].Exceeded())
+
+	_, err = EvaluateBudget(&tfv1.PeriodicalBudget{BudgetPerMonth: "ten"}, nodes, nil, now)
+	assert.Error(t, err)
+}
+
+func TestAccrueNodeCostAfterTermination(t *testing.T) {
+	now := time.Date(2025, 5, 20, 12, 0, 0, 0, time.UTC)
+	budget := &tfv1.PeriodicalBudget{BudgetPerDay: "30", BudgetPerMonth: "1000"}
+	newNode := func(name string, created time.Time) tfv1.GPUNode {
+		return tfv1.GPUNode{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(created)},
+			Spec:       tfv1.GPUNodeSpec{CostPerHour: "2"},
+		}
+	}
+
+	// 10 hours of a node since midnight, 32 spent
+	expensive := newNode("expensive", now.Add(-10*time.Hour))
+	cheap := newNode("cheap", now.Add(-6*time.Hour))
+	usages, err := EvaluateBudget(budget, []tfv1.GPUNode{expensive, cheap}, nil, now)
+	require.NoError(t, err)
+	assert.InDelta(t, 32, usages[0].Spent, 1e-9)
+	assert.True(t, usages[0].Exceeded())
+
+	// the node is terminated, its costs are kept while it is still being deleted
+	expensive.DeletionTimestamp = ptr.To(metav1.NewTime(now))
+	accrued, err := AccrueNodeCost(nil, &expensive, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, accrued, 3)
+	assert.Equal(t, "20", accrued[0].Cost)
+	usages, err = EvaluateBudget(budget, []tfv1.GPUNode{expensive, cheap}, accrued, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.InDelta(t, 32+2.0/60, usages[0].Spent, 1e-9)
+	assert.InDelta(t, 2, usages[0].CostPerHour, 1e-9)
+
+	// counted once even when the deletion is handled again
+	accrued, err = AccrueNodeCost(accrued, &expensive, now.Add(2*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, "20", accrued[0].Cost)
+
+	// the pool scales up again after the node is gone, the terminated node still counts
+	later := now.Add(time.Hour)
+	rescaled := newNode("rescaled", later)
+	usages, err = EvaluateBudget(budget, []tfv1.GPUNode{cheap, rescaled}, accrued, later)
+	require.NoError(t, err)
+	assert.InDelta(t, 20+2*7, usages[0].Spent, 1e-9)
+	assert.True(t, usages[0].Exceeded())
+	assert.InDelta(t, 20+2*7, usages[1].Spent, 1e-9)
+
+	// accrued costs of the previous day no longer count, the month keeps them
+	tomorrow := time.Date(2025, 5, 21, 1, 0, 0, 0, time.UTC)
+	usages, err = EvaluateBudget(budget, []tfv1.GPUNode{cheap}, accrued, tomorrow)
+	require.NoError(t, err)
+	assert.InDelta(t, 2, usages[0].Spent, 1e-9)
+	assert.InDelta(t, 20+2*19, usages[1].Spent, 1e-9)
+	accrued, err = AccrueNodeCost(accrued, &rescaled, tomorrow)
+	require.NoError(t, err)
+	assert.Equal(t, "2", accrued[0].Cost)
+	assert.Equal(t, []string{"rescaled"}, accrued[0].Nodes)
+	assert.Equal(t, []string{"expensive", "rescaled"}, accrued[1].Nodes)
+}
@@ -2,7 +2,9 @@ package component
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"slices"
 	"sort"
 
 	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
@@ -28,10 +30,14 @@ func (c *Client) GetName() string {
 
 func (c *Client) DetectConfigChange(pool *tfv1.GPUPool, status *tfv1.PoolComponentStatus) (bool, string, string) {
 	oldHash := status.ClientVersion
-	changed, newHash := utils.CompareAndGetObjectHash(oldHash, pool.Spec.ComponentConfig.Client)
+	changed, newHash := utils.CompareAndGetObjectHash(oldHash, ActiveClientConfig(pool))
 	return changed, newHash, oldHash
 }
 
+func (c *Client) GetConfig(pool *tfv1.GPUPool) any {
+	return pool.Spec.ComponentConfig.Client
+}
+
 func (c *Client) SetConfigHash(status *tfv1.PoolComponentStatus, hash string) {
 	status.ClientVersion = hash
 }
@@ -105,6 +111,36 @@ func (c *Client) PerformBatchUpdate(r client.Client, ctx context.Context, pool *
 	return true, nil
 }
 
+func (c *Client) CheckUpdatedHealth(r client.Client, ctx context.Context, pool *tfv1.GPUPool, configHash string) (int, int, error) {
+	podList := &corev1.PodList{}
+	if err := r.List(ctx, podList, client.MatchingLabels{
+		constants.TensorFusionEnabledLabelKey: constants.TrueStringValue,
+		constants.GpuPoolKey:                  pool.Name,
+		constants.LabelKeyPodTemplateHash:     configHash,
+	}); err != nil {
+		return 0, 0, fmt.Errorf("failed to list pods: %w", err)
+	}
+	// the client application is not updated, only containers injected into client pods are watched
+	notReady, failed := countUnhealthyContainers(podList.Items, injectedContainerNames(ActiveClientConfig(pool)))
+	return notReady, failed, nil
+}
+
+// injectedContainerNames returns names of containers the operator injects into client pods
+func injectedContainerNames(config *tfv1.ClientConfig) []string {
+	names := []string{constants.EmbeddedWorkerContainerName}
+	if config == nil || config.PatchToPod == nil {
+		return names
+	}
+	patch := &corev1.Pod{}
+	if err := json.Unmarshal(config.PatchToPod.Raw, patch); err != nil {
+		return names
+	}
+	for _, container := range slices.Concat(patch.Spec.InitContainers, patch.Spec.Containers) {
+		names = append(names, container.Name)
+	}
+	return names
+}
+
 type ClientPodsByCreationTimestamp []*corev1.Pod
 
 func (o ClientPodsByCreationTimestamp) Len() int      { return len(o) }
@@ -4,10 +4,16 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"slices"
 	"time"
 
 	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
 	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/NexusGPU/tensor-fusion/internal/utils"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -25,12 +31,20 @@ type Interface interface {
 	SetUpdateProgress(status *tfv1.PoolComponentStatus, progress int32)
 	GetResourcesInfo(r client.Client, ctx context.Context, pool *tfv1.GPUPool, hash string) (int, int, bool, error)
 	PerformBatchUpdate(r client.Client, ctx context.Context, pool *tfv1.GPUPool, delta int) (bool, error)
+	// GetConfig returns the spec config of the component
+	GetConfig(pool *tfv1.GPUPool) any
+	// CheckUpdatedHealth returns the number of updated pods not ready yet and failing to start
+	CheckUpdatedHealth(r client.Client, ctx context.Context, pool *tfv1.GPUPool, hash string) (int, int, error)
 }
 
 func ManageUpdate(r client.Client, ctx context.Context, pool *tfv1.GPUPool, component Interface) (*ctrl.Result, error) {
 	log := log.FromContext(ctx)
 
-	autoUpdate, batchInterval := getUpdatePolicy(pool)
+	if err := rollBack(r, ctx, pool, component); err != nil {
+		return nil, err
+	}
+
+	policy := getUpdatePolicy(pool)
 	newStatus := pool.Status.ComponentStatus.DeepCopy()
 
 	changed, configHash, oldHash := component.DetectConfigChange(pool, newStatus)
@@ -38,7 +52,10 @@ func ManageUpdate(r client.Client, ctx context.Context, pool *tfv1.GPUPool, comp
 		log.Info("component configuration changed", "component", component.GetName(), "old hash", oldHash, "new hash", configHash)
 		component.SetConfigHash(newStatus, configHash)
 		component.SetUpdateProgress(newStatus, 0)
-		if oldHash == "" || !autoUpdate {
+		if err := recordRevision(newStatus, component.GetName(), component.GetConfig(pool), configHash); err != nil {
+			return nil, err
+		}
+		if oldHash == "" || !policy.autoUpdate {
 			return nil, patchComponentStatus(r, ctx, pool, newStatus)
 		}
 		if pool.Annotations == nil {
@@ -47,19 +64,37 @@ func ManageUpdate(r client.Client, ctx context.Context, pool *tfv1.GPUPool, comp
 		patch := client.MergeFrom(pool.DeepCopy())
 		component.SetUpdateInProgressInfo(pool, configHash)
 		component.SetBatchUpdateLastTimeInfo(pool, "")
+		resetUpdateTimer(pool, component)
 		if err := r.Patch(ctx, pool, patch); err != nil {
 			return nil, fmt.Errorf("failed to patch pool: %w", err)
 		}
+		for _, format := range []string{constants.ConditionStatusTypeUpdateFailedFormat, constants.ConditionStatusTypeUpdatePausedFormat} {
+			if err := setUpdateCondition(r, ctx, pool, component, format, "", ""); err != nil {
+				return nil, err
+			}
+		}
 	} else {
-		if !autoUpdate || component.GetUpdateInProgressInfo(pool) != configHash {
+		if !policy.autoUpdate || component.GetUpdateInProgressInfo(pool) != configHash {
 			return nil, nil
 		}
+		if elapsed := updateElapsed(pool, component, time.Now()); policy.maxDuration > 0 && elapsed > policy.maxDuration {
+			log.Info("update exceeds max duration", "component", component.GetName(), "elapsed", elapsed, "maxDuration", policy.maxDuration)
+			patch := client.MergeFrom(pool.DeepCopy())
+			component.SetUpdateInProgressInfo(pool, "")
+			component.SetBatchUpdateLastTimeInfo(pool, "")
+			resetUpdateTimer(pool, component)
+			if err := r.Patch(ctx, pool, patch); err != nil {
+				return nil, fmt.Errorf("failed to patch pool: %w", err)
+			}
+			return nil, setUpdateCondition(r, ctx, pool, component, constants.ConditionStatusTypeUpdateFailedFormat, "MaxDurationExceeded",
+				fmt.Sprintf("update to %s has taken %s, exceeding max duration %s", configHash, elapsed.Round(time.Second), policy.maxDuration))
+		}
 		if timeInfo := component.GetBatchUpdateLastTimeInfo(pool); len(timeInfo) != 0 {
 			lastBatchUpdateTime, err := time.Parse(time.RFC3339, timeInfo)
 			if err != nil {
 				return nil, err
 			}
-			nextBatchUpdateTime := lastBatchUpdateTime.Add(batchInterval)
+			nextBatchUpdateTime := lastBatchUpdateTime.Add(policy.batchInterval)
 			if now := time.Now(); now.Before(nextBatchUpdateTime) {
 				log.Info("next batch update time not yet reached", "now", now, "nextBatchUpdateTime", nextBatchUpdateTime)
 				return &ctrl.Result{RequeueAfter: nextBatchUpdateTime.Sub(now)}, nil
@@ -85,36 +120,149 @@ func ManageUpdate(r client.Client, ctx context.Context, pool *tfv1.GPUPool, comp
 		"updateProgress", newUpdateProgress, "totalSize", totalSize, "updatedSize", updatedSize,
 		"batchPercentage", batchPercentage, "currentBatchIndex", currentBatchIndex, "delta", delta)
 
+	// batches only complete when updated pods are ready, and pause when updated pods fail to start
+	notReady, failed, err := component.CheckUpdatedHealth(r, ctx, pool, configHash)
+	if err != nil {
+		return nil, err
+	}
+	if failed >= policy.failureThreshold {
+		log.Info("update paused since updated pods fail to start", "component", component.GetName(), "hash", configHash, "failed", failed)
+		patch := client.MergeFrom(pool.DeepCopy())
+		pauseUpdateTimer(pool, component, time.Now())
+		if err := r.Patch(ctx, pool, patch); err != nil {
+			return nil, fmt.Errorf("failed to patch pool: %w", err)
+		}
+		if err := setUpdateCondition(r, ctx, pool, component, constants.ConditionStatusTypeUpdatePausedFormat, "UpdatedPodsFailing",
+			fmt.Sprintf("%d updated pods fail to start, fix the config or roll back with annotation %s", failed, constants.RollbackAnnotation)); err != nil {
+			return nil, err
+		}
+		return &ctrl.Result{RequeueAfter: constants.PendingRequeueDuration}, patchComponentStatus(r, ctx, pool, newStatus)
+	}
+	if err := setUpdateCondition(r, ctx, pool, component, constants.ConditionStatusTypeUpdatePausedFormat, "", ""); err != nil {
+		return nil, err
+	}
+	if delta == 0 && notReady > 0 {
+		log.Info("waiting for updated pods to be ready", "component", component.GetName(), "hash", configHash, "notReady", notReady)
+		return &ctrl.Result{RequeueAfter: constants.PendingRequeueDuration}, patchComponentStatus(r, ctx, pool, newStatus)
+	}
+
 	var ctrlResult *ctrl.Result
 	if delta == 0 {
 		patch := client.MergeFrom(pool.DeepCopy())
 		newUpdateProgress = min((currentBatchIndex+1)*batchPercentage, 100)
 		component.SetUpdateProgress(newStatus, newUpdateProgress)
+		pauseUpdateTimer(pool, component, time.Now())
 		if newUpdateProgress != 100 {
 			component.SetBatchUpdateLastTimeInfo(pool, time.Now().Format(time.RFC3339))
-			interval := max(batchInterval, constants.PendingRequeueDuration)
+			interval := max(policy.batchInterval, constants.PendingRequeueDuration)
 			ctrlResult = &ctrl.Result{RequeueAfter: interval}
 			log.Info("current batch update has completed", "progress", newUpdateProgress, "currentBatchIndex", currentBatchIndex, "nextUpdateTime", time.Now().Add(interval))
 		} else {
 			component.SetUpdateInProgressInfo(pool, "")
 			component.SetBatchUpdateLastTimeInfo(pool, "")
+			resetUpdateTimer(pool, component)
 			log.Info("all batch update has completed", "component", component.GetName(), "hash", configHash)
 		}
 		if err := r.Patch(ctx, pool, patch); err != nil {
 			return nil, fmt.Errorf("failed to patch pool: %w", err)
 		}
 	} else if delta > 0 {
-		recheck, err := component.PerformBatchUpdate(r, ctx, pool, int(delta))
+		now := time.Now()
+		inWindow, nextWindow, err := checkMaintenanceWindow(getMaintenanceWindow(pool), now)
 		if err != nil {
 			return nil, err
-		} else if recheck {
-			ctrlResult = &ctrl.Result{RequeueAfter: constants.PendingRequeueDuration}
+		}
+		// batches only start inside maintenance windows, the time waiting for the next window is not counted
+		patch := client.MergeFrom(pool.DeepCopy())
+		if !inWindow {
+			pauseUpdateTimer(pool, component, now)
+		} else {
+			startUpdateTimer(pool, component, now)
+		}
+		if err := r.Patch(ctx, pool, patch); err != nil {
+			return nil, fmt.Errorf("failed to patch pool: %w", err)
+		}
+
+		if !inWindow {
+			log.Info("waiting for the next maintenance window", "component", component.GetName(), "nextWindow", nextWindow)
+			ctrlResult = &ctrl.Result{RequeueAfter: nextWindow.Sub(now)}
+		} else {
+			recheck, err := component.PerformBatchUpdate(r, ctx, pool, int(delta))
+			if err != nil {
+				return nil, err
+			} else if recheck {
+				ctrlResult = &ctrl.Result{RequeueAfter: constants.PendingRequeueDuration}
+			}
 		}
 	}
 
 	return ctrlResult, patchComponentStatus(r, ctx, pool, newStatus)
 }
 
+// countUnhealthyPods returns the number of pods not ready and failing to start, pods being deleted or completed are skipped
+func countUnhealthyPods(pods []corev1.Pod) (int, int) {
+	var notReady, failed int
+	for i := range pods {
+		pod := &pods[i]
+		if !pod.DeletionTimestamp.IsZero() || pod.Status.Phase == corev1.PodSucceeded {
+			continue
+		}
+		if isPodFailing(pod) {
+			failed++
+		}
+		if pod.Status.Phase != corev1.PodRunning || !utils.IsPodConditionTrue(pod.Status.Conditions, corev1.PodReady) {
+			notReady++
+		}
+	}
+	return notReady, failed
+}
+
+// countUnhealthyContainers returns the number of pods whose given containers are not ready and failing to start,
+// other containers and the pod phase are not considered, pods being deleted or completed are skipped
+func countUnhealthyContainers(pods []corev1.Pod, containerNames []string) (int, int) {
+	var notReady, failed int
+	for i := range pods {
+		pod := &pods[i]
+		if !pod.DeletionTimestamp.IsZero() || pod.Status.Phase == corev1.PodSucceeded {
+			continue
+		}
+		expected := lo.CountBy(slices.Concat(pod.Spec.InitContainers, pod.Spec.Containers), func(container corev1.Container) bool {
+			return slices.Contains(containerNames, container.Name)
+		})
+		statuses := lo.Filter(slices.Concat(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses), func(status corev1.ContainerStatus, _ int) bool {
+			return slices.Contains(containerNames, status.Name)
+		})
+		if lo.SomeBy(statuses, isContainerFailing) {
+			failed++
+		}
+		if len(statuses) < expected || !lo.EveryBy(statuses, isContainerReady) {
+			notReady++
+		}
+	}
+	return notReady, failed
+}
+
+var failingContainerReasons = []string{
+	"CrashLoopBackOff", "ImagePullBackOff", "ErrImagePull", "InvalidImageName",
+	"CreateContainerConfigError", "CreateContainerError",
+}
+
+func isPodFailing(pod *corev1.Pod) bool {
+	if pod.Status.Phase == corev1.PodFailed {
+		return true
+	}
+	return lo.SomeBy(slices.Concat(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses), isContainerFailing)
+}
+
+func isContainerFailing(status corev1.ContainerStatus) bool {
+	return status.State.Waiting != nil && slices.Contains(failingContainerReasons, status.State.Waiting.Reason)
+}
+
+// isContainerReady returns whether the container is ready, init containers are ready once completed
+func isContainerReady(status corev1.ContainerStatus) bool {
+	return status.Ready || (status.State.Terminated != nil && status.State.Terminated.ExitCode == 0)
+}
+
 func patchComponentStatus(r client.Client, ctx context.Context, pool *tfv1.GPUPool, newStatus *tfv1.PoolComponentStatus) error {
 	patch := client.MergeFrom(pool.DeepCopy())
 	pool.Status.ComponentStatus = *newStatus
@@ -124,25 +272,116 @@ func patchComponentStatus(r client.Client, ctx context.Context, pool *tfv1.GPUPo
 	return nil
 }
 
-func getUpdatePolicy(pool *tfv1.GPUPool) (bool, time.Duration) {
-	autoUpdate := false
-	batchInterval := time.Duration(600) * time.Second
+type updatePolicy struct {
+	autoUpdate       bool
+	batchInterval    time.Duration
+	maxDuration      time.Duration
+	failureThreshold int
+}
+
+func getUpdatePolicy(pool *tfv1.GPUPool) updatePolicy {
+	policy := updatePolicy{
+		batchInterval:    time.Duration(600) * time.Second,
+		failureThreshold: 1,
+	}
 
 	if pool.Spec.NodeManagerConfig != nil {
 		updatePolicy := pool.Spec.NodeManagerConfig.NodePoolRollingUpdatePolicy
 		if updatePolicy != nil {
 			if updatePolicy.AutoUpdate != nil {
-				autoUpdate = *updatePolicy.AutoUpdate
+				policy.autoUpdate = *updatePolicy.AutoUpdate
 			}
 
 			duration, err := time.ParseDuration(updatePolicy.BatchInterval)
 			if err == nil {
-				batchInterval = duration
+				policy.batchInterval = duration
+			}
+
+			duration, err = time.ParseDuration(updatePolicy.MaxDuration)
+			if err == nil {
+				policy.maxDuration = duration
+			}
+
+			if updatePolicy.FailureThreshold > 0 {
+				policy.failureThreshold = int(updatePolicy.FailureThreshold)
 			}
 		}
 	}
 
-	return autoUpdate, batchInterval
+	return policy
+}
+
+func getMaintenanceWindow(pool *tfv1.GPUPool) tfv1.MaintenanceWindow {
+	if pool.Spec.NodeManagerConfig == nil || pool.Spec.NodeManagerConfig.NodePoolRollingUpdatePolicy == nil {
+		return tfv1.MaintenanceWindow{}
+	}
+	return pool.Spec.NodeManagerConfig.NodePoolRollingUpdatePolicy.MaintenanceWindow
+}
+
+// Time spent on an update is tracked in pool annotations as the duration of finished batches plus the start time
+// of the running batch, so that waits between batches and outside maintenance windows are not counted
+func updateStartTimeAnnotation(component Interface) string {
+	return fmt.Sprintf("%s/%s-update-start-time", constants.Domain, component.GetName())
+}
+
+func updateElapsedAnnotation(component Interface) string {
+	return fmt.Sprintf("%s/%s-update-elapsed", constants.Domain, component.GetName())
+}
+
+func updateElapsed(pool *tfv1.GPUPool, component Interface, now time.Time) time.Duration {
+	elapsed, _ := time.ParseDuration(pool.Annotations[updateElapsedAnnotation(component)])
+	if startTime, err := time.Parse(time.RFC3339, pool.Annotations[updateStartTimeAnnotation(component)]); err == nil {
+		elapsed += now.Sub(startTime)
+	}
+	return elapsed
+}
+
+func startUpdateTimer(pool *tfv1.GPUPool, component Interface, now time.Time) {
+	if pool.Annotations == nil {
+		pool.Annotations = map[string]string{}
+	}
+	if pool.Annotations[updateStartTimeAnnotation(component)] == "" {
+		pool.Annotations[updateStartTimeAnnotation(component)] = now.Format(time.RFC3339)
+	}
+}
+
+func pauseUpdateTimer(pool *tfv1.GPUPool, component Interface, now time.Time) {
+	if pool.Annotations[updateStartTimeAnnotation(component)] == "" {
+		return
+	}
+	pool.Annotations[updateElapsedAnnotation(component)] = updateElapsed(pool, component, now).String()
+	delete(pool.Annotations, updateStartTimeAnnotation(component))
+}
+
+func resetUpdateTimer(pool *tfv1.GPUPool, component Interface) {
+	delete(pool.Annotations, updateStartTimeAnnotation(component))
+	delete(pool.Annotations, updateElapsedAnnotation(component))
+}
+
+// setUpdateCondition sets the update condition of the component with the reason and message, or clears it when
+// the message is empty
+func setUpdateCondition(r client.Client, ctx context.Context, pool *tfv1.GPUPool, component Interface, typeFormat, reason, message string) error {
+	conditionType := fmt.Sprintf(typeFormat, lo.Capitalize(component.GetName()))
+	patch := client.MergeFrom(pool.DeepCopy())
+	var changed bool
+	if message == "" {
+		changed = meta.RemoveStatusCondition(&pool.Status.Conditions, conditionType)
+	} else {
+		changed = meta.SetStatusCondition(&pool.Status.Conditions, metav1.Condition{
+			Type:               conditionType,
+			Status:             metav1.ConditionTrue,
+			Reason:             reason,
+			Message:            message,
+			ObservedGeneration: pool.Generation,
+		})
+	}
+	if !changed {
+		return nil
+	}
+	if err := r.Status().Patch(ctx, pool, patch); err != nil {
+		return fmt.Errorf("failed to patch pool conditions: %w", err)
+	}
+	return nil
 }
 
 func calculateDesiredUpdatedDelta(total int, updatedSize int, batchPercentage int32, updateProgress int32) (int32, int32, int32) {
@@ -20,6 +20,10 @@ const (
 )
 
 type Hypervisor struct {
+	// MultiProcessQueuing is the effective queuing config of the pool's scheduling config template,
+	// nil when not enabled
+	MultiProcessQueuing *tfv1.MultiProcessQueuing
+
 	nodesToUpdate []*tfv1.GPUNode
 }
 
@@ -29,8 +33,12 @@ func (h *Hypervisor) GetName() string {
 
 func (h *Hypervisor) DetectConfigChange(pool *tfv1.GPUPool, status *tfv1.PoolComponentStatus) (bool, string, string) {
 	oldHash := status.HypervisorVersion
-	changed, newHash := utils.CompareAndGetObjectHash(oldHash, pool.Spec.ComponentConfig.Hypervisor)
-	return changed, newHash, oldHash
+	newHash := HypervisorConfigHash(ActiveHypervisorConfig(pool), h.MultiProcessQueuing)
+	return oldHash != newHash, newHash, oldHash
+}
+
+func (h *Hypervisor) GetConfig(pool *tfv1.GPUPool) any {
+	return pool.Spec.ComponentConfig.Hypervisor
 }
 
 func (h *Hypervisor) SetConfigHash(status *tfv1.PoolComponentStatus, hash string) {
@@ -122,6 +130,49 @@ func (h *Hypervisor) PerformBatchUpdate(r client.Client, ctx context.Context, po
 	return false, nil
 }
 
+func (h *Hypervisor) CheckUpdatedHealth(r client.Client, ctx context.Context, pool *tfv1.GPUPool, configHash string) (int, int, error) {
+	podList := &corev1.PodList{}
+	labels := client.MatchingLabels{
+		constants.LabelComponent:          constants.ComponentHypervisor,
+		constants.LabelKeyPodTemplateHash: configHash,
+	}
+	labels[fmt.Sprintf(constants.GPUNodePoolIdentifierLabelFormat, pool.Name)] = constants.TrueStringValue
+	if err := r.List(ctx, podList, client.InNamespace(utils.CurrentNamespace()), labels); err != nil {
+		return 0, 0, fmt.Errorf("failed to list hypervisor pods: %w", err)
+	}
+	notReady, failed := countUnhealthyPods(podList.Items)
+	return notReady, failed, nil
+}
+
+// HypervisorConfigHash returns the pod template hash of hypervisor pods,
+// queuing config is only hashed when enabled, so that enabling it triggers a rolling update
+func HypervisorConfigHash(config *tfv1.HypervisorConfig, queuing *tfv1.MultiProcessQueuing) string {
+	if queuing == nil {
+		return utils.GetObjectHash(config)
+	}
+	return utils.GetObjectHash(config, queuing)
+}
+
+// GetMultiProcessQueuing returns the multi-process queuing config of the pool's scheduling config template,
+// nil when the pool has no template or queuing is not enabled
+func GetMultiProcessQueuing(ctx context.Context, r client.Client, pool *tfv1.GPUPool) (*tfv1.MultiProcessQueuing, error) {
+	if pool.Spec.SchedulingConfigTemplate == nil {
+		return nil, nil
+	}
+	template := &tfv1.SchedulingConfigTemplate{}
+	if err := r.Get(ctx, client.ObjectKey{Name: *pool.Spec.SchedulingConfigTemplate}, template); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get scheduling config template: %w", err)
+	}
+	hypervisor := template.Spec.Hypervisor
+	if hypervisor == nil || hypervisor.MultiProcessQueuing.Enable == nil || !*hypervisor.MultiProcessQueuing.Enable {
+		return nil, nil
+	}
+	return hypervisor.MultiProcessQueuing.DeepCopy(), nil
+}
+
 type GPUNodeByCreationTimestamp []*tfv1.GPUNode
 
 func (o GPUNodeByCreationTimestamp) Len() int      { return len(o) }
@@ -0,0 +1,173 @@
+package component
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+)
+
+// cronSchedule is a crontab expression "minute hour day-of-month month day-of-week",
+// a time is inside the maintenance window when the minute it falls in matches the expression,
+// e.g. "* 2-4 * * 6" is 02:00 to 04:59 every Saturday
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// standard cron matches either day field when both are restricted
+	domRestricted, dowRestricted bool
+	location                     *time.Location
+}
+
+type cronField struct {
+	min, max int
+}
+
+var cronFields = []cronField{
+	{0, 59}, // minute
+	{0, 23}, // hour
+	{1, 31}, // day of month
+	{1, 12}, // month
+	{0, 7},  // day of week, 0 and 7 are Sunday
+}
+
+// parseCronSchedule parses a 5 fields crontab expression, an optional "CRON_TZ=<zone>" prefix sets the time zone,
+// UTC is used by default
+func parseCronSchedule(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	location := time.UTC
+	if len(fields) > 0 && strings.HasPrefix(fields[0], "CRON_TZ=") {
+		loc, err := time.LoadLocation(strings.TrimPrefix(fields[0], "CRON_TZ="))
+		if err != nil {
+			return nil, fmt.Errorf("invalid time zone in %q: %w", expr, err)
+		}
+		location = loc
+		fields = fields[1:]
+	}
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("invalid cron expression %q: expected %d fields, got %d", expr, len(cronFields), len(fields))
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, field := range fields {
+		var err error
+		if bits[i], err = parseCronField(field, cronFields[i]); err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+	}
+	// Sunday can be written as 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+	return &cronSchedule{
+		minute:        bits[0],
+		hour:          bits[1],
+		dom:           bits[2],
+		month:         bits[3],
+		dow:           bits[4],
+		domRestricted: fields[2] != "*",
+		dowRestricted: fields[4] != "*",
+		location:      location,
+	}, nil
+}
+
+// parseCronField parses comma separated values, ranges and steps, e.g. "*/15", "1-5", "0,30"
+func parseCronField(field string, bounds cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+		}
+
+		low, high := bounds.min, bounds.max
+		if rangePart != "*" {
+			lowPart, highPart, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = strconv.Atoi(lowPart); err != nil {
+				return 0, fmt.Errorf("invalid value in %q", part)
+			}
+			high = low
+			if isRange {
+				if high, err = strconv.Atoi(highPart); err != nil {
+					return 0, fmt.Errorf("invalid range in %q", part)
+				}
+			} else if hasStep {
+				high = bounds.max
+			}
+		}
+		if low < bounds.min || high > bounds.max || low > high {
+			return 0, fmt.Errorf("%q out of range [%d, %d]", part, bounds.min, bounds.max)
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<t.Day()) != 0
+	dowMatch := s.dow&(1<<int(t.Weekday())) != 0
+	if s.domRestricted && s.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+func (s *cronSchedule) matches(t time.Time) bool {
+	t = t.In(s.location)
+	return s.month&(1<<int(t.Month())) != 0 && s.dayMatches(t) &&
+		s.hour&(1<<t.Hour()) != 0 && s.minute&(1<<t.Minute()) != 0
+}
+
+// next returns the first matching minute at or after t, false when nothing matches within 5 years
+func (s *cronSchedule) next(t time.Time) (time.Time, bool) {
+	t = t.In(s.location)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, s.location)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.location)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.location)
+		case s.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.location)
+		case s.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// checkMaintenanceWindow returns whether now is inside one of the maintenance windows, and when the next
+// window starts when it's not. Updates are always allowed when no window is configured
+func checkMaintenanceWindow(window tfv1.MaintenanceWindow, now time.Time) (bool, time.Time, error) {
+	if len(window.Includes) == 0 {
+		return true, now, nil
+	}
+	var nextStart time.Time
+	for _, expr := range window.Includes {
+		schedule, err := parseCronSchedule(expr)
+		if err != nil {
+			return false, time.Time{}, err
+		}
+		if schedule.matches(now) {
+			return true, now, nil
+		}
+		if start, ok := schedule.next(now); ok && (nextStart.IsZero() || start.Before(nextStart)) {
+			nextStart = start
+		}
+	}
+	if nextStart.IsZero() {
+		return false, time.Time{}, fmt.Errorf("maintenance windows %v never start", window.Includes)
+	}
+	return false, nextStart, nil
+}
@@ -0,0 +1,68 @@
+package component
+
+import (
+	"testing"
+	"time"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCronSchedule(t *testing.T) {
+	// 2025-06-07 is a Saturday
+	saturday := time.Date(2025, 6, 7, 3, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		expr    string
+		t       time.Time
+		matches bool
+		wantErr bool
+	}{
+		{name: "every minute", expr: "* * * * *", t: saturday, matches: true},
+		{name: "hour range", expr: "* 2-4 * * 6", t: saturday, matches: true},
+		{name: "outside hour range", expr: "* 2-4 * * 6", t: saturday.Add(2 * time.Hour), matches: false},
+		{name: "sunday as 7", expr: "* * * * 7", t: saturday.AddDate(0, 0, 1), matches: true},
+		{name: "step", expr: "*/15 * * * *", t: saturday, matches: true},
+		{name: "list", expr: "0,45 * * * *", t: saturday, matches: false},
+		{name: "either day field", expr: "* * 1 * 6", t: saturday, matches: true},
+		{name: "time zone", expr: "CRON_TZ=Asia/Shanghai * 11 * * *", t: saturday, matches: true},
+		{name: "too few fields", expr: "* * * *", wantErr: true},
+		{name: "out of range", expr: "60 * * * *", wantErr: true},
+		{name: "invalid step", expr: "*/0 * * * *", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := parseCronSchedule(tt.expr)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.matches, schedule.matches(tt.t))
+		})
+	}
+}
+
+func TestCheckMaintenanceWindow(t *testing.T) {
+	now := time.Date(2025, 6, 7, 5, 30, 20, 0, time.UTC)
+
+	inWindow, _, err := checkMaintenanceWindow(tfv1.MaintenanceWindow{}, now)
+	require.NoError(t, err)
+	assert.True(t, inWindow, "updates are allowed without windows")
+
+	inWindow, next, err := checkMaintenanceWindow(tfv1.MaintenanceWindow{Includes: []string{"* 2-4 * * 6", "30 1 * * *"}}, now)
+	require.NoError(t, err)
+	assert.False(t, inWindow)
+	assert.Equal(t, time.Date(2025, 6, 8, 1, 30, 0, 0, time.UTC), next)
+
+	inWindow, next, err = checkMaintenanceWindow(tfv1.MaintenanceWindow{Includes: []string{"0 0 29 2 *"}}, now)
+	require.NoError(t, err)
+	assert.False(t, inWindow)
+	assert.Equal(t, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC), next)
+
+	_, _, err = checkMaintenanceWindow(tfv1.MaintenanceWindow{Includes: []string{"0 0 31 2 *"}}, now)
+	assert.Error(t, err, "windows that never start are rejected")
+}
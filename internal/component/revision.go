@@ -0,0 +1,124 @@
+package component
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/NexusGPU/tensor-fusion/internal/utils"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// ActiveHypervisorConfig returns the hypervisor config to roll out, the current revision when the spec config is rolled back
+func ActiveHypervisorConfig(pool *tfv1.GPUPool) *tfv1.HypervisorConfig {
+	return activeConfig(pool, constants.ComponentHypervisor, pool.Spec.ComponentConfig.Hypervisor)
+}
+
+// ActiveWorkerConfig returns the worker config to roll out, the current revision when the spec config is rolled back
+func ActiveWorkerConfig(pool *tfv1.GPUPool) *tfv1.WorkerConfig {
+	return activeConfig(pool, constants.ComponentWorker, pool.Spec.ComponentConfig.Worker)
+}
+
+// ActiveClientConfig returns the client config to roll out, the current revision when the spec config is rolled back
+func ActiveClientConfig(pool *tfv1.GPUPool) *tfv1.ClientConfig {
+	return activeConfig(pool, constants.ComponentClient, pool.Spec.ComponentConfig.Client)
+}
+
+func activeConfig[T any](pool *tfv1.GPUPool, name string, spec *T) *T {
+	revisions, ok := pool.Status.ComponentStatus.Revisions[name]
+	if !ok || !isRolledBack(revisions, spec) {
+		return spec
+	}
+	config := new(T)
+	if err := json.Unmarshal(revisions.Current.Config.Raw, config); err != nil {
+		return spec
+	}
+	return config
+}
+
+func isRolledBack(revisions tfv1.ComponentRevisions, spec any) bool {
+	return revisions.RolledBackFrom != "" && revisions.RolledBackFrom == utils.GetObjectHash(spec)
+}
+
+// recordRevision makes the config of the new version the current revision, the old current revision is kept
+// as the previous one to roll back to
+func recordRevision(status *tfv1.PoolComponentStatus, name string, spec any, version string) error {
+	revisions := status.Revisions[name]
+	if revisions.RolledBackFrom != "" && !isRolledBack(revisions, spec) {
+		// spec config changed after rollback
+		revisions.RolledBackFrom = ""
+	}
+	if revisions.Current.Version != version {
+		config := revisions.Current.Config
+		if !isRolledBack(revisions, spec) {
+			raw, err := json.Marshal(spec)
+			if err != nil {
+				return fmt.Errorf("failed to marshal %s config: %w", name, err)
+			}
+			config = runtime.RawExtension{Raw: raw}
+		}
+		if revisions.Current.Version != "" {
+			revisions.Previous = revisions.Current.DeepCopy()
+		}
+		revisions.Current = tfv1.ComponentRevision{Version: version, Config: config}
+	}
+	if status.Revisions == nil {
+		status.Revisions = map[string]tfv1.ComponentRevisions{}
+	}
+	status.Revisions[name] = revisions
+	return nil
+}
+
+// rollBack swaps the current and previous revisions of the component when requested by the rollback annotation,
+// so that the previous revision is rolled out in place of the spec config, rolling back again undoes the rollback
+func rollBack(r client.Client, ctx context.Context, pool *tfv1.GPUPool, component Interface) error {
+	requested := strings.Split(pool.Annotations[constants.RollbackAnnotation], ",")
+	if !slices.Contains(requested, component.GetName()) {
+		return nil
+	}
+	log := log.FromContext(ctx)
+
+	revisions, ok := pool.Status.ComponentStatus.Revisions[component.GetName()]
+	if ok && revisions.Previous != nil {
+		spec := component.GetConfig(pool)
+		if isRolledBack(revisions, spec) {
+			revisions.RolledBackFrom = ""
+		} else {
+			revisions.RolledBackFrom = utils.GetObjectHash(spec)
+		}
+		revisions.Current, *revisions.Previous = *revisions.Previous, revisions.Current
+
+		statusPatch := client.MergeFrom(pool.DeepCopy())
+		pool.Status.ComponentStatus.Revisions[component.GetName()] = revisions
+		if err := r.Status().Patch(ctx, pool, statusPatch); err != nil {
+			return fmt.Errorf("failed to patch pool status: %w", err)
+		}
+		for _, format := range []string{constants.ConditionStatusTypeUpdateFailedFormat, constants.ConditionStatusTypeUpdatePausedFormat} {
+			if err := setUpdateCondition(r, ctx, pool, component, format, "", ""); err != nil {
+				return err
+			}
+		}
+		log.Info("rolling back component", "component", component.GetName(), "version", revisions.Current.Version)
+	} else {
+		log.Info("no previous revision to roll back to", "component", component.GetName())
+	}
+
+	patch := client.MergeFrom(pool.DeepCopy())
+	remaining := lo.Without(requested, component.GetName())
+	if len(remaining) == 0 {
+		delete(pool.Annotations, constants.RollbackAnnotation)
+	} else {
+		pool.Annotations[constants.RollbackAnnotation] = strings.Join(remaining, ",")
+	}
+	if err := r.Patch(ctx, pool, patch); err != nil {
+		return fmt.Errorf("failed to patch pool: %w", err)
+	}
+	return nil
+}
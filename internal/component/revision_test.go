@@ -0,0 +1,124 @@
+package component
+
+import (
+	"testing"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/config"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/NexusGPU/tensor-fusion/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestRecordRevision(t *testing.T) {
+	v1 := &tfv1.ClientConfig{OperatorEndpoint: "http://operator-v1"}
+	v2 := &tfv1.ClientConfig{OperatorEndpoint: "http://operator-v2"}
+	pool := &tfv1.GPUPool{Spec: tfv1.GPUPoolSpec{ComponentConfig: &tfv1.ComponentConfig{Client: v1}}}
+	status := &pool.Status.ComponentStatus
+
+	require.NoError(t, recordRevision(status, constants.ComponentClient, v1, utils.GetObjectHash(v1)))
+	assert.Nil(t, status.Revisions[constants.ComponentClient].Previous)
+
+	require.NoError(t, recordRevision(status, constants.ComponentClient, v2, utils.GetObjectHash(v2)))
+	revisions := status.Revisions[constants.ComponentClient]
+	assert.Equal(t, utils.GetObjectHash(v2), revisions.Current.Version)
+	require.NotNil(t, revisions.Previous)
+	assert.Equal(t, utils.GetObjectHash(v1), revisions.Previous.Version)
+
+	// roll back to v1 while the spec config stays v2
+	pool.Spec.ComponentConfig.Client = v2
+	revisions.RolledBackFrom = utils.GetObjectHash(v2)
+	revisions.Current, *revisions.Previous = *revisions.Previous, revisions.Current
+	status.Revisions[constants.ComponentClient] = revisions
+	assert.Equal(t, v1, ActiveClientConfig(pool))
+
+	require.NoError(t, recordRevision(status, constants.ComponentClient, v2, utils.GetObjectHash(v1)))
+	assert.Equal(t, utils.GetObjectHash(v1), status.Revisions[constants.ComponentClient].Current.Version,
+		"rolled back revision is kept")
+
+	// changing the spec config ends the rollback
+	v3 := &tfv1.ClientConfig{OperatorEndpoint: "http://operator-v3"}
+	pool.Spec.ComponentConfig.Client = v3
+	assert.Equal(t, v3, ActiveClientConfig(pool))
+	require.NoError(t, recordRevision(status, constants.ComponentClient, v3, utils.GetObjectHash(v3)))
+	revisions = status.Revisions[constants.ComponentClient]
+	assert.Empty(t, revisions.RolledBackFrom)
+	assert.Equal(t, utils.GetObjectHash(v3), revisions.Current.Version)
+	assert.Equal(t, utils.GetObjectHash(v1), revisions.Previous.Version)
+}
+
+func TestActiveConfigInvalidRevision(t *testing.T) {
+	spec := &tfv1.WorkerConfig{}
+	pool := &tfv1.GPUPool{Spec: tfv1.GPUPoolSpec{ComponentConfig: &tfv1.ComponentConfig{Worker: spec}}}
+	pool.Status.ComponentStatus.Revisions = map[string]tfv1.ComponentRevisions{
+		constants.ComponentWorker: {
+			Current:        tfv1.ComponentRevision{Version: "old", Config: runtime.RawExtension{Raw: []byte("invalid")}},
+			RolledBackFrom: utils.GetObjectHash(spec),
+		},
+	}
+	assert.Same(t, spec, ActiveWorkerConfig(pool))
+}
+
+func TestCountUnhealthyPods(t *testing.T) {
+	ready := corev1.Pod{Status: corev1.PodStatus{
+		Phase:      corev1.PodRunning,
+		Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+	}}
+	pending := corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodPending}}
+	crashing := corev1.Pod{Status: corev1.PodStatus{
+		Phase: corev1.PodRunning,
+		ContainerStatuses: []corev1.ContainerStatus{{
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+		}},
+	}}
+	pullFailed := corev1.Pod{Status: corev1.PodStatus{
+		Phase: corev1.PodPending,
+		InitContainerStatuses: []corev1.ContainerStatus{{
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+		}},
+	}}
+
+	completed := corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodSucceeded}}
+
+	notReady, failed := countUnhealthyPods([]corev1.Pod{ready, pending, crashing, pullFailed, completed})
+	assert.Equal(t, 3, notReady)
+	assert.Equal(t, 2, failed)
+}
+
+func TestCountUnhealthyContainers(t *testing.T) {
+	injected := injectedContainerNames(config.MockGPUPoolSpec.ComponentConfig.Client)
+	assert.ElementsMatch(t, []string{"inject-lib", constants.EmbeddedWorkerContainerName}, injected)
+
+	spec := corev1.PodSpec{
+		InitContainers: []corev1.Container{{Name: "inject-lib"}},
+		Containers:     []corev1.Container{{Name: "app"}},
+	}
+	// the client application is not ready, injected containers are fine
+	appNotReady := corev1.Pod{Spec: spec, Status: corev1.PodStatus{
+		Phase: corev1.PodFailed,
+		InitContainerStatuses: []corev1.ContainerStatus{{
+			Name:  "inject-lib",
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}},
+		}},
+		ContainerStatuses: []corev1.ContainerStatus{{
+			Name:  "app",
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+		}},
+	}}
+	notStarted := corev1.Pod{Spec: spec, Status: corev1.PodStatus{Phase: corev1.PodPending}}
+	injectFailed := corev1.Pod{Spec: spec, Status: corev1.PodStatus{
+		Phase: corev1.PodPending,
+		InitContainerStatuses: []corev1.ContainerStatus{{
+			Name:  "inject-lib",
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+		}},
+	}}
+	completed := corev1.Pod{Spec: spec, Status: corev1.PodStatus{Phase: corev1.PodSucceeded}}
+
+	notReady, failed := countUnhealthyContainers([]corev1.Pod{appNotReady, notStarted, injectFailed, completed}, injected)
+	assert.Equal(t, 2, notReady)
+	assert.Equal(t, 1, failed)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/NexusGPU/tensor-fusion/internal/constants"
 	"github.com/NexusGPU/tensor-fusion/internal/utils"
 	"github.com/NexusGPU/tensor-fusion/internal/worker"
+	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
@@ -28,10 +29,14 @@ func (w *Worker) GetName() string {
 
 func (w *Worker) DetectConfigChange(pool *tfv1.GPUPool, status *tfv1.PoolComponentStatus) (bool, string, string) {
 	oldHash := status.WorkerVersion
-	changed, newHash := utils.CompareAndGetObjectHash(oldHash, pool.Spec.ComponentConfig.Worker)
+	changed, newHash := utils.CompareAndGetObjectHash(oldHash, ActiveWorkerConfig(pool))
 	return changed, newHash, oldHash
 }
 
+func (w *Worker) GetConfig(pool *tfv1.GPUPool) any {
+	return pool.Spec.ComponentConfig.Worker
+}
+
 func (w *Worker) SetConfigHash(status *tfv1.PoolComponentStatus, hash string) {
 	status.WorkerVersion = hash
 }
@@ -75,7 +80,7 @@ func (w *Worker) GetResourcesInfo(r client.Client, ctx context.Context, pool *tf
 
 	total := len(workloadList.Items)
 
-	workerGenerator := &worker.WorkerGenerator{WorkerConfig: pool.Spec.ComponentConfig.Worker}
+	workerGenerator := &worker.WorkerGenerator{WorkerConfig: ActiveWorkerConfig(pool)}
 	for _, workload := range workloadList.Items {
 		if !workload.DeletionTimestamp.IsZero() {
 			total--
@@ -115,6 +120,40 @@ func (w *Worker) PerformBatchUpdate(r client.Client, ctx context.Context, pool *
 	return true, nil
 }
 
+// CheckUpdatedHealth checks worker pods of workloads updated to the config, the config hash of workers
+// differs per workload so the pod template hash of each workload is checked
+func (w *Worker) CheckUpdatedHealth(r client.Client, ctx context.Context, pool *tfv1.GPUPool, configHash string) (int, int, error) {
+	workloadList := &tfv1.TensorFusionWorkloadList{}
+	if err := r.List(ctx, workloadList, client.MatchingLabels(map[string]string{
+		constants.GpuPoolKey: pool.Name,
+	})); err != nil {
+		return 0, 0, fmt.Errorf("failed to list workloads : %w", err)
+	}
+
+	var notReady, failed int
+	workerGenerator := &worker.WorkerGenerator{WorkerConfig: ActiveWorkerConfig(pool)}
+	for _, workload := range workloadList.Items {
+		podTemplateHash, err := workerGenerator.PodTemplateHash(workload.Spec)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to get pod template hash: %w", err)
+		}
+		if workload.Status.PodTemplateHash != podTemplateHash {
+			continue
+		}
+		podList := &corev1.PodList{}
+		if err := r.List(ctx, podList, client.InNamespace(workload.Namespace), client.MatchingLabels{
+			constants.WorkloadKey:             workload.Name,
+			constants.LabelKeyPodTemplateHash: podTemplateHash,
+		}); err != nil {
+			return 0, 0, fmt.Errorf("failed to list worker pods: %w", err)
+		}
+		podsNotReady, podsFailed := countUnhealthyPods(podList.Items)
+		notReady += podsNotReady
+		failed += podsFailed
+	}
+	return notReady, failed, nil
+}
+
 type TensorFusionWorkloadByCreationTimestamp []*tfv1.TensorFusionWorkload
 
 func (o TensorFusionWorkloadByCreationTimestamp) Len() int      { return len(o) }
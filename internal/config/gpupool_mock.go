@@ -36,7 +36,6 @@ var MockGPUPoolSpec = &tfv1.GPUPoolSpec{
 			AutoUpdate:        ptr.To(false),
 			BatchPercentage:   25,
 			BatchInterval:     "10m",
-			MaxDuration:       "10m",
 			MaintenanceWindow: tfv1.MaintenanceWindow{},
 		},
 	},
@@ -26,6 +26,8 @@ const (
 	ComponentHypervisor    = "hypervisor"
 	ComponentNodeDiscovery = "node-discovery"
 	ComponentOperator      = "operator"
+	// set on WorkerMigrations started by the rebalancer
+	ComponentReBalancer = "rebalancer"
 
 	GPUNodePoolIdentifierLabelPrefix = Domain + "/pool-"
 	GPUNodePoolIdentifierLabelFormat = Domain + "/pool-%s"
@@ -51,6 +53,23 @@ const (
 	GenWorkloadAnnotation          = Domain + "/generate-workload"
 	IsLocalGPUAnnotation           = Domain + "/is-local-gpu"
 	StandaloneWorkerModeAnnotation = Domain + "/no-standalone-worker-mode"
+	CrossNodeGPUsAnnotation        = Domain + "/cross-node-gpus"
+	GangSchedulingAnnotation       = Domain + "/gang-scheduling"
+
+	// Set on client pods running the vGPU worker as a sidecar in embedded worker mode,
+	// GPUs allocated for the pod are released by finalizer
+	EmbeddedWorkerLabel = Domain + "/embedded-worker"
+	// Client pods with an embedded worker are held by the scheduling gate until GPUs are allocated for them
+	EmbeddedWorkerSchedulingGate = Domain + "/embedded-worker-allocation"
+	// Name of the sidecar container and the port it listens on inside the client pod network
+	EmbeddedWorkerContainerName = "tensorfusion-worker"
+	EmbeddedWorkerPort          = 39900
+	// Prefix of annotations holding env of embedded workers depending on the GPUs allocated for them
+	GPUEnvAnnotationPrefix = Domain + "/env-"
+
+	// Workers spread over multiple nodes for the same replica share the replica label
+	WorkerReplicaLabel   = Domain + "/replica"
+	WorkerRankAnnotation = Domain + "/worker-rank"
 
 	GenHostPortLabel        = Domain + "/host-port"
 	GenHostPortLabelValue   = "auto"
@@ -61,6 +80,45 @@ const (
 	AutoScaleRequestsAnnotation = Domain + "/auto-requests"
 	AutoScaleReplicasAnnotation = Domain + "/auto-replicas"
 
+	// Last time the autoscaler changed replicas of the workload, for scale up and down cool down
+	LastScaleUpTimeAnnotation   = Domain + "/last-scale-up-time"
+	LastScaleDownTimeAnnotation = Domain + "/last-scale-down-time"
+	// Last time the autoscaler applied recommended requests to the workload
+	LastRequestsUpdateTimeAnnotation = Domain + "/last-requests-update-time"
+
+	// Set on idle worker pods by auto freeze, the value is where the GPU context is frozen to,
+	// GPUs of frozen workers are released until they are woken up
+	WorkerFrozenAnnotation = Domain + "/frozen"
+	FrozenToMem            = "mem"
+	FrozenToDisk           = "disk"
+	// Written back on frozen worker pods by the worker once its GPU context is frozen, the value is where it is
+	// frozen to, GPUs are kept until then since the worker may still hold VRAM
+	WorkerFrozenConfirmedAnnotation = Domain + "/frozen-confirmed"
+	// Set on frozen worker pods once their GPUs are released, so that they are released only once
+	WorkerFrozenGPUReleasedAnnotation = Domain + "/frozen-gpu-released"
+	// Set on frozen worker pods when a connection needs them, GPUs are allocated again before waking up
+	WorkerWakeUpAnnotation = Domain + "/wake-up"
+
+	// Last time a worker of the workload was moved off hot GPUs by the rebalancer, for rebalance cool down
+	LastReBalanceTimeAnnotation = Domain + "/last-rebalance-time"
+
+	// Set on the new worker started by a WorkerMigration, the value is the name of the worker being migrated
+	MigrationSourceAnnotation = Domain + "/migration-source"
+
+	// Set on GPUPool to roll back component updates to the previous revision, comma separated component names,
+	// e.g. "hypervisor,worker", removed once the rollback starts
+	RollbackAnnotation = Domain + "/rollback"
+
+	// Set on node discovery jobs and hypervisor pods, the GPUNode gpuCardIndices they were created with,
+	// both are recreated when the indices change
+	GPUCardIndicesAnnotation = Domain + "/gpu-card-indices"
+	// Set on GPUNodes drained to be terminated since the pool's budget is exceeded, the node is deleted once drained
+	BudgetTerminateAnnotation = Domain + "/budget-terminate"
+	// number of times the drain migration of a worker has been started, used to back off retries of failed migrations
+	DrainMigrationAttemptsAnnotation = Domain + "/drain-migration-attempts"
+	// Set on node discovery jobs, the interval node discovery runs with, the job is recreated when it changes
+	NodeDiscoveryIntervalAnnotation = Domain + "/node-discovery-interval"
+
 	// GPUModelAnnotation specifies the required GPU model (e.g., "A100", "H100")
 	GPUModelAnnotation = Domain + "/gpu-model"
 
@@ -73,21 +131,33 @@ const (
 	PendingRequeueDuration = time.Second * 3
 	StatusCheckInterval    = time.Second * 6
 
+	// GPUNodes whose hypervisor has sent heartbeats become Unknown when no heartbeat is received for the duration
+	HypervisorHeartbeatTimeout = time.Minute * 2
+	// Node discovery rediscovers GPUs and reports their health in the interval unless set by the operator flag
+	DefaultNodeDiscoveryInterval = time.Minute
+
 	GetConnectionURLEnv    = "TENSOR_FUSION_OPERATOR_GET_CONNECTION_URL"
+	HeartbeatURLEnv        = "TENSOR_FUSION_OPERATOR_HEARTBEAT_URL"
 	ConnectionNameEnv      = "TENSOR_FUSION_CONNECTION_NAME"
 	ConnectionNamespaceEnv = "TENSOR_FUSION_CONNECTION_NAMESPACE"
 
+	NvidiaVisibleDevicesEnv    = "NVIDIA_VISIBLE_DEVICES"
 	WorkerPortEnv              = "TENSOR_FUSION_WORKER_PORT"
 	WorkerCudaUpLimitTflopsEnv = "TENSOR_FUSION_CUDA_UP_LIMIT_TFLOPS"
 	WorkerCudaUpLimitEnv       = "TENSOR_FUSION_CUDA_UP_LIMIT"
 	WorkerCudaMemLimitEnv      = "TENSOR_FUSION_CUDA_MEM_LIMIT"
 	WorkloadNameEnv            = "TENSOR_FUSION_WORKLOAD_NAME"
+	WorkloadNamespaceEnv       = "TENSOR_FUSION_WORKLOAD_NAMESPACE"
 	PoolNameEnv                = "TENSOR_FUSION_POOL_NAME"
 	PodNameEnv                 = "POD_NAME"
 	GPUNodeNameEnv             = "GPU_NODE_NAME"
+	GPUCardIndicesEnv          = "TENSOR_FUSION_GPU_CARD_INDICES"
 	NamespaceEnv               = "OPERATOR_NAMESPACE"
 	NamespaceDefaultVal        = "tensor-fusion-sys"
 
+	MultiProcessQueuingIntervalEnv   = "TENSOR_FUSION_QUEUING_INTERVAL"
+	MultiProcessQueuingTimeSlicesEnv = "TENSOR_FUSION_QUEUING_TIME_SLICES"
+
 	KubernetesHostNameLabel      = "kubernetes.io/hostname"
 	GiBToBytes                   = 1024 * 1024 * 1024
 	HypervisorServiceAccountName = "tensor-fusion-hypervisor-sa"
@@ -110,6 +180,23 @@ const (
 	ConditionStatusTypeGPUPool               = "GPUPoolReady"
 	ConditionStatusTypeTimeSeriesDatabase    = "TimeSeriesDatabaseReady"
 	ConditionStatusTypeCloudVendorConnection = "CloudVendorConnectionReady"
+
+	// set on GPUPool when the rolling update of a component exceeds its max duration, e.g. HypervisorUpdateFailed
+	ConditionStatusTypeUpdateFailedFormat = "%sUpdateFailed"
+	// set on GPUPool when updated pods of a component fail to start and the update is paused, e.g. HypervisorUpdatePaused
+	ConditionStatusTypeUpdatePausedFormat = "%sUpdatePaused"
+
+	// set on GPU by node discovery, True means the GPU is unhealthy and excluded from allocation
+	ConditionStatusTypeGPUECCError    = "ECCError"
+	ConditionStatusTypeGPUXIDError    = "XIDError"
+	ConditionStatusTypeGPUOverheating = "Overheating"
+	ConditionStatusTypeGPUDeviceLost  = "DeviceLost"
+	// set on GPU by node discovery when clocks are slowed down, e.g. by thermal or power limits, informational only
+	ConditionStatusTypeGPUThrottled = "Throttled"
+	// set on GPUNode when draining is blocked by workers labeled do-not-disrupt
+	ConditionStatusTypeDrainBlocked = "DrainBlocked"
+	// set on GPU from hypervisor heartbeats, True when the hypervisor is serving the GPU, GPUs are not allocated when False
+	ConditionStatusTypeGPUHypervisorReady = "HypervisorReady"
 )
 
 const (
@@ -120,6 +207,9 @@ const (
 	PhaseMigrating  = "Migrating"
 	PhaseDestroying = "Destroying"
 
+	PhaseUnschedulable = "Unschedulable"
+	PhaseUnhealthy     = "Unhealthy"
+
 	PhaseRunning   = "Running"
 	PhaseSucceeded = "Succeeded"
 	PhaseFailed    = "Failed"
@@ -148,6 +238,10 @@ const (
 
 const TFDataPath = "/tmp/tensor-fusion/data"
 const DataVolumeName = "tf-data"
+
+// Annotations of worker pods are mounted here, TFlops limits auto set at runtime are read from the annotations file
+const TFPodInfoPath = "/etc/tensor-fusion/podinfo"
+const PodInfoVolumeName = "tf-podinfo"
 const TensorFusionPoolManualCompaction = Domain + "/manual-compaction"
 const AlertJobName = "tensor-fusion"
 
@@ -20,17 +20,23 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
 	cloudprovider "github.com/NexusGPU/tensor-fusion/internal/cloudprovider"
 	"github.com/NexusGPU/tensor-fusion/internal/cloudprovider/types"
+	"github.com/NexusGPU/tensor-fusion/internal/component"
 	"github.com/NexusGPU/tensor-fusion/internal/constants"
 	"github.com/NexusGPU/tensor-fusion/internal/gpuallocator"
 	"github.com/NexusGPU/tensor-fusion/internal/metrics"
 	"github.com/NexusGPU/tensor-fusion/internal/utils"
+	"github.com/samber/lo"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -48,12 +54,17 @@ type GPUNodeReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
+	// interval of node discovery to rediscover GPUs and report their health, default DefaultNodeDiscoveryInterval
+	NodeDiscoveryInterval time.Duration
 }
 
 // +kubebuilder:rbac:groups=tensor-fusion.ai,resources=gpunodes,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=tensor-fusion.ai,resources=gpunodes/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=tensor-fusion.ai,resources=gpunodes/finalizers,verbs=update
 // +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;patch;delete
+// +kubebuilder:rbac:groups=tensor-fusion.ai,resources=workermigrations,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
 
 // Reconcile GPU nodes
 func (r *GPUNodeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -83,6 +94,11 @@ func (r *GPUNodeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		// remove from metrics map
 		metrics.RemoveNodeMetrics(node.Name)
 
+		// costs of the node keep counting against the pool budget after it is gone
+		if err := recordDeletedNodeCost(ctx, r.Client, node); err != nil {
+			return false, fmt.Errorf("record cost of deleted node: %w", err)
+		}
+
 		switch node.Spec.ManageMode {
 		case tfv1.GPUNodeManageModeAutoSelect:
 			// Do nothing, but if it's managed by Karpenter, should come up with some way to tell Karpenter to terminate the GPU node
@@ -187,9 +203,18 @@ func (r *GPUNodeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	if err != nil {
 		return ctrl.Result{}, err
 	}
-	if checkAgain {
+
+	draining, err := r.reconcileDrain(ctx, node)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if checkAgain || draining {
 		return ctrl.Result{RequeueAfter: constants.StatusCheckInterval}, nil
 	}
+	// check again when heartbeats would go stale
+	if remaining, ok := heartbeatRemaining(node, time.Now()); ok && remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
 	return ctrl.Result{}, nil
 }
 
@@ -218,6 +243,15 @@ func (r *GPUNodeReconciler) checkStatusAndUpdateVirtualCapacity(ctx context.Cont
 
 		return true, nil
 	} else {
+		// resumed heartbeats trigger reconcile by updating node status
+		if remaining, ok := heartbeatRemaining(node, time.Now()); ok && remaining <= 0 {
+			return false, r.markHeartbeatLost(ctx, node)
+		}
+
+		if err := r.syncMultiProcessQueuingStatus(ctx, node, pod); err != nil {
+			return true, err
+		}
+
 		gpuModels, err := gpuallocator.RefreshGPUNodeCapacity(ctx, r.Client, node, poolObj)
 		if err != nil {
 			return true, err
@@ -226,7 +260,7 @@ func (r *GPUNodeReconciler) checkStatusAndUpdateVirtualCapacity(ctx context.Cont
 		// update metrics to get historical allocation line chart and trending
 		metrics.SetNodeMetrics(node, poolObj, gpuModels)
 
-		err = r.syncStatusToGPUDevices(ctx, node, tfv1.TensorFusionGPUPhaseRunning)
+		err = r.syncStatusToGPUDevices(ctx, node, schedulableGPUPhase(node))
 		if err != nil {
 			return true, err
 		}
@@ -234,6 +268,38 @@ func (r *GPUNodeReconciler) checkStatusAndUpdateVirtualCapacity(ctx context.Cont
 	}
 }
 
+// syncMultiProcessQueuingStatus reports the queuing config rendered into the running hypervisor pod
+func (r *GPUNodeReconciler) syncMultiProcessQueuingStatus(ctx context.Context, node *tfv1.GPUNode, pod *corev1.Pod) error {
+	queuing := multiProcessQueuingFromPod(pod)
+	if equality.Semantic.DeepEqual(node.Status.HypervisorStatus.MultiProcessQueuing, queuing) {
+		return nil
+	}
+	patch := client.MergeFrom(node.DeepCopy())
+	node.Status.HypervisorStatus.MultiProcessQueuing = queuing
+	if err := r.Status().Patch(ctx, node, patch); err != nil {
+		return fmt.Errorf("failed to update hypervisor queuing status: %w", err)
+	}
+	return nil
+}
+
+func multiProcessQueuingFromPod(pod *corev1.Pod) *tfv1.MultiProcessQueuing {
+	if len(pod.Spec.Containers) == 0 {
+		return nil
+	}
+	env := lo.SliceToMap(pod.Spec.Containers[0].Env, func(env corev1.EnvVar) (string, string) {
+		return env.Name, env.Value
+	})
+	interval, ok := env[constants.MultiProcessQueuingIntervalEnv]
+	if !ok {
+		return nil
+	}
+	queuing := &tfv1.MultiProcessQueuing{Enable: ptr.To(true), Interval: interval}
+	if timeSlices := env[constants.MultiProcessQueuingTimeSlicesEnv]; timeSlices != "" {
+		queuing.QueueLevelTimeSlices = strings.Split(timeSlices, ",")
+	}
+	return queuing
+}
+
 func (r *GPUNodeReconciler) syncStatusToGPUDevices(ctx context.Context, node *tfv1.GPUNode, state tfv1.TensorFusionGPUPhase) error {
 	gpuList, err := r.fetchAllOwnedGPUDevices(ctx, node)
 	if err != nil {
@@ -241,9 +307,17 @@ func (r *GPUNodeReconciler) syncStatusToGPUDevices(ctx context.Context, node *tf
 	}
 
 	for _, gpu := range gpuList {
-		if gpu.Status.Phase != state {
+		phase := state
+		// health is reported by node discovery, unhealthy GPUs stay out of allocation until they recover,
+		// so do GPUs the hypervisor reports it is not serving
+		if phase == tfv1.TensorFusionGPUPhaseRunning && gpu.IsUnhealthy() {
+			phase = tfv1.TensorFusionGPUPhaseUnhealthy
+		} else if phase == tfv1.TensorFusionGPUPhaseRunning && gpu.IsHypervisorNotReady() {
+			phase = tfv1.TensorFusionGPUPhaseUnknown
+		}
+		if gpu.Status.Phase != phase {
 			patch := client.MergeFrom(gpu.DeepCopy())
-			gpu.Status.Phase = state
+			gpu.Status.Phase = phase
 			if err := r.Status().Patch(ctx, &gpu, patch); err != nil {
 				return fmt.Errorf("failed to patch GPU device status: %w", err)
 			}
@@ -312,7 +386,11 @@ func (r *GPUNodeReconciler) reconcileNodeDiscoveryJob(
 	})
 	tmpl.Spec.EnableServiceLinks = ptr.To(false)
 
+	// node discovery keeps running to report GPU health, restarted in place when it fails
+	interval := lo.Ternary(r.NodeDiscoveryInterval > 0, r.NodeDiscoveryInterval, constants.DefaultNodeDiscoveryInterval)
+	tmpl.Spec.RestartPolicy = corev1.RestartPolicyOnFailure
 	if len(tmpl.Spec.Containers) > 0 {
+		tmpl.Spec.Containers[0].Args = append(tmpl.Spec.Containers[0].Args, "--discovery-interval="+interval.String())
 		if len(tmpl.Spec.Containers[0].Env) == 0 {
 			tmpl.Spec.Containers[0].Env = []corev1.EnvVar{}
 		}
@@ -323,15 +401,22 @@ func (r *GPUNodeReconciler) reconcileNodeDiscoveryJob(
 	}
 
 	// create node-discovery job
+	annotations := make(map[string]string, len(tmpl.Annotations)+1)
+	for k, v := range tmpl.Annotations {
+		annotations[k] = v
+	}
+	annotations[constants.GPUCardIndicesAnnotation] = gpuCardIndices(gpunode)
+	annotations[constants.NodeDiscoveryIntervalAnnotation] = interval.String()
 	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        getDiscoveryJobName(gpunode.Name),
 			Namespace:   utils.CurrentNamespace(),
 			Labels:      tmpl.Labels,
-			Annotations: tmpl.Annotations,
+			Annotations: annotations,
 		},
 		Spec: batchv1.JobSpec{
 			TTLSecondsAfterFinished: ptr.To[int32](3600 * 10),
+			BackoffLimit:            ptr.To[int32](math.MaxInt32),
 			Template:                tmpl,
 		},
 	}
@@ -346,6 +431,14 @@ func (r *GPUNodeReconciler) reconcileNodeDiscoveryJob(
 		} else {
 			return fmt.Errorf("create node discovery job %w", err)
 		}
+	} else if job.DeletionTimestamp.IsZero() && (job.Annotations[constants.GPUCardIndicesAnnotation] != gpuCardIndices(gpunode) ||
+		job.Annotations[constants.NodeDiscoveryIntervalAnnotation] != interval.String() || isJobFinished(job)) {
+		// GPU card indices or the interval changed, or node discovery stopped, e.g. one-shot jobs created before
+		// upgrade, run it again to onboard or clean up GPUs, the job is recreated once deleted
+		log.Info("rerun node discovery job", "indices", gpuCardIndices(gpunode), "interval", interval)
+		if err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("delete outdated node discovery job %w", err)
+		}
 	}
 
 	return nil
@@ -358,6 +451,12 @@ func (r *GPUNodeReconciler) reconcileHypervisorPod(ctx context.Context, node *tf
 		return "", fmt.Errorf("missing hypervisor config")
 	}
 
+	queuing, err := component.GetMultiProcessQueuing(ctx, r.Client, pool)
+	if err != nil {
+		return "", err
+	}
+	configHash := component.HypervisorConfigHash(component.ActiveHypervisorConfig(pool), queuing)
+
 	key := client.ObjectKey{
 		Namespace: utils.CurrentNamespace(),
 		Name:      fmt.Sprintf("hypervisor-%s", node.Name),
@@ -369,7 +468,9 @@ func (r *GPUNodeReconciler) reconcileHypervisorPod(ctx context.Context, node *tf
 			return "", fmt.Errorf("failed to get current hypervisor pod: %w", err)
 		}
 	} else {
-		if node.Status.Phase == tfv1.TensorFusionGPUNodePhaseRunning {
+		// the hypervisor only manages GPUs it was started with, restart it even when running
+		indicesChanged := currentPod.Annotations[constants.GPUCardIndicesAnnotation] != gpuCardIndices(node)
+		if node.Status.Phase == tfv1.TensorFusionGPUNodePhaseRunning && !indicesChanged {
 			return key.Name, nil
 		}
 
@@ -378,8 +479,8 @@ func (r *GPUNodeReconciler) reconcileHypervisorPod(ctx context.Context, node *tf
 			return key.Name, nil
 		}
 
-		if utils.IsPodTerminated(currentPod) ||
-			currentPod.Labels[constants.LabelKeyPodTemplateHash] != utils.GetObjectHash(pool.Spec.ComponentConfig.Hypervisor) {
+		if utils.IsPodTerminated(currentPod) || indicesChanged ||
+			currentPod.Labels[constants.LabelKeyPodTemplateHash] != configHash {
 			if err := r.Delete(ctx, currentPod); err != nil {
 				return "", fmt.Errorf("failed to delete old hypervisor pod: %w", err)
 			}
@@ -390,7 +491,7 @@ func (r *GPUNodeReconciler) reconcileHypervisorPod(ctx context.Context, node *tf
 	}
 
 	// no existing pod or config changed, so create new one
-	if err := r.createHypervisorPod(ctx, key, node, pool); err != nil {
+	if err := r.createHypervisorPod(ctx, key, node, pool, queuing, configHash); err != nil {
 		if errors.IsAlreadyExists(err) {
 			return "", nil
 		} else {
@@ -401,11 +502,18 @@ func (r *GPUNodeReconciler) reconcileHypervisorPod(ctx context.Context, node *tf
 	return key.Name, nil
 }
 
-func (r *GPUNodeReconciler) createHypervisorPod(ctx context.Context, key client.ObjectKey, node *tfv1.GPUNode, pool *tfv1.GPUPool) error {
+func (r *GPUNodeReconciler) createHypervisorPod(
+	ctx context.Context,
+	key client.ObjectKey,
+	node *tfv1.GPUNode,
+	pool *tfv1.GPUPool,
+	queuing *tfv1.MultiProcessQueuing,
+	configHash string,
+) error {
 	log := log.FromContext(ctx)
 
 	podTmpl := &corev1.PodTemplate{}
-	err := json.Unmarshal(pool.Spec.ComponentConfig.Hypervisor.PodTemplate.Raw, podTmpl)
+	err := json.Unmarshal(component.ActiveHypervisorConfig(pool).PodTemplate.Raw, podTmpl)
 	if err != nil {
 		return fmt.Errorf("failed to unmarshal pod template: %w", err)
 	}
@@ -445,6 +553,27 @@ func (r *GPUNodeReconciler) createHypervisorPod(ctx context.Context, key client.
 		Name:  constants.GPUNodeNameEnv,
 		Value: node.Name,
 	})
+	if queuing != nil {
+		spec.Containers[0].Env = append(spec.Containers[0].Env, corev1.EnvVar{
+			Name:  constants.MultiProcessQueuingIntervalEnv,
+			Value: queuing.Interval,
+		}, corev1.EnvVar{
+			Name:  constants.MultiProcessQueuingTimeSlicesEnv,
+			Value: strings.Join(queuing.QueueLevelTimeSlices, ","),
+		})
+	}
+	if clientConfig := component.ActiveClientConfig(pool); clientConfig != nil && clientConfig.OperatorEndpoint != "" {
+		spec.Containers[0].Env = append(spec.Containers[0].Env, corev1.EnvVar{
+			Name:  constants.HeartbeatURLEnv,
+			Value: fmt.Sprintf("%s/api/heartbeat", clientConfig.OperatorEndpoint),
+		})
+	}
+	if indices := gpuCardIndices(node); indices != "" {
+		spec.Containers[0].Env = append(spec.Containers[0].Env, corev1.EnvVar{
+			Name:  constants.GPUCardIndicesEnv,
+			Value: indices,
+		})
+	}
 	spec.ServiceAccountName = constants.HypervisorServiceAccountName
 	newPod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -456,11 +585,18 @@ func (r *GPUNodeReconciler) createHypervisorPod(ctx context.Context, key client.
 					mergedLabels[k] = v
 				}
 				mergedLabels[fmt.Sprintf(constants.GPUNodePoolIdentifierLabelFormat, pool.Name)] = "true"
-				mergedLabels[constants.LabelKeyPodTemplateHash] = utils.GetObjectHash(pool.Spec.ComponentConfig.Hypervisor)
+				mergedLabels[constants.LabelKeyPodTemplateHash] = configHash
 				mergedLabels[constants.LabelComponent] = constants.ComponentHypervisor
 				return mergedLabels
 			}(),
-			Annotations: podTmpl.Template.Annotations,
+			Annotations: func() map[string]string {
+				mergedAnnotations := make(map[string]string)
+				for k, v := range podTmpl.Template.Annotations {
+					mergedAnnotations[k] = v
+				}
+				mergedAnnotations[constants.GPUCardIndicesAnnotation] = gpuCardIndices(node)
+				return mergedAnnotations
+			}(),
 		},
 		Spec: spec,
 	}
@@ -599,3 +735,17 @@ func (r *GPUNodeReconciler) SetupWithManager(mgr ctrl.Manager) error {
 func getDiscoveryJobName(gpunodeName string) string {
 	return fmt.Sprintf("node-discovery-%s", gpunodeName)
 }
+
+func isJobFinished(job *batchv1.Job) bool {
+	return lo.ContainsBy(job.Status.Conditions, func(condition batchv1.JobCondition) bool {
+		return (condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed) &&
+			condition.Status == corev1.ConditionTrue
+	})
+}
+
+// gpuCardIndices returns comma separated GPU card indices managed on the node, empty means all GPUs
+func gpuCardIndices(node *tfv1.GPUNode) string {
+	return strings.Join(lo.Map(node.Spec.GPUCardIndices, func(index int, _ int) string {
+		return strconv.Itoa(index)
+	}), ",")
+}
@@ -18,15 +18,20 @@ package controller
 
 import (
 	"fmt"
+	"time"
 
 	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
 	"github.com/NexusGPU/tensor-fusion/internal/utils"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var _ = Describe("GPUNode Controller", func() {
@@ -52,6 +57,8 @@ var _ = Describe("GPUNode Controller", func() {
 				}, job)).Should(Succeed())
 
 				g.Expect(job.Spec.TTLSecondsAfterFinished).Should(Equal(ptr.To[int32](3600 * 10)))
+				g.Expect(job.Spec.Template.Spec.RestartPolicy).Should(Equal(corev1.RestartPolicyOnFailure))
+				g.Expect(job.Spec.Template.Spec.Containers[0].Args).Should(ContainElement("--discovery-interval=1m0s"))
 			}).Should(Succeed())
 
 			By("checking that the hypervisor pod is created")
@@ -88,4 +95,136 @@ var _ = Describe("GPUNode Controller", func() {
 
 		})
 	})
+
+	Context("When cordoning and draining gpunodes", func() {
+		var tfEnv *TensorFusionEnv
+		key := client.ObjectKey{Name: "mock", Namespace: "default"}
+
+		BeforeEach(func() {
+			tfEnv = NewTensorFusionEnvBuilder().
+				AddPoolWithNodeCount(2).
+				SetGpuCountPerNode(1).
+				Build()
+		})
+		AfterEach(func() {
+			cleanupWorkload(key)
+			tfEnv.Cleanup()
+		})
+
+		It("should exclude GPUs of cordoned nodes from allocation", func() {
+			updateGPUNodeSpec(tfEnv.GetGPUNode(0, 0), func(node *tfv1.GPUNode) {
+				node.Spec.Unschedulable = true
+			})
+			Eventually(func(g Gomega) {
+				g.Expect(tfEnv.GetNodeGpuList(0, 0).Items[0].Status.Phase).Should(Equal(tfv1.TensorFusionGPUPhaseUnschedulable))
+			}).Should(Succeed())
+
+			updateGPUNodeSpec(tfEnv.GetGPUNode(0, 0), func(node *tfv1.GPUNode) {
+				node.Spec.Unschedulable = false
+			})
+			Eventually(func(g Gomega) {
+				g.Expect(tfEnv.GetNodeGpuList(0, 0).Items[0].Status.Phase).Should(Equal(tfv1.TensorFusionGPUPhaseRunning))
+			}).Should(Succeed())
+		})
+
+		It("should migrate workers off a drained node", func() {
+			workload := createTensorFusionWorkload(tfEnv.GetGPUPool(0).Name, key, 1)
+			checkWorkerPodCount(workload)
+			source := getWorkerPods(key)[0]
+			gpu := &tfv1.GPU{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: source.Annotations[constants.GpuKey]}, gpu)).Should(Succeed())
+			gpuNode := &tfv1.GPUNode{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: gpu.Labels[constants.LabelKeyOwner]}, gpuNode)).Should(Succeed())
+
+			updateGPUNodeSpec(gpuNode, func(node *tfv1.GPUNode) {
+				node.Spec.Drain = true
+			})
+
+			By("migrating the worker to the other node")
+			migrationKey := client.ObjectKey{Name: getDrainMigrationName(source.Name), Namespace: key.Namespace}
+			Eventually(func(g Gomega) {
+				migration := &tfv1.WorkerMigration{}
+				g.Expect(k8sClient.Get(ctx, migrationKey, migration)).Should(Succeed())
+				g.Expect(migration.Status.TargetWorkerName).ShouldNot(BeEmpty())
+				migration.Spec.DrainTimeout = "0s"
+				g.Expect(k8sClient.Update(ctx, migration)).Should(Succeed())
+
+				node := &tfv1.GPUNode{}
+				g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(gpuNode), node)).Should(Succeed())
+				g.Expect(node.Status.Drain).ShouldNot(BeNil())
+				g.Expect(node.Status.Drain.MigratingWorkers).Should(Equal(int32(1)))
+				g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(gpu), gpu)).Should(Succeed())
+				g.Expect(gpu.Status.Phase).Should(Equal(tfv1.TensorFusionGPUPhaseMigrating))
+			}).Should(Succeed())
+
+			for _, pod := range getWorkerPods(key) {
+				if pod.Name != source.Name {
+					pod.Status.Phase = corev1.PodRunning
+					pod.Status.Conditions = append(pod.Status.Conditions, corev1.PodCondition{Type: corev1.PodReady, Status: corev1.ConditionTrue})
+					Expect(k8sClient.Status().Update(ctx, &pod)).Should(Succeed())
+				}
+			}
+
+			By("reporting the node as drained once the worker is moved off")
+			Eventually(func(g Gomega) {
+				node := &tfv1.GPUNode{}
+				g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(gpuNode), node)).Should(Succeed())
+				g.Expect(node.Status.Drain).ShouldNot(BeNil())
+				g.Expect(node.Status.Drain.Phase).Should(Equal(tfv1.GPUNodeDrained))
+				g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(gpu), gpu)).Should(Succeed())
+				g.Expect(gpu.Status.Phase).Should(Equal(tfv1.TensorFusionGPUPhaseUnschedulable))
+			}).WithTimeout(30 * time.Second).Should(Succeed())
+
+			By("garbage collecting the finished migration")
+			Eventually(func(g Gomega) {
+				err := k8sClient.Get(ctx, migrationKey, &tfv1.WorkerMigration{})
+				g.Expect(errors.IsNotFound(err)).Should(BeTrue())
+			}).Should(Succeed())
+		})
+
+		It("should report workers labeled do-not-disrupt blocking the drain", func() {
+			workload := createTensorFusionWorkload(tfEnv.GetGPUPool(0).Name, key, 1)
+			checkWorkerPodCount(workload)
+			worker := getWorkerPods(key)[0]
+			worker.Labels[constants.SchedulingDoNotDisruptLabel] = constants.TrueStringValue
+			Expect(k8sClient.Update(ctx, &worker)).Should(Succeed())
+			gpu := &tfv1.GPU{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: worker.Annotations[constants.GpuKey]}, gpu)).Should(Succeed())
+			gpuNode := &tfv1.GPUNode{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: gpu.Labels[constants.LabelKeyOwner]}, gpuNode)).Should(Succeed())
+
+			updateGPUNodeSpec(gpuNode, func(node *tfv1.GPUNode) {
+				node.Spec.Drain = true
+			})
+			Eventually(func(g Gomega) {
+				node := &tfv1.GPUNode{}
+				g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(gpuNode), node)).Should(Succeed())
+				g.Expect(node.Status.Drain).ShouldNot(BeNil())
+				g.Expect(node.Status.Drain.Phase).Should(Equal(tfv1.GPUNodeDraining))
+				g.Expect(node.Status.Drain.BlockedWorkers).Should(Equal(int32(1)))
+				g.Expect(meta.IsStatusConditionTrue(node.Status.Conditions, constants.ConditionStatusTypeDrainBlocked)).Should(BeTrue())
+			}).Should(Succeed())
+
+			By("clearing the blocked state once the drain is cancelled")
+			updateGPUNodeSpec(gpuNode, func(node *tfv1.GPUNode) {
+				node.Spec.Drain = false
+			})
+			Eventually(func(g Gomega) {
+				node := &tfv1.GPUNode{}
+				g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(gpuNode), node)).Should(Succeed())
+				g.Expect(node.Status.Drain).Should(BeNil())
+				g.Expect(meta.FindStatusCondition(node.Status.Conditions, constants.ConditionStatusTypeDrainBlocked)).Should(BeNil())
+			}).Should(Succeed())
+		})
+	})
 })
+
+func updateGPUNodeSpec(gpuNode *tfv1.GPUNode, update func(node *tfv1.GPUNode)) {
+	GinkgoHelper()
+	Eventually(func(g Gomega) {
+		node := &tfv1.GPUNode{}
+		g.Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(gpuNode), node)).Should(Succeed())
+		update(node)
+		g.Expect(k8sClient.Update(ctx, node)).Should(Succeed())
+	}).Should(Succeed())
+}
@@ -0,0 +1,302 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	defaultDrainGracePeriod = 5 * time.Minute
+
+	drainMigrationBaseBackoff = 10 * time.Second
+	drainMigrationMaxBackoff  = 2 * time.Minute
+)
+
+// schedulableGPUPhase returns the phase of GPUs on a node whose hypervisor is running,
+// GPUs of cordoned or draining nodes keep serving existing workers but are excluded from allocation
+func schedulableGPUPhase(node *tfv1.GPUNode) tfv1.TensorFusionGPUPhase {
+	switch {
+	case node.Spec.Drain && (node.Status.Drain == nil || node.Status.Drain.Phase == tfv1.GPUNodeDraining):
+		return tfv1.TensorFusionGPUPhaseMigrating
+	case node.Spec.Drain || node.Spec.Unschedulable:
+		return tfv1.TensorFusionGPUPhaseUnschedulable
+	default:
+		return tfv1.TensorFusionGPUPhaseRunning
+	}
+}
+
+// reconcileDrain migrates workers off a node being drained, workers still on the node after the grace period
+// are evicted so that their workloads start them elsewhere. Workers labeled do-not-disrupt are left untouched,
+// they block the node from being drained and are reported by the DrainBlocked condition
+func (r *GPUNodeReconciler) reconcileDrain(ctx context.Context, node *tfv1.GPUNode) (draining bool, err error) {
+	if !node.Spec.Drain {
+		if node.Status.Drain == nil {
+			return false, nil
+		}
+		// migrations still in progress are reverted, the workers stay on the node
+		if err := r.deleteDrainMigrations(ctx, node); err != nil {
+			return false, err
+		}
+		patch := client.MergeFrom(node.DeepCopy())
+		node.Status.Drain = nil
+		meta.RemoveStatusCondition(&node.Status.Conditions, constants.ConditionStatusTypeDrainBlocked)
+		if err := r.Status().Patch(ctx, node, patch); err != nil {
+			return false, fmt.Errorf("failed to clear drain status: %w", err)
+		}
+		return false, nil
+	}
+
+	// GPUs are switched out of allocation first, migrations are started in the next round
+	// so that new workers are not placed on the node being drained
+	if node.Status.Drain == nil {
+		patch := client.MergeFrom(node.DeepCopy())
+		node.Status.Drain = &tfv1.GPUNodeDrainStatus{
+			Phase:     tfv1.GPUNodeDraining,
+			StartTime: metav1.Now(),
+		}
+		if err := r.Status().Patch(ctx, node, patch); err != nil {
+			return true, fmt.Errorf("failed to init drain status: %w", err)
+		}
+		r.Recorder.Eventf(node, corev1.EventTypeNormal, "Draining", "Start draining workers off node %s", node.Name)
+		return true, nil
+	}
+	if node.Status.Drain.Phase == tfv1.GPUNodeDrained {
+		return false, nil
+	}
+
+	workers, err := r.listNodeWorkers(ctx, node)
+	if err != nil {
+		return true, err
+	}
+	migrations, err := r.listDrainMigrations(ctx, node)
+	if err != nil {
+		return true, err
+	}
+
+	gracePeriod, err := parseMigrationTimeout(node.Spec.DrainGracePeriod, defaultDrainGracePeriod)
+	if err != nil {
+		r.Recorder.Eventf(node, corev1.EventTypeWarning, "InvalidDrainGracePeriod", "%v, use default %s", err, defaultDrainGracePeriod)
+		gracePeriod = defaultDrainGracePeriod
+	}
+	evict := time.Since(node.Status.Drain.StartTime.Time) >= gracePeriod
+
+	status := node.Status.Drain.DeepCopy()
+	status.RemainingWorkers = int32(len(workers))
+	status.MigratingWorkers = 0
+	blocked := []string{}
+	for i := range workers {
+		pod := &workers[i]
+		if !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if pod.Labels[constants.SchedulingDoNotDisruptLabel] == constants.TrueStringValue {
+			blocked = append(blocked, pod.Namespace+"/"+pod.Name)
+			continue
+		}
+		if evict {
+			if err := r.Delete(ctx, pod); err != nil && !errors.IsNotFound(err) {
+				return true, fmt.Errorf("failed to evict worker %s/%s: %w", pod.Namespace, pod.Name, err)
+			}
+			status.EvictedWorkers++
+			r.Recorder.Eventf(node, corev1.EventTypeNormal, "EvictedWorker", "Evicted worker %s/%s not migrated within %s", pod.Namespace, pod.Name, gracePeriod)
+			continue
+		}
+		if unmovableReason(pod) != "" {
+			// frozen workers and workers of cross-node replicas wait for eviction
+			continue
+		}
+		migration, _ := lo.Find(migrations, func(migration tfv1.WorkerMigration) bool {
+			return migration.Name == getDrainMigrationName(pod.Name)
+		})
+		migrating, err := r.migrateWorker(ctx, node, pod, migration)
+		if err != nil {
+			return true, err
+		}
+		if migrating {
+			status.MigratingWorkers++
+		}
+	}
+	status.BlockedWorkers = int32(len(blocked))
+
+	if len(workers) == 0 {
+		now := metav1.Now()
+		status.Phase = tfv1.GPUNodeDrained
+		status.CompletionTime = &now
+		if err := r.deleteDrainMigrations(ctx, node); err != nil {
+			return true, err
+		}
+	} else if err := r.deleteSucceededMigrations(ctx, migrations); err != nil {
+		return true, err
+	}
+
+	conditions := slices.Clone(node.Status.Conditions)
+	blockedChanged := false
+	if len(blocked) > 0 {
+		blockedChanged = meta.SetStatusCondition(&conditions, metav1.Condition{
+			Type:    constants.ConditionStatusTypeDrainBlocked,
+			Status:  metav1.ConditionTrue,
+			Reason:  "DoNotDisrupt",
+			Message: fmt.Sprintf("Workers labeled do-not-disrupt: %s", strings.Join(blocked, ", ")),
+		})
+	} else {
+		meta.RemoveStatusCondition(&conditions, constants.ConditionStatusTypeDrainBlocked)
+	}
+	if !equality.Semantic.DeepEqual(node.Status.Drain, status) || !equality.Semantic.DeepEqual(node.Status.Conditions, conditions) {
+		patch := client.MergeFrom(node.DeepCopy())
+		node.Status.Drain = status
+		node.Status.Conditions = conditions
+		if err := r.Status().Patch(ctx, node, patch); err != nil {
+			return true, fmt.Errorf("failed to update drain status: %w", err)
+		}
+	}
+	if blockedChanged {
+		r.Recorder.Eventf(node, corev1.EventTypeWarning, "DrainBlocked", "Workers labeled do-not-disrupt: %s", strings.Join(blocked, ", "))
+	}
+	if status.Phase == tfv1.GPUNodeDrained {
+		r.Recorder.Eventf(node, corev1.EventTypeNormal, "Drained", "All workers are moved off node %s", node.Name)
+		return false, nil
+	}
+	log.FromContext(ctx).Info("draining GPU node", "node", node.Name, "remaining", status.RemainingWorkers,
+		"migrating", status.MigratingWorkers, "evicted", status.EvictedWorkers, "blocked", status.BlockedWorkers)
+	return true, nil
+}
+
+// listNodeWorkers lists worker pods running on GPUs of the node
+func (r *GPUNodeReconciler) listNodeWorkers(ctx context.Context, node *tfv1.GPUNode) ([]corev1.Pod, error) {
+	gpus, err := r.fetchAllOwnedGPUDevices(ctx, node)
+	if err != nil {
+		return nil, err
+	}
+	gpuNames := lo.Map(gpus, func(gpu tfv1.GPU, _ int) string {
+		return gpu.Name
+	})
+
+	podList := &corev1.PodList{}
+	if err := r.List(ctx, podList, client.MatchingLabels{constants.LabelComponent: constants.ComponentWorker}); err != nil {
+		return nil, fmt.Errorf("failed to list workers: %w", err)
+	}
+	return lo.Filter(podList.Items, func(pod corev1.Pod, _ int) bool {
+		return slices.ContainsFunc(strings.Split(pod.Annotations[constants.GpuKey], ","), func(name string) bool {
+			return slices.Contains(gpuNames, name)
+		})
+	}), nil
+}
+
+// migrateWorker starts a migration moving the worker to other GPUs, failed migrations are deleted and started
+// again with exponential backoff, the attempts are counted on the worker. Returns whether the migration is in progress
+func (r *GPUNodeReconciler) migrateWorker(
+	ctx context.Context, node *tfv1.GPUNode, pod *corev1.Pod, migration tfv1.WorkerMigration,
+) (bool, error) {
+	if migration.Name != "" {
+		if !migration.DeletionTimestamp.IsZero() || migration.Status.Phase == tfv1.WorkerMigrationSucceeded {
+			return false, nil
+		}
+		if migration.Status.Phase != tfv1.WorkerMigrationFailed {
+			return true, nil
+		}
+		attempts, _ := strconv.Atoi(pod.Annotations[constants.DrainMigrationAttemptsAnnotation])
+		backoff := drainMigrationBackoff(attempts)
+		if migration.Status.CompletionTime != nil && time.Since(migration.Status.CompletionTime.Time) < backoff {
+			return false, nil
+		}
+
+		patch := client.MergeFrom(pod.DeepCopy())
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations[constants.DrainMigrationAttemptsAnnotation] = strconv.Itoa(attempts + 1)
+		if err := r.Patch(ctx, pod, patch); err != nil {
+			return false, fmt.Errorf("failed to count migration attempts of worker %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+		// the migration is started again once the deleted one is gone
+		if err := r.Delete(ctx, &migration); err != nil && !errors.IsNotFound(err) {
+			return false, fmt.Errorf("failed to delete failed migration %s/%s: %w", migration.Namespace, migration.Name, err)
+		}
+		r.Recorder.Eventf(node, corev1.EventTypeNormal, "RetryMigration", "Retry migrating worker %s/%s after %s, last attempt failed: %s",
+			pod.Namespace, pod.Name, backoff, migration.Status.Message)
+		return false, nil
+	}
+
+	migration = tfv1.WorkerMigration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getDrainMigrationName(pod.Name),
+			Namespace: pod.Namespace,
+			Labels: map[string]string{
+				constants.LabelKeyOwner: node.Name,
+			},
+		},
+		Spec: tfv1.WorkerMigrationSpec{
+			WorkerName: pod.Name,
+		},
+	}
+	if err := r.Create(ctx, &migration); err != nil && !errors.IsAlreadyExists(err) {
+		return false, fmt.Errorf("failed to create migration for worker %s/%s: %w", pod.Namespace, pod.Name, err)
+	}
+	return true, nil
+}
+
+func getDrainMigrationName(workerName string) string {
+	return fmt.Sprintf("drain-%s", workerName)
+}
+
+func drainMigrationBackoff(attempts int) time.Duration {
+	// the base backoff shifted by 4 already exceeds the max backoff
+	if attempts >= 4 {
+		return drainMigrationMaxBackoff
+	}
+	return min(drainMigrationBaseBackoff<<attempts, drainMigrationMaxBackoff)
+}
+
+// listDrainMigrations lists migrations started by draining the node
+func (r *GPUNodeReconciler) listDrainMigrations(ctx context.Context, node *tfv1.GPUNode) ([]tfv1.WorkerMigration, error) {
+	migrationList := &tfv1.WorkerMigrationList{}
+	if err := r.List(ctx, migrationList, client.MatchingLabels{constants.LabelKeyOwner: node.Name}); err != nil {
+		return nil, fmt.Errorf("failed to list drain migrations: %w", err)
+	}
+	return lo.Filter(migrationList.Items, func(migration tfv1.WorkerMigration, _ int) bool {
+		return strings.HasPrefix(migration.Name, getDrainMigrationName(""))
+	}), nil
+}
+
+// deleteSucceededMigrations garbage collects migrations whose workers are moved off the node
+func (r *GPUNodeReconciler) deleteSucceededMigrations(ctx context.Context, migrations []tfv1.WorkerMigration) error {
+	for i := range migrations {
+		migration := &migrations[i]
+		if migration.Status.Phase != tfv1.WorkerMigrationSucceeded || !migration.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if err := r.Delete(ctx, migration); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete migration %s/%s: %w", migration.Namespace, migration.Name, err)
+		}
+	}
+	return nil
+}
+
+// deleteDrainMigrations deletes all migrations started by draining the node once the drain ends
+func (r *GPUNodeReconciler) deleteDrainMigrations(ctx context.Context, node *tfv1.GPUNode) error {
+	migrations, err := r.listDrainMigrations(ctx, node)
+	if err != nil {
+		return err
+	}
+	for i := range migrations {
+		if err := r.Delete(ctx, &migrations[i]); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete migration %s/%s: %w", migrations[i].Namespace, migrations[i].Name, err)
+		}
+	}
+	return nil
+}
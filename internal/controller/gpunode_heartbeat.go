@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// heartbeatRemaining returns how long until hypervisor heartbeats of the node go stale, negative when already stale,
+// ok is false when the hypervisor never sent heartbeats, e.g. versions without heartbeat support
+func heartbeatRemaining(node *tfv1.GPUNode, now time.Time) (remaining time.Duration, ok bool) {
+	lastHeartbeat := node.Status.HypervisorStatus.LastHeartbeatTime
+	if lastHeartbeat.IsZero() {
+		return 0, false
+	}
+	return lastHeartbeat.Add(constants.HypervisorHeartbeatTimeout).Sub(now), true
+}
+
+// markHeartbeatLost sets the node and its GPUs to Unknown so that GPUs are not allocated until heartbeats resume,
+// the node is set back to Running once its capacity is refreshed with fresh heartbeats
+func (r *GPUNodeReconciler) markHeartbeatLost(ctx context.Context, node *tfv1.GPUNode) error {
+	if node.Status.Phase != tfv1.TensorFusionGPUNodePhaseUnknown {
+		patch := client.MergeFrom(node.DeepCopy())
+		node.Status.Phase = tfv1.TensorFusionGPUNodePhaseUnknown
+		if err := r.Status().Patch(ctx, node, patch); err != nil {
+			return fmt.Errorf("failed to mark node unknown: %w", err)
+		}
+		r.Recorder.Eventf(node, corev1.EventTypeWarning, "HeartbeatLost",
+			"No hypervisor heartbeat since %s, GPUs are excluded from allocation",
+			node.Status.HypervisorStatus.LastHeartbeatTime.Format(time.RFC3339))
+		log.FromContext(ctx).Info("hypervisor heartbeat lost", "node", node.Name,
+			"lastHeartbeatTime", node.Status.HypervisorStatus.LastHeartbeatTime)
+	}
+	return r.syncStatusToGPUDevices(ctx, node, tfv1.TensorFusionGPUPhaseUnknown)
+}
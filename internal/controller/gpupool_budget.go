@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/cloudprovider/common"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// reconcileBudget evaluates the provisioner budget of the pool and records exceeded periods in pool status,
+// the least used provisioned nodes are terminated when the strategy is AlertAndTerminateVM.
+// Returns the budget usages to check new nodes against, nil when no budget is set
+func (r *GPUPoolReconciler) reconcileBudget(ctx context.Context, pool *tfv1.GPUPool) ([]common.BudgetUsage, error) {
+	var budget *tfv1.PeriodicalBudget
+	if provisioner := pool.Spec.NodeManagerConfig.NodeProvisioner; provisioner != nil {
+		budget = provisioner.Budget
+	}
+	if budget == nil {
+		return nil, r.setBudgetExceeded(ctx, pool, nil)
+	}
+
+	nodes := &tfv1.GPUNodeList{}
+	if err := r.List(ctx, nodes, client.MatchingLabels{constants.LabelKeyOwner: pool.Name}); err != nil {
+		return nil, fmt.Errorf("failed to list nodes of pool %s: %w", pool.Name, err)
+	}
+	usages, err := common.EvaluateBudget(budget, nodes.Items, pool.Status.AccruedCosts, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	exceeded := lo.Filter(usages, func(usage common.BudgetUsage, _ int) bool {
+		return usage.Exceeded()
+	})
+	if err := r.setBudgetExceeded(ctx, pool, exceeded); err != nil {
+		return nil, err
+	}
+	if budget.BudgetExceedStrategy == tfv1.BudgetExceedStrategyAlertAndTerminateVM {
+		if err := r.terminateNodesOverBudget(ctx, pool, nodes.Items, usages); err != nil {
+			return nil, err
+		}
+	}
+	return usages, nil
+}
+
+// recordDeletedNodeCost adds costs of the deleted node to the accrued costs of its pool,
+// so that budgets still count the node once it is gone
+func recordDeletedNodeCost(ctx context.Context, c client.Client, node *tfv1.GPUNode) error {
+	poolName := node.Labels[constants.LabelKeyOwner]
+	if node.Spec.CostPerHour == "" || poolName == "" {
+		return nil
+	}
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		pool := &tfv1.GPUPool{}
+		if err := c.Get(ctx, client.ObjectKey{Name: poolName}, pool); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		accrued, err := common.AccrueNodeCost(pool.Status.AccruedCosts, node, time.Now())
+		if err != nil {
+			return err
+		}
+		patch := client.MergeFromWithOptions(pool.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		pool.Status.AccruedCosts = accrued
+		return c.Status().Patch(ctx, pool, patch)
+	})
+}
+
+// setBudgetExceeded records exceeded periods in pool status, alerts when the budget becomes exceeded
+func (r *GPUPoolReconciler) setBudgetExceeded(ctx context.Context, pool *tfv1.GPUPool, exceeded []common.BudgetUsage) error {
+	budgetExceeded := strings.Join(lo.Map(exceeded, func(usage common.BudgetUsage, _ int) string {
+		return usage.Period
+	}), ",")
+	if pool.Status.BudgetExceeded == budgetExceeded {
+		return nil
+	}
+
+	patch := client.MergeFrom(pool.DeepCopy())
+	pool.Status.BudgetExceeded = budgetExceeded
+	if err := r.Status().Patch(ctx, pool, patch); err != nil {
+		return fmt.Errorf("failed to update budget exceeded status: %w", err)
+	}
+	if budgetExceeded == "" {
+		r.Recorder.Eventf(pool, corev1.EventTypeNormal, "BudgetRecovered", "Costs of pool %s are within budget", pool.Name)
+		return nil
+	}
+	for _, usage := range exceeded {
+		r.Recorder.Eventf(pool, corev1.EventTypeWarning, "BudgetExceeded",
+			"Budget per %s exceeded, spent %.2f of %.2f, stop scaling up", usage.Period, usage.Spent, usage.Budget)
+	}
+	log.FromContext(ctx).Info("pool budget exceeded", "pool", pool.Name, "periods", budgetExceeded)
+	return nil
+}
+
+// terminateNodesOverBudget drains the least used provisioned nodes until running the rest of them until the end
+// of each period fits the budget, nodes are deleted once their workers are moved off. All provisioned nodes are
+// drained when the budget is already spent. Nodes labeled do-not-disrupt are kept
+func (r *GPUPoolReconciler) terminateNodesOverBudget(
+	ctx context.Context, pool *tfv1.GPUPool, nodes []tfv1.GPUNode, usages []common.BudgetUsage,
+) error {
+	// costs of nodes being terminated no longer count, deleted nodes are already left out of usages
+	removedCostPerHour := 0.0
+	for i := range nodes {
+		node := &nodes[i]
+		if _, ok := node.Annotations[constants.BudgetTerminateAnnotation]; !ok || !node.DeletionTimestamp.IsZero() {
+			continue
+		}
+		costPerHour, err := common.NodeCostPerHour(node)
+		if err != nil {
+			return err
+		}
+		removedCostPerHour += costPerHour
+		if node.Status.Drain == nil || node.Status.Drain.Phase != tfv1.GPUNodeDrained {
+			continue
+		}
+		r.Recorder.Eventf(pool, corev1.EventTypeWarning, "BudgetTerminateNode",
+			"Terminating drained node %s since budget of pool %s is exceeded", node.Name, pool.Name)
+		if err := r.Delete(ctx, node); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("delete node(%s) : %w", node.Name, err)
+		}
+	}
+
+	candidates := lo.Filter(nodes, func(node tfv1.GPUNode, _ int) bool {
+		_, terminating := node.Annotations[constants.BudgetTerminateAnnotation]
+		costPerHour, _ := common.NodeCostPerHour(&node)
+		return node.Spec.ManageMode == tfv1.GPUNodeManageModeProvisioned && node.DeletionTimestamp.IsZero() &&
+			!terminating && costPerHour > 0 &&
+			node.Labels[constants.SchedulingDoNotDisruptLabel] != constants.TrueStringValue
+	})
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return allocatedTFlopsPercent(&candidates[i]) < allocatedTFlopsPercent(&candidates[j])
+	})
+	for i := range candidates {
+		if common.FitsBudget(usages, removedCostPerHour) {
+			return nil
+		}
+		node := &candidates[i]
+		costPerHour, _ := common.NodeCostPerHour(node)
+		patch := client.MergeFrom(node.DeepCopy())
+		if node.Annotations == nil {
+			node.Annotations = map[string]string{}
+		}
+		node.Annotations[constants.BudgetTerminateAnnotation] = constants.TrueStringValue
+		node.Spec.Drain = true
+		if err := r.Patch(ctx, node, patch); err != nil {
+			return fmt.Errorf("drain node(%s) : %w", node.Name, err)
+		}
+		removedCostPerHour += costPerHour
+		r.Recorder.Eventf(pool, corev1.EventTypeWarning, "BudgetDrainNode",
+			"Draining node %s to terminate it since budget of pool %s would be exceeded", node.Name, pool.Name)
+	}
+	return nil
+}
+
+func allocatedTFlopsPercent(node *tfv1.GPUNode) float64 {
+	total := node.Status.TotalTFlops.AsApproximateFloat64()
+	if total == 0 {
+		return 0
+	}
+	return (total - node.Status.AvailableTFlops.AsApproximateFloat64()) / total
+}
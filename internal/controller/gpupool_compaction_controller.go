@@ -63,6 +63,10 @@ func (r *GPUPoolCompactionReconciler) checkNodeCompaction(ctx context.Context, p
 		if gpuNode.Labels[constants.SchedulingDoNotDisruptLabel] == constants.TrueStringValue {
 			continue
 		}
+		// Skip a node being drained, it's compacted once all workers are moved off
+		if gpuNode.Spec.Drain && (gpuNode.Status.Drain == nil || gpuNode.Status.Drain.Phase != tfv1.GPUNodeDrained) {
+			continue
+		}
 
 		// Check if node is empty, if not, continue
 		var nodeGPUConnection tfv1.TensorFusionConnectionList
@@ -21,12 +21,15 @@ import (
 	"fmt"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
 	"github.com/NexusGPU/tensor-fusion/internal/component"
 	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/NexusGPU/tensor-fusion/internal/metrics"
 	utils "github.com/NexusGPU/tensor-fusion/internal/utils"
+	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -35,8 +38,10 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 // GPUPoolReconciler reconciles a GPUPool object
@@ -47,6 +52,10 @@ type GPUPoolReconciler struct {
 
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
+
+	// GPU model to costs per hour, used to price GPUs of nodes without cost when calculating savings,
+	// replaced as a whole when GPU info config changes
+	GPUPricingMap *atomic.Pointer[map[string]float64]
 }
 
 // +kubebuilder:rbac:groups=tensor-fusion.ai,resources=gpupools,verbs=get;list;watch;create;update;patch;delete
@@ -208,6 +217,21 @@ func (r *GPUPoolReconciler) reconcilePoolCurrentCapacityAndReadiness(ctx context
 
 	pool.Status.RunningAppsCnt = runningAppsCnt
 
+	pool.Status.AllocatedTFlopsPercent = tfv1.AllocatedPercent(totalTFlops, availableTFlops)
+	pool.Status.AllocatedVRAMPercent = tfv1.AllocatedPercent(totalVRAM, availableVRAM)
+
+	gpus := &tfv1.GPUList{}
+	if err := r.List(ctx, gpus, client.MatchingLabels{constants.GpuPoolKey: pool.Name}); err != nil {
+		return fmt.Errorf("list GPUs of Pool %s failed: %w", pool.Name, err)
+	}
+	var gpuPricingMap map[string]float64
+	if r.GPUPricingMap != nil {
+		gpuPricingMap = *r.GPUPricingMap.Load()
+	}
+	savedPerHour, potentialPerHour := metrics.CalculateSavings(nodes.Items, gpus.Items, gpuPricingMap)
+	pool.Status.SavedCostsPerMonth = tfv1.FormatFloat(savedPerHour * metrics.HoursPerMonth)
+	pool.Status.PotentialSavingsPerMonth = tfv1.FormatFloat(potentialPerHour * metrics.HoursPerMonth)
+
 	allowScaleToZero := true
 	if pool.Spec.CapacityConfig != nil && pool.Spec.CapacityConfig.MinResources != nil {
 		minTFlops, _ := pool.Spec.CapacityConfig.MinResources.TFlops.AsInt64()
@@ -249,8 +273,13 @@ func (r *GPUPoolReconciler) reconcilePoolComponents(ctx context.Context, pool *t
 		log.Info("Finished reconciling components", "duration", time.Since(startTime))
 	}()
 
+	queuing, err := component.GetMultiProcessQueuing(ctx, r.Client, pool)
+	if err != nil {
+		return nil, err
+	}
+
 	components := []component.Interface{
-		&component.Hypervisor{},
+		&component.Hypervisor{MultiProcessQueuing: queuing},
 		&component.Worker{},
 		&component.Client{},
 	}
@@ -281,8 +310,28 @@ func (r *GPUPoolReconciler) reconcilePoolComponents(ctx context.Context, pool *t
 // SetupWithManager sets up the controller with the Manager.
 func (r *GPUPoolReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&tfv1.GPUPool{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		For(&tfv1.GPUPool{}, builder.WithPredicates(predicate.Or(
+			predicate.GenerationChangedPredicate{},
+			// rollback is requested by annotation without changing the spec
+			predicate.NewPredicateFuncs(func(obj client.Object) bool {
+				return obj.GetAnnotations()[constants.RollbackAnnotation] != ""
+			}),
+		))).
 		Named("gpupool").
 		Owns(&tfv1.GPUNode{}).
+		Watches(&tfv1.SchedulingConfigTemplate{}, handler.EnqueueRequestsFromMapFunc(r.findPoolsForTemplate)).
 		Complete(r)
 }
+
+// findPoolsForTemplate enqueues pools using the template, hypervisor config in templates is part of the component hash
+func (r *GPUPoolReconciler) findPoolsForTemplate(ctx context.Context, obj client.Object) []reconcile.Request {
+	pools := &tfv1.GPUPoolList{}
+	if err := r.List(ctx, pools); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list pools for scheduling config template", "template", obj.GetName())
+		return nil
+	}
+	return lo.FilterMap(pools.Items, func(pool tfv1.GPUPool, _ int) (reconcile.Request, bool) {
+		return reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&pool)},
+			pool.Spec.SchedulingConfigTemplate != nil && *pool.Spec.SchedulingConfigTemplate == obj.GetName()
+	})
+}
@@ -535,6 +535,7 @@ func verifyAllWorkerPodContainerName(tfEnv *TensorFusionEnv, name string) {
 			g.Expect(podList.Items).Should(HaveLen(int(*workload.Spec.Replicas)))
 			for _, pod := range podList.Items {
 				g.Expect(pod.Spec.Containers[0].Name).Should(Equal(name))
+				updatePodPhaseToRunning(&pod, pod.Labels[constants.LabelKeyPodTemplateHash])
 			}
 		}
 
@@ -700,6 +701,8 @@ func createClientPodByIndex(tfEnv *TensorFusionEnv, index int) {
 		},
 	}
 	Expect(k8sClient.Create(ctx, pod)).Should(Succeed())
+	// updated client pods need to be ready before the next batch
+	updatePodPhaseToRunning(pod, pod.Labels[constants.LabelKeyPodTemplateHash])
 
 	Eventually(func(g Gomega) {
 		pod := &corev1.Pod{}
@@ -732,6 +735,7 @@ func createClientPods(tfEnv *TensorFusionEnv, count int) {
 			},
 		}
 		Expect(k8sClient.Create(ctx, pod)).Should(Succeed())
+		updatePodPhaseToRunning(pod, pod.Labels[constants.LabelKeyPodTemplateHash])
 	}
 
 	Eventually(func(g Gomega) {
@@ -23,6 +23,16 @@ import (
 // Controller and trigger logic for abstract layer of node provisioning
 func (r *GPUPoolReconciler) reconcilePoolCapacityWithProvisioner(ctx context.Context, pool *tfv1.GPUPool) (bool, error) {
 	log := log.FromContext(ctx)
+
+	budgetUsages, err := r.reconcileBudget(ctx, pool)
+	if err != nil {
+		return false, err
+	}
+	if pool.Status.BudgetExceeded != "" {
+		log.Info("Should NOT scale up GPU node due to budget exceeded", "pool", pool.Name, "periods", pool.Status.BudgetExceeded)
+		return false, nil
+	}
+
 	// check if min resource constraint is satisfied
 	shouldScaleUp := false
 	tflopsGap := int64(0)
@@ -106,25 +116,38 @@ func (r *GPUPoolReconciler) reconcilePoolCapacityWithProvisioner(ctx context.Con
 		return false, err
 	}
 
+	costsPerHour := make([]float64, len(gpuNodeParams))
+	totalCostPerHour := 0.0
+	for i, node := range gpuNodeParams {
+		costsPerHour[i], err = provider.GetInstancePricing(node.InstanceType, node.Region, node.CapacityType)
+		if err != nil {
+			return false, err
+		}
+		totalCostPerHour += costsPerHour[i]
+	}
+	for _, usage := range budgetUsages {
+		if usage.WouldExceed(totalCostPerHour) {
+			log.Info("Should NOT scale up GPU node due to budget constraint", "pool", pool.Name, "period", usage.Period)
+			r.Recorder.Eventf(pool, corev1.EventTypeWarning, "BudgetConstraintReached",
+				"Budget per %s would be exceeded by %.2f/hour of new nodes, spent %.2f of %.2f, can not scale up",
+				usage.Period, totalCostPerHour, usage.Spent, usage.Budget)
+			return false, nil
+		}
+	}
+
 	var wg sync.WaitGroup
 	wg.Add(len(gpuNodeParams))
 
 	var errList []error
 
-	for _, node := range gpuNodeParams {
-		go func(node types.NodeCreationParam) {
+	for i, node := range gpuNodeParams {
+		go func(node types.NodeCreationParam, costPerHour float64) {
 			defer wg.Done()
 
 			// Create GPUNode custom resource immediately and GPUNode controller will watch the K8S node to be ready
 			// Persist the status to GPUNode to avoid duplicated creation in next reconciliation
 			// If the K8S node never be ready after some time, the GPUNode will be deleted, then the Pool reconcile loop can scale up and meet the capacity constraint again
 
-			costPerHour, pricingErr := provider.GetInstancePricing(node.InstanceType, node.Region, node.CapacityType)
-			if pricingErr != nil {
-				errList = append(errList, pricingErr)
-				return
-			}
-
 			params, _ := json.Marshal(node)
 			gpuNodeRes := &tfv1.GPUNode{
 				ObjectMeta: metav1.ObjectMeta{
@@ -157,7 +180,7 @@ func (r *GPUPoolReconciler) reconcilePoolCapacityWithProvisioner(ctx context.Con
 				errList = append(errList, err)
 				return
 			}
-		}(node)
+		}(node, costsPerHour[i])
 	}
 
 	wg.Wait()
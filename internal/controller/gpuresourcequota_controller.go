@@ -0,0 +1,105 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/NexusGPU/tensor-fusion/internal/gpuallocator"
+)
+
+// GPUResourceQuotaReconciler reconciles a GPUResourceQuota object
+type GPUResourceQuotaReconciler struct {
+	client.Client
+	Scheme    *runtime.Scheme
+	Allocator *gpuallocator.GpuAllocator
+}
+
+// +kubebuilder:rbac:groups=tensor-fusion.ai,resources=gpuresourcequotas,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=tensor-fusion.ai,resources=gpuresourcequotas/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=tensor-fusion.ai,resources=gpuresourcequotas/finalizers,verbs=update
+
+// GPUResourceQuota is enforced by the pod webhook and GPU allocator, reconcile only reports
+// the usage tracked by the allocator in status
+func (r *GPUResourceQuotaReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	quota := &tfv1.GPUResourceQuota{}
+	if err := r.Get(ctx, req.NamespacedName, quota); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	used := r.Allocator.QuotaUsage(quota.Namespace, quota.Spec.PoolName)
+	if !equality.Semantic.DeepEqual(quota.Status.Used, used) {
+		quota.Status.Used = used
+		now := metav1.Now()
+		quota.Status.LastUpdateTime = &now
+		if err := r.Status().Update(ctx, quota); err != nil {
+			return ctrl.Result{}, fmt.Errorf("update gpu resource quota status: %w", err)
+		}
+		log.Info("Updated gpu resource quota usage", "quota", quota.Name, "namespace", quota.Namespace)
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GPUResourceQuotaReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	workerPredicate, err := predicate.LabelSelectorPredicate(metav1.LabelSelector{
+		MatchLabels: map[string]string{constants.LabelComponent: constants.ComponentWorker},
+	})
+	if err != nil {
+		return fmt.Errorf("create worker predicate: %w", err)
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&tfv1.GPUResourceQuota{}).
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(r.findQuotasForWorker), builder.WithPredicates(workerPredicate)).
+		Named("gpuresourcequota").
+		Complete(r)
+}
+
+// findQuotasForWorker maps a worker pod to quotas of its namespace
+func (r *GPUResourceQuotaReconciler) findQuotasForWorker(ctx context.Context, obj client.Object) []reconcile.Request {
+	quotas := &tfv1.GPUResourceQuotaList{}
+	if err := r.List(ctx, quotas, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list GPUResourceQuota", "namespace", obj.GetNamespace())
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(quotas.Items))
+	for i := range quotas.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&quotas.Items[i])})
+	}
+	return requests
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+)
+
+var _ = Describe("GPUResourceQuota Controller", func() {
+	Context("When reconciling a resource", func() {
+		const resourceName = "test-quota"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			By("creating the custom resource for the Kind GPUResourceQuota")
+			quota := &tfv1.GPUResourceQuota{}
+			err := k8sClient.Get(ctx, typeNamespacedName, quota)
+			if err != nil && errors.IsNotFound(err) {
+				resource := &tfv1.GPUResourceQuota{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      resourceName,
+						Namespace: "default",
+					},
+					Spec: tfv1.GPUResourceQuotaSpec{
+						PoolName: "mock",
+						Hard: tfv1.GPUResourceQuotaAmounts{
+							RequestsTflops: ptr.To(resource.MustParse("100")),
+							Workers:        ptr.To(int32(10)),
+						},
+					},
+				}
+				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+			}
+		})
+
+		AfterEach(func() {
+			resource := &tfv1.GPUResourceQuota{}
+			err := k8sClient.Get(ctx, typeNamespacedName, resource)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Cleanup the specific resource instance GPUResourceQuota")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+		})
+
+		It("should report usage tracked by the allocator in status", func() {
+			Eventually(func(g Gomega) {
+				quota := &tfv1.GPUResourceQuota{}
+				g.Expect(k8sClient.Get(ctx, typeNamespacedName, quota)).Should(Succeed())
+				g.Expect(quota.Status.LastUpdateTime).ShouldNot(BeNil())
+				g.Expect(quota.Status.Used.Workers).Should(Equal(ptr.To(int32(0))))
+			}).Should(Succeed())
+		})
+	})
+})
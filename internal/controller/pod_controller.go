@@ -20,18 +20,24 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 
 	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/config"
 	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/NexusGPU/tensor-fusion/internal/gpuallocator"
 	"github.com/NexusGPU/tensor-fusion/internal/portallocator"
 	"github.com/NexusGPU/tensor-fusion/internal/utils"
 	v1 "github.com/NexusGPU/tensor-fusion/internal/webhook/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/worker"
+	"github.com/lithammer/shortuuid/v4"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -44,6 +50,9 @@ type PodReconciler struct {
 	client.Client
 	Scheme        *runtime.Scheme
 	PortAllocator *portallocator.PortAllocator
+	Allocator     *gpuallocator.GpuAllocator
+	Recorder      record.EventRecorder
+	GpuInfos      *[]config.GpuInfo
 }
 
 // +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;create;update;patch;delete;deletecollection
@@ -79,11 +88,20 @@ func (r *PodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		return ctrl.Result{}, nil
 	}
 
-	if _, ok := pod.Annotations[constants.TensorFusionEnabledReplicasAnnotation]; ok {
+	_, hasEnabledReplicas := pod.Annotations[constants.TensorFusionEnabledReplicasAnnotation]
+	embeddedWorker := pod.Labels[constants.EmbeddedWorkerLabel] == constants.TrueStringValue
+	if hasEnabledReplicas || embeddedWorker {
 		shouldReturn, err := utils.HandleFinalizer(ctx, pod, r.Client, func(context context.Context, pod *corev1.Pod) (bool, error) {
-			counter := &v1.TensorFusionPodCounter{Client: r.Client}
-			if err := counter.Decrease(ctx, pod); err != nil {
-				return false, err
+			if hasEnabledReplicas {
+				counter := &v1.TensorFusionPodCounter{Client: r.Client}
+				if err := counter.Decrease(ctx, pod); err != nil {
+					return false, err
+				}
+			}
+			if embeddedWorker {
+				if err := r.releaseEmbeddedWorkerGPUs(ctx, pod); err != nil {
+					return false, err
+				}
 			}
 			return true, nil
 		})
@@ -95,6 +113,13 @@ func (r *PodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		}
 	}
 
+	if embeddedWorker && pod.DeletionTimestamp.IsZero() && hasEmbeddedWorkerGate(pod) {
+		if err := r.assignEmbeddedWorkerGPUs(ctx, pod); err != nil {
+			log.Error(err, "Failed to allocate GPUs for embedded worker", "pod", pod.Name)
+			return ctrl.Result{RequeueAfter: constants.PendingRequeueDuration}, nil
+		}
+	}
+
 	existConn := &tfv1.TensorFusionConnection{}
 	if err := r.Get(ctx, types.NamespacedName{Name: tfConnection.Name, Namespace: tfConnection.Namespace}, existConn); err != nil {
 		if errors.IsNotFound(err) {
@@ -107,6 +132,74 @@ func (r *PodReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 	return ctrl.Result{}, nil
 }
 
+func hasEmbeddedWorkerGate(pod *corev1.Pod) bool {
+	return lo.ContainsBy(pod.Spec.SchedulingGates, func(gate corev1.PodSchedulingGate) bool {
+		return gate.Name == constants.EmbeddedWorkerSchedulingGate
+	})
+}
+
+// assignEmbeddedWorkerGPUs allocates GPUs for the embedded worker of a client pod held by the scheduling gate,
+// records them on the pod and releases the gate, GPUs are given back when the pod can not be updated
+func (r *PodReconciler) assignEmbeddedWorkerGPUs(ctx context.Context, pod *corev1.Pod) error {
+	workload := &tfv1.TensorFusionWorkload{}
+	if err := r.Get(ctx, client.ObjectKey{Name: pod.Annotations[constants.WorkloadKey], Namespace: pod.Namespace}, workload); err != nil {
+		return fmt.Errorf("get workload: %w", err)
+	}
+
+	resources := worker.WorkerResources(pod)
+	req := gpuallocator.NewAllocRequest(workload)
+	req.Request = resources.Requests
+	req.Limit = resources.Limits
+	// the embedded worker runs in the client pod, all its GPUs must be on the same node
+	req.CrossNode = false
+	gpus, err := r.Allocator.AllocWithFilters(ctx, req)
+	if err != nil {
+		r.Recorder.Eventf(pod, corev1.EventTypeWarning, "ScheduleGPUFailed", "Failed to schedule GPU for embedded worker: %v", err)
+		return fmt.Errorf("allocate gpus: %w", err)
+	}
+	gpuKeys := lo.Map(gpus, func(gpu *tfv1.GPU, _ int) types.NamespacedName {
+		return client.ObjectKeyFromObject(gpu)
+	})
+
+	workerGenerator := &worker.WorkerGenerator{GpuInfos: r.GpuInfos}
+	gpuEnv, err := workerGenerator.GPUEnv(gpus, resources.Limits)
+	if err != nil {
+		r.Allocator.Dealloc(ctx, req.WorkloadNameNamespace, resources, gpuKeys)
+		return fmt.Errorf("generate gpu env: %w", err)
+	}
+	worker.AssignEmbeddedWorkerGPUs(pod, gpus, gpuEnv)
+	if err := r.Update(ctx, pod); err != nil {
+		r.Allocator.Dealloc(ctx, req.WorkloadNameNamespace, resources, gpuKeys)
+		return fmt.Errorf("assign gpus to pod: %w", err)
+	}
+	log.FromContext(ctx).Info("Allocated GPUs for embedded worker", "pod", pod.Name, "gpus", gpuKeys)
+	return nil
+}
+
+// releaseEmbeddedWorkerGPUs gives back GPUs allocated for the embedded worker of the client pod, the pod
+// is marked released first so that GPUs are not released twice, same as worker pods
+func (r *PodReconciler) releaseEmbeddedWorkerGPUs(ctx context.Context, pod *corev1.Pod) error {
+	if _, released := pod.Annotations[constants.GpuReleasedAnnotation]; released {
+		return nil
+	}
+	// deleted before GPUs were allocated
+	if pod.Annotations[constants.GpuKey] == "" {
+		return nil
+	}
+	pod.Annotations[constants.GpuReleasedAnnotation] = shortuuid.New()
+	if err := r.Update(ctx, pod); err != nil {
+		return fmt.Errorf("mark gpus of embedded worker released: %w", err)
+	}
+
+	gpus := lo.FilterMap(strings.Split(pod.Annotations[constants.GpuKey], ","), func(name string, _ int) (types.NamespacedName, bool) {
+		return types.NamespacedName{Name: name}, name != ""
+	})
+	workloadNameNs := tfv1.NameNamespace{Namespace: pod.Namespace, Name: pod.Annotations[constants.WorkloadKey]}
+	r.Allocator.Dealloc(ctx, workloadNameNs, worker.WorkerResources(pod), gpus)
+	log.FromContext(ctx).Info("Released GPUs of embedded worker", "pod", pod.Name, "gpus", gpus)
+	return nil
+}
+
 func generateTensorFusionConnection(pod *corev1.Pod) *tfv1.TensorFusionConnection {
 	workloadName, ok := pod.Annotations[constants.WorkloadKey]
 	if !ok {
@@ -23,6 +23,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -127,11 +128,13 @@ var _ = BeforeSuite(func() {
 	})
 	Expect(err).ToNot(HaveOccurred())
 
+	hourlyUnitPriceMap := &atomic.Pointer[map[string]float64]{}
+	hourlyUnitPriceMap.Store(&map[string]float64{
+		"A100": 10,
+	})
 	metricsRecorder = &metrics.MetricsRecorder{
-		MetricsOutputPath: "./metrics.log",
-		HourlyUnitPriceMap: map[string]float64{
-			"A100": 10,
-		},
+		MetricsOutputPath:  "./metrics.log",
+		HourlyUnitPriceMap: hourlyUnitPriceMap,
 		WorkerUnitPriceMap: make(map[string]map[string]metrics.RawBillingPricing),
 	}
 
@@ -175,13 +178,6 @@ var _ = BeforeSuite(func() {
 	}).SetupWithManager(mgr)
 	Expect(err).ToNot(HaveOccurred())
 
-	err = (&PodReconciler{
-		Client:        mgr.GetClient(),
-		Scheme:        mgr.GetScheme(),
-		PortAllocator: portAllocator,
-	}).SetupWithManager(mgr)
-	Expect(err).ToNot(HaveOccurred())
-
 	err = (&NodeReconciler{
 		Client:   mgr.GetClient(),
 		Scheme:   mgr.GetScheme(),
@@ -199,6 +195,16 @@ var _ = BeforeSuite(func() {
 	_, err = allocator.SetupWithManager(ctx, mgr)
 	Expect(err).ToNot(HaveOccurred())
 
+	err = (&PodReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		PortAllocator: portAllocator,
+		Allocator:     allocator,
+		Recorder:      mgr.GetEventRecorderFor("Pod"),
+		GpuInfos:      config.MockGpuInfo(),
+	}).SetupWithManager(mgr)
+	Expect(err).ToNot(HaveOccurred())
+
 	err = (&TensorFusionConnectionReconciler{
 		Client:   mgr.GetClient(),
 		Scheme:   mgr.GetScheme(),
@@ -212,6 +218,13 @@ var _ = BeforeSuite(func() {
 	}).SetupWithManager(ctx, mgr)
 	Expect(err).ToNot(HaveOccurred())
 
+	err = (&GPUResourceQuotaReconciler{
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Allocator: allocator,
+	}).SetupWithManager(mgr)
+	Expect(err).ToNot(HaveOccurred())
+
 	err = (&TensorFusionWorkloadReconciler{
 		Client:        mgr.GetClient(),
 		Scheme:        mgr.GetScheme(),
@@ -222,6 +235,16 @@ var _ = BeforeSuite(func() {
 	}).SetupWithManager(mgr)
 	Expect(err).ToNot(HaveOccurred())
 
+	err = (&WorkerMigrationReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		Recorder:      mgr.GetEventRecorderFor("WorkerMigration"),
+		Allocator:     allocator,
+		PortAllocator: portAllocator,
+		GpuInfos:      config.MockGpuInfo(),
+	}).SetupWithManager(mgr)
+	Expect(err).ToNot(HaveOccurred())
+
 	go func() {
 		defer GinkgoRecover()
 		err = mgr.Start(ctx)
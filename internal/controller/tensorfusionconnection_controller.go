@@ -19,9 +19,11 @@ package controller
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -78,6 +80,11 @@ func (r *TensorFusionConnectionReconciler) Reconcile(ctx context.Context, req ct
 		return ctrl.Result{}, fmt.Errorf("get TensorFusionWorkload: %w", err)
 	}
 
+	// clients with an embedded worker connect to the worker sidecar in their own pod
+	if workload.Spec.EmbeddedWorker() {
+		return ctrl.Result{}, r.connectEmbeddedWorker(ctx, connection)
+	}
+
 	needReSelectWorker, workerStatus := r.needReSelectWorker(connection, workload.Status.WorkerStatuses)
 	if needReSelectWorker {
 		r.Recorder.Eventf(connection, corev1.EventTypeNormal, "SelectingWorker", "Selecting worker for connection %s", connection.Name)
@@ -97,14 +104,11 @@ func (r *TensorFusionConnectionReconciler) Reconcile(ctx context.Context, req ct
 		r.Recorder.Eventf(connection, corev1.EventTypeNormal, "WorkerSelected", "Worker %s successfully selected for connection", workerStatus.WorkerName)
 	}
 
-	connection.Status.Phase = workerStatus.WorkerPhase
+	// workers of a cross-node replica are connected together, ordered by rank
+	replicaWorkers := worker.ReplicaWorkers(workload, workerStatus)
+	connection.Status.Phase = replicaPhase(replicaWorkers)
 	connection.Status.WorkerName = workerStatus.WorkerName
-	resourceVersion := workerStatus.ResourceVersion
-	if resourceVersion == "" {
-		resourceVersion = "0"
-	}
-
-	connection.Status.ConnectionURL = fmt.Sprintf("native+%s+%d+%s-%s", workerStatus.WorkerIp, workerStatus.WorkerPort, workerStatus.WorkerName, resourceVersion)
+	connection.Status.ConnectionURL = connectionURL(replicaWorkers)
 	if err := r.Status().Update(ctx, connection); err != nil {
 		return ctrl.Result{}, fmt.Errorf("update connection status: %w", err)
 	}
@@ -112,6 +116,62 @@ func (r *TensorFusionConnectionReconciler) Reconcile(ctx context.Context, req ct
 	return ctrl.Result{}, nil
 }
 
+// connectEmbeddedWorker points the connection to the worker sidecar of the client pod owning it,
+// the sidecar listens in the network of the client pod
+func (r *TensorFusionConnectionReconciler) connectEmbeddedWorker(ctx context.Context, connection *tfv1.TensorFusionConnection) error {
+	podRef, ok := lo.Find(connection.OwnerReferences, func(ref metav1.OwnerReference) bool {
+		return ref.Kind == "Pod"
+	})
+	if !ok {
+		return fmt.Errorf("connection %s is not owned by a client pod", connection.Name)
+	}
+	embeddedWorker := tfv1.WorkerStatus{
+		WorkerPhase: tfv1.WorkerRunning,
+		WorkerName:  podRef.Name,
+		WorkerIp:    "127.0.0.1",
+		WorkerPort:  constants.EmbeddedWorkerPort,
+	}
+	url := connectionURL([]tfv1.WorkerStatus{embeddedWorker})
+	if connection.Status.ConnectionURL == url {
+		return nil
+	}
+	connection.Status.Phase = embeddedWorker.WorkerPhase
+	connection.Status.WorkerName = embeddedWorker.WorkerName
+	connection.Status.ConnectionURL = url
+	if err := r.Status().Update(ctx, connection); err != nil {
+		return fmt.Errorf("update connection status: %w", err)
+	}
+	r.Recorder.Eventf(connection, corev1.EventTypeNormal, "ConnectionReady", "Connection URL: %s", connection.Status.ConnectionURL)
+	return nil
+}
+
+// connectionURL returns the URL of the replica workers, the resource version of the worker is part of the URL
+// so that clients reconnect when the worker changes
+func connectionURL(workers []tfv1.WorkerStatus) string {
+	return strings.Join(lo.Map(workers, func(status tfv1.WorkerStatus, _ int) string {
+		resourceVersion := status.ResourceVersion
+		if resourceVersion == "" {
+			resourceVersion = "0"
+		}
+		return fmt.Sprintf("native+%s+%d+%s-%s", status.WorkerIp, status.WorkerPort, status.WorkerName, resourceVersion)
+	}), ",")
+}
+
+// replicaPhase returns the phase of the worst worker in the replica
+func replicaPhase(workers []tfv1.WorkerStatus) tfv1.WorkerPhase {
+	phase := tfv1.WorkerRunning
+	for _, status := range workers {
+		switch status.WorkerPhase {
+		case tfv1.WorkerFailed:
+			return tfv1.WorkerFailed
+		case tfv1.WorkerRunning:
+		default:
+			phase = status.WorkerPhase
+		}
+	}
+	return phase
+}
+
 func (r *TensorFusionConnectionReconciler) needReSelectWorker(connection *tfv1.TensorFusionConnection, workerStatuses []tfv1.WorkerStatus) (bool, tfv1.WorkerStatus) {
 	workerStatus, ok := lo.Find(workerStatuses, func(workerStatus tfv1.WorkerStatus) bool {
 		return workerStatus.WorkerName == connection.Status.WorkerName
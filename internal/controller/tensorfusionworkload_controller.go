@@ -18,6 +18,7 @@ package controller
 
 import (
 	"context"
+	goErrors "errors"
 	"fmt"
 	"sort"
 	"strconv"
@@ -27,15 +28,19 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/component"
 	"github.com/NexusGPU/tensor-fusion/internal/config"
 	"github.com/NexusGPU/tensor-fusion/internal/constants"
 	"github.com/NexusGPU/tensor-fusion/internal/gpuallocator"
@@ -87,6 +92,8 @@ func (r *TensorFusionWorkloadReconciler) Reconcile(ctx context.Context, req ctrl
 	}
 
 	shouldReturn, err := utils.HandleFinalizer(ctx, workload, r.Client, func(ctx context.Context, _ *tfv1.TensorFusionWorkload) (bool, error) {
+		// drop queued allocation and GPUs reserved for it
+		r.Allocator.CancelPending(ctx, tfv1.NameNamespace{Namespace: workload.Namespace, Name: workload.Name})
 		// delete all pods
 		existsPods := lo.Filter(podList.Items, func(pod corev1.Pod, _ int) bool {
 			return pod.DeletionTimestamp == nil
@@ -143,6 +150,18 @@ func (r *TensorFusionWorkloadReconciler) Reconcile(ctx context.Context, req ctrl
 	// init metrics map if needed
 	handleMetricsRecorder(podList, workload)
 
+	// frozen workers requested by connections get their GPUs back first
+	deleted, err := r.wakeUpWorkers(ctx, workload, podList.Items)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if deleted {
+		return ctrl.Result{RequeueAfter: constants.PendingRequeueDuration}, nil
+	}
+	if err := r.releaseFrozenWorkers(ctx, workload, podList.Items); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Fetch the GPUPool
 	pool := &tfv1.GPUPool{}
 	if err := r.Get(ctx, client.ObjectKey{Name: workload.Spec.PoolName}, pool); err != nil {
@@ -150,7 +169,7 @@ func (r *TensorFusionWorkloadReconciler) Reconcile(ctx context.Context, req ctrl
 	}
 
 	// Create worker generator
-	workerGenerator := &worker.WorkerGenerator{WorkerConfig: pool.Spec.ComponentConfig.Worker, GpuInfos: r.GpuInfos}
+	workerGenerator := &worker.WorkerGenerator{WorkerConfig: component.ActiveWorkerConfig(pool), GpuInfos: r.GpuInfos}
 
 	podTemplateHash, err := workerGenerator.PodTemplateHash(workload.Spec)
 	if err != nil {
@@ -210,9 +229,17 @@ func (r *TensorFusionWorkloadReconciler) reconcileScaling(
 	if workload.Spec.Replicas != nil {
 		desiredReplicas = *workload.Spec.Replicas
 	}
+	// embedded workers run inside client pods on GPUs allocated by the pod webhook, no worker pod is needed
+	if workload.Spec.EmbeddedWorker() {
+		desiredReplicas = 0
+	}
 
-	// Count current replicas
-	currentReplicas := int32(len(podList.Items))
+	// Count current replicas, workers of a cross-node replica are counted once,
+	// a worker being migrated and the new worker it is migrated to are counted once as well
+	replicas := groupWorkerReplicas(lo.Reject(podList.Items, func(pod corev1.Pod, _ int) bool {
+		return isMigrationTarget(&pod, podList.Items)
+	}))
+	currentReplicas := int32(len(replicas))
 	log.Info("Current replicas", "count", currentReplicas, "desired", desiredReplicas)
 
 	// Update workload status
@@ -236,17 +263,17 @@ func (r *TensorFusionWorkloadReconciler) reconcileScaling(
 		if !result.IsZero() {
 			return result, nil
 		}
-	} else if currentReplicas > desiredReplicas {
-		log.Info("Scaling down workers", "from", currentReplicas, "to", desiredReplicas)
+	} else {
+		// nothing to allocate, GPUs reserved by the pending queue are no longer needed
+		r.Allocator.CancelPending(ctx, tfv1.NameNamespace{Namespace: workload.Namespace, Name: workload.Name})
+	}
 
-		// Sort pods by creation time (oldest first)
-		sort.Slice(podList.Items, func(i, j int) bool {
-			return podList.Items[i].CreationTimestamp.Before(&podList.Items[j].CreationTimestamp)
-		})
+	if currentReplicas > desiredReplicas {
+		log.Info("Scaling down workers", "from", currentReplicas, "to", desiredReplicas)
 
-		// Calculate how many pods need to be removed
-		podsToRemove := int(currentReplicas - desiredReplicas)
-		if err := r.scaleDownWorkers(ctx, workload, podList.Items[:podsToRemove]); err != nil {
+		// Calculate how many replicas need to be removed, replicas are sorted by creation time (oldest first)
+		replicasToRemove := int(currentReplicas - desiredReplicas)
+		if err := r.scaleDownWorkers(ctx, workload, lo.Flatten(replicas[:replicasToRemove])); err != nil {
 			return ctrl.Result{}, err
 		}
 	}
@@ -254,6 +281,37 @@ func (r *TensorFusionWorkloadReconciler) reconcileScaling(
 	return ctrl.Result{}, nil
 }
 
+// groupWorkerReplicas groups worker pods by replica sorted by creation time (oldest first),
+// pods without replica label are started on a single node and form a replica on their own
+func groupWorkerReplicas(pods []corev1.Pod) [][]corev1.Pod {
+	replicas := [][]corev1.Pod{}
+	replicaIndex := make(map[string]int)
+	for _, pod := range pods {
+		replicaID, ok := pod.Labels[constants.WorkerReplicaLabel]
+		if !ok || replicaID == "" {
+			replicas = append(replicas, []corev1.Pod{pod})
+			continue
+		}
+		if index, exists := replicaIndex[replicaID]; exists {
+			replicas[index] = append(replicas[index], pod)
+			continue
+		}
+		replicaIndex[replicaID] = len(replicas)
+		replicas = append(replicas, []corev1.Pod{pod})
+	}
+
+	oldest := func(replica []corev1.Pod) metav1.Time {
+		return lo.MinBy(replica, func(a, b corev1.Pod) bool {
+			return a.CreationTimestamp.Before(&b.CreationTimestamp)
+		}).CreationTimestamp
+	}
+	sort.SliceStable(replicas, func(i, j int) bool {
+		oldestI, oldestJ := oldest(replicas[i]), oldest(replicas[j])
+		return oldestI.Before(&oldestJ)
+	})
+	return replicas
+}
+
 func handleMetricsRecorder(podList *corev1.PodList, workload *tfv1.TensorFusionWorkload) {
 	now := time.Now()
 	for i := range podList.Items {
@@ -262,19 +320,43 @@ func handleMetricsRecorder(podList *corev1.PodList, workload *tfv1.TensorFusionW
 	}
 }
 
-// TODO for is-local-gpu mode, should not start worker, and workload replicas must be dynamic, to support worker-as-lib design
-// create special tensorfusion connection instead with finalizer, based on workload status's pending worker count, when connection is removed, should Dealloc GPU resources
 func (r *TensorFusionWorkloadReconciler) tryStartWorker(
 	ctx context.Context,
 	workerGenerator *worker.WorkerGenerator,
 	gpus []*tfv1.GPU,
 	workload *tfv1.TensorFusionWorkload,
 	hash string,
+	replicaID string,
+	rank int,
+) (*corev1.Pod, error) {
+	pod, err := newWorkerPod(r.Scheme, r.PortAllocator, workerGenerator, gpus, workload, hash)
+	if err != nil {
+		return nil, err
+	}
+	if replicaID != "" {
+		pod.Labels[constants.WorkerReplicaLabel] = replicaID
+		pod.Annotations[constants.WorkerRankAnnotation] = strconv.Itoa(rank)
+	}
+	if err := r.Create(ctx, pod); err != nil {
+		return nil, fmt.Errorf("create pod %w", err)
+	}
+	return pod, nil
+}
+
+// newWorkerPod generates a worker pod of the workload on GPUs of a single node with a host port assigned,
+// the pod is owned by the workload and releases its GPUs by finalizer
+func newWorkerPod(
+	scheme *runtime.Scheme,
+	portAllocator *portallocator.PortAllocator,
+	workerGenerator *worker.WorkerGenerator,
+	gpus []*tfv1.GPU,
+	workload *tfv1.TensorFusionWorkload,
+	hash string,
 ) (*corev1.Pod, error) {
 	if len(gpus) == 0 || gpus[0].Labels == nil {
 		return nil, fmt.Errorf("no gpus or no labels, can not assign host port for worker")
 	}
-	port, err := r.PortAllocator.AssignHostPort(gpus[0].Status.NodeSelector[constants.KubernetesHostNameLabel])
+	port, err := portAllocator.AssignHostPort(gpus[0].Status.NodeSelector[constants.KubernetesHostNameLabel])
 	if err != nil {
 		return nil, fmt.Errorf("get host port %w", err)
 	}
@@ -303,12 +385,9 @@ func (r *TensorFusionWorkloadReconciler) tryStartWorker(
 	// Add finalizer for GPU resource cleanup
 	pod.Finalizers = append(pod.Finalizers, constants.Finalizer)
 
-	if err := ctrl.SetControllerReference(workload, pod, r.Scheme); err != nil {
+	if err := ctrl.SetControllerReference(workload, pod, scheme); err != nil {
 		return nil, fmt.Errorf("set owner reference %w", err)
 	}
-	if err := r.Create(ctx, pod); err != nil {
-		return nil, fmt.Errorf("create pod %w", err)
-	}
 	return pod, nil
 }
 
@@ -346,6 +425,12 @@ func (r *TensorFusionWorkloadReconciler) handlePodGPUCleanup(ctx context.Context
 		return false, err
 	}
 
+	// GPUs of frozen workers are released once the worker confirms the freeze
+	if frozenGPUsReleased(pod) {
+		log.Info("Pod is frozen, GPU resources already released", "pod", pod.Name)
+		return true, nil
+	}
+
 	// read the GPU names from the pod annotations
 	gpuNamesStr, ok := pod.Annotations[constants.GpuKey]
 	if !ok {
@@ -358,8 +443,8 @@ func (r *TensorFusionWorkloadReconciler) handlePodGPUCleanup(ctx context.Context
 	gpus := lo.Map(gpuNames, func(gpuName string, _ int) types.NamespacedName {
 		return types.NamespacedName{Name: gpuName}
 	})
-	// Release GPU resources
-	r.Allocator.Dealloc(ctx, tfv1.NameNamespace{Name: workload.Name, Namespace: workload.Namespace}, workload.Spec.Resources.Requests, gpus)
+	// Release GPU resources, the pod may be started with requests before they are auto set
+	r.Allocator.Dealloc(ctx, tfv1.NameNamespace{Name: workload.Name, Namespace: workload.Namespace}, worker.WorkerResources(pod), gpus)
 	log.Info("Released GPU resources via finalizer", "gpus", gpus, "pod", pod.Name)
 
 	return true, nil
@@ -380,40 +465,246 @@ func (r *TensorFusionWorkloadReconciler) deletePod(ctx context.Context, pod *cor
 
 // scaleUpWorkers handles the scaling up of worker pods
 func (r *TensorFusionWorkloadReconciler) scaleUpWorkers(ctx context.Context, workerGenerator *worker.WorkerGenerator, workload *tfv1.TensorFusionWorkload, count int, hash string) (ctrl.Result, error) {
-	workloadNameNs := tfv1.NameNamespace{Namespace: workload.Namespace, Name: workload.Name}
+	if workload.Spec.GangScheduling {
+		return r.scaleUpGangWorkers(ctx, workerGenerator, workload, count, hash)
+	}
+
 	// Create worker pods
+	workloadNameNs := tfv1.NameNamespace{Namespace: workload.Namespace, Name: workload.Name}
 	for range count {
 		// Schedule GPU for the worker
-		gpus, err := r.Allocator.Alloc(ctx, gpuallocator.AllocRequest{
-			PoolName:              workload.Spec.PoolName,
-			WorkloadNameNamespace: workloadNameNs,
-			Request:               workload.Spec.Resources.Requests,
-			Count:                 workload.Spec.GPUCount,
-			GPUModel:              workload.Spec.GPUModel,
-			NodeAffinity:          workload.Spec.NodeAffinity,
-		})
+		gpus, err := r.Allocator.Alloc(ctx, gpuallocator.NewAllocRequest(workload))
+		if goErrors.Is(err, gpuallocator.ErrQuotaExceeded) {
+			r.Recorder.Eventf(workload, corev1.EventTypeWarning, "QuotaExceeded", "Failed to schedule GPU: %v", err)
+			return ctrl.Result{RequeueAfter: constants.PendingRequeueDuration}, nil
+		}
+		if goErrors.Is(err, gpuallocator.ErrLocalGPUNotAllowed) {
+			r.Recorder.Eventf(workload, corev1.EventTypeWarning, "LocalGPUNotAllowed", "Failed to schedule GPU: %v", err)
+			return ctrl.Result{RequeueAfter: constants.PendingRequeueDuration}, nil
+		}
 		if err != nil {
 			metrics.SetSchedulerMetrics(workload.Spec.PoolName, false)
-			r.Recorder.Eventf(workload, corev1.EventTypeWarning, "ScheduleGPUFailed", "Failed to schedule GPU: %v", err)
+			position := r.Allocator.QueuePosition(workloadNameNs)
+			r.Recorder.Eventf(workload, corev1.EventTypeWarning, "ScheduleGPUFailed", "Failed to schedule GPU, queued at position %d: %v", position, err)
+			if workload.Status.QueuePosition != position {
+				workload.Status.QueuePosition = position
+				if err := r.Status().Update(ctx, workload); err != nil {
+					return ctrl.Result{}, fmt.Errorf("update status: %w", err)
+				}
+			}
+			if _, err := r.preemptLowerPriorityWorkers(ctx, workload); err != nil {
+				return ctrl.Result{}, err
+			}
 			return ctrl.Result{RequeueAfter: constants.PendingRequeueDuration}, nil
 		}
 
 		metrics.SetSchedulerMetrics(workload.Spec.PoolName, true)
-		_, err = r.tryStartWorker(ctx, workerGenerator, gpus, workload, hash)
-		if err != nil {
-			// Try to release all allocated GPUs if pod creation fails
-			gpus := lo.Map(gpus, func(gpu *tfv1.GPU, _ int) types.NamespacedName {
-				return client.ObjectKeyFromObject(gpu)
-			})
-			r.Allocator.Dealloc(ctx, workloadNameNs, workload.Spec.Resources.Requests, gpus)
-			return ctrl.Result{}, fmt.Errorf("create worker pod: %w", err)
+		if _, err := r.startReplicaWorkers(ctx, workerGenerator, gpus, workload, hash); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// scaleUpGangWorkers allocates GPUs of all missing replicas at once, no worker is started
+// until the whole gang fits, and the workload stays Pending with GPUScheduled condition false
+// while the gang is queued. Workers of the gang are all started or none of them
+func (r *TensorFusionWorkloadReconciler) scaleUpGangWorkers(ctx context.Context, workerGenerator *worker.WorkerGenerator, workload *tfv1.TensorFusionWorkload, count int, hash string) (ctrl.Result, error) {
+	workloadNameNs := tfv1.NameNamespace{Namespace: workload.Namespace, Name: workload.Name}
+	replicaGPUs, err := r.Allocator.AllocGang(ctx, gpuallocator.NewAllocRequest(workload), count)
+	if err != nil {
+		metrics.SetSchedulerMetrics(workload.Spec.PoolName, false)
+		r.Recorder.Eventf(workload, corev1.EventTypeWarning, "GangScheduleFailed", "Failed to schedule GPUs for all %d replicas: %v", count, err)
+		workload.Status.Phase = tfv1.TensorFusionWorkloadPhasePending
+		workload.Status.QueuePosition = r.Allocator.QueuePosition(workloadNameNs)
+		meta.SetStatusCondition(&workload.Status.Conditions, metav1.Condition{
+			Type:    constants.ConditionStatusTypeGPUScheduled,
+			Status:  metav1.ConditionFalse,
+			Reason:  "WaitingForGang",
+			Message: fmt.Sprintf("Waiting for GPUs of all %d replicas: %v", count, err),
+		})
+		if err := r.Status().Update(ctx, workload); err != nil {
+			return ctrl.Result{}, fmt.Errorf("update status: %w", err)
+		}
+		return ctrl.Result{RequeueAfter: constants.PendingRequeueDuration}, nil
+	}
+
+	metrics.SetSchedulerMetrics(workload.Spec.PoolName, true)
+	startedPods := []*corev1.Pod{}
+	for i, gpus := range replicaGPUs {
+		pods, err := r.startReplicaWorkers(ctx, workerGenerator, gpus, workload, hash)
+		if err == nil {
+			startedPods = append(startedPods, pods...)
+			continue
 		}
 
+		// Release GPUs reserved for replicas not started yet, started workers release their GPUs by finalizer
+		for _, notStarted := range replicaGPUs[i+1:] {
+			r.Allocator.Dealloc(ctx, workloadNameNs, workload.Spec.Resources, lo.Map(notStarted, func(gpu *tfv1.GPU, _ int) types.NamespacedName {
+				return client.ObjectKeyFromObject(gpu)
+			}))
+		}
+		for _, startedPod := range startedPods {
+			if deleteErr := r.deletePod(ctx, startedPod); deleteErr != nil {
+				return ctrl.Result{}, fmt.Errorf("start gang replica %d/%d: %w, rollback started workers: %v", i+1, len(replicaGPUs), err, deleteErr)
+			}
+		}
+		return ctrl.Result{}, fmt.Errorf("start gang replica %d/%d: %w", i+1, len(replicaGPUs), err)
 	}
 
+	if meta.SetStatusCondition(&workload.Status.Conditions, metav1.Condition{
+		Type:    constants.ConditionStatusTypeGPUScheduled,
+		Status:  metav1.ConditionTrue,
+		Reason:  "GangScheduled",
+		Message: fmt.Sprintf("GPUs of %d replicas scheduled", count),
+	}) {
+		if err := r.Status().Update(ctx, workload); err != nil {
+			return ctrl.Result{}, fmt.Errorf("update status: %w", err)
+		}
+	}
 	return ctrl.Result{}, nil
 }
 
+// preemptLowerPriorityWorkers evicts the minimal set of lower priority workers so that the allocation
+// of a high or critical QoS workload succeeds on retry, workers labeled do-not-disrupt are never evicted
+func (r *TensorFusionWorkloadReconciler) preemptLowerPriorityWorkers(ctx context.Context, workload *tfv1.TensorFusionWorkload) (bool, error) {
+	log := log.FromContext(ctx)
+
+	pool := &tfv1.GPUPool{}
+	if err := r.Get(ctx, client.ObjectKey{Name: workload.Spec.PoolName}, pool); err != nil {
+		return false, fmt.Errorf("get pool %s: %w", workload.Spec.PoolName, err)
+	}
+	if !gpuallocator.CanPreempt(pool, workload.Spec.Qos) {
+		return false, nil
+	}
+	priority := gpuallocator.QoSPriority(pool, workload.Spec.Qos)
+
+	workers := &corev1.PodList{}
+	if err := r.List(ctx, workers, client.MatchingLabels{
+		constants.LabelComponent: constants.ComponentWorker,
+	}); err != nil {
+		return false, fmt.Errorf("list workers: %w", err)
+	}
+
+	workloads := make(map[tfv1.NameNamespace]*tfv1.TensorFusionWorkload)
+	candidates := []gpuallocator.PreemptionCandidate{}
+	for _, pod := range workers.Items {
+		if !pod.DeletionTimestamp.IsZero() || pod.Labels[constants.SchedulingDoNotDisruptLabel] == constants.TrueStringValue ||
+			frozenWorkerPhase(&pod) != "" {
+			continue
+		}
+		workloadNameNs := tfv1.NameNamespace{Namespace: pod.Namespace, Name: pod.Labels[constants.WorkloadKey]}
+		if workloadNameNs.Namespace == workload.Namespace && workloadNameNs.Name == workload.Name {
+			continue
+		}
+		victimWorkload, ok := workloads[workloadNameNs]
+		if !ok {
+			victimWorkload = &tfv1.TensorFusionWorkload{}
+			if err := r.Get(ctx, client.ObjectKey{Namespace: workloadNameNs.Namespace, Name: workloadNameNs.Name}, victimWorkload); err != nil {
+				if errors.IsNotFound(err) {
+					continue
+				}
+				return false, fmt.Errorf("get workload %s/%s: %w", workloadNameNs.Namespace, workloadNameNs.Name, err)
+			}
+			workloads[workloadNameNs] = victimWorkload
+		}
+		if victimWorkload.Spec.PoolName != workload.Spec.PoolName ||
+			victimWorkload.Labels[constants.SchedulingDoNotDisruptLabel] == constants.TrueStringValue {
+			continue
+		}
+		victimPriority := gpuallocator.QoSPriority(pool, victimWorkload.Spec.Qos)
+		if victimPriority >= priority {
+			continue
+		}
+
+		candidates = append(candidates, gpuallocator.PreemptionCandidate{
+			Worker:                client.ObjectKeyFromObject(&pod),
+			WorkloadNameNamespace: workloadNameNs,
+			Priority:              victimPriority,
+			Request:               worker.WorkerResources(&pod).Requests,
+			GPUs: lo.Map(strings.Split(pod.Annotations[constants.GpuKey], ","), func(gpuName string, _ int) types.NamespacedName {
+				return types.NamespacedName{Name: gpuName}
+			}),
+		})
+	}
+	if len(candidates) == 0 {
+		return false, nil
+	}
+
+	victims, err := r.Allocator.PlanPreemption(ctx, gpuallocator.NewAllocRequest(workload), candidates)
+	if err != nil {
+		log.Info("no preemption can make room for workload", "workload", workload.Name, "reason", err.Error())
+		return false, nil
+	}
+
+	for _, victim := range victims {
+		pod := &corev1.Pod{}
+		if err := r.Get(ctx, victim.Worker, pod); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return false, fmt.Errorf("get worker %s: %w", victim.Worker, err)
+		}
+		if err := r.deletePod(ctx, pod); err != nil {
+			return false, err
+		}
+		if victimWorkload, ok := workloads[victim.WorkloadNameNamespace]; ok {
+			r.Recorder.Eventf(victimWorkload, corev1.EventTypeWarning, "Preempted",
+				"Worker %s preempted by workload %s/%s with %s QoS", pod.Name, workload.Namespace, workload.Name, workload.Spec.Qos)
+		}
+		r.Recorder.Eventf(workload, corev1.EventTypeNormal, "PreemptingWorkers",
+			"Preempting worker %s/%s of workload %s", pod.Namespace, pod.Name, victim.WorkloadNameNamespace.Name)
+	}
+	return len(victims) > 0, nil
+}
+
+// startReplicaWorkers starts one worker on each node the replica's GPUs are allocated on and returns them,
+// workers spread over multiple nodes share a replica ID and are ranked by node name
+func (r *TensorFusionWorkloadReconciler) startReplicaWorkers(
+	ctx context.Context,
+	workerGenerator *worker.WorkerGenerator,
+	gpus []*tfv1.GPU,
+	workload *tfv1.TensorFusionWorkload,
+	hash string,
+) ([]*corev1.Pod, error) {
+	workloadNameNs := tfv1.NameNamespace{Namespace: workload.Namespace, Name: workload.Name}
+	gpusByNode := lo.GroupBy(gpus, func(gpu *tfv1.GPU) string {
+		return gpu.Labels[constants.LabelKeyOwner]
+	})
+	nodeNames := lo.Keys(gpusByNode)
+	sort.Strings(nodeNames)
+
+	replicaID := ""
+	if len(nodeNames) > 1 {
+		replicaID = shortuuid.New()
+	}
+
+	startedPods := []*corev1.Pod{}
+	for rank, nodeName := range nodeNames {
+		pod, err := r.tryStartWorker(ctx, workerGenerator, gpusByNode[nodeName], workload, hash, replicaID, rank)
+		if err == nil {
+			startedPods = append(startedPods, pod)
+			continue
+		}
+
+		// Release GPUs of workers not started, started workers release their GPUs by finalizer
+		for _, notStarted := range nodeNames[rank:] {
+			gpuKeys := lo.Map(gpusByNode[notStarted], func(gpu *tfv1.GPU, _ int) types.NamespacedName {
+				return client.ObjectKeyFromObject(gpu)
+			})
+			r.Allocator.Dealloc(ctx, workloadNameNs, workload.Spec.Resources, gpuKeys)
+		}
+		for _, startedPod := range startedPods {
+			if deleteErr := r.deletePod(ctx, startedPod); deleteErr != nil {
+				return nil, fmt.Errorf("create worker pod: %w, rollback started workers: %v", err, deleteErr)
+			}
+		}
+		return nil, fmt.Errorf("create worker pod: %w", err)
+	}
+	return startedPods, nil
+}
+
 // updateStatus updates the status of a TensorFusionWorkload
 func (r *TensorFusionWorkloadReconciler) updateStatus(
 	ctx context.Context,
@@ -424,6 +715,9 @@ func (r *TensorFusionWorkloadReconciler) updateStatus(
 	log := log.FromContext(ctx)
 	readyReplicas := int32(0)
 	failedWorkers := 0
+	// a cross-node replica is ready only when all of its workers are ready
+	notReadyReplicaIDs := make(map[string]struct{})
+	readyReplicaIDs := make(map[string]struct{})
 
 	// Create a worker statuses slice to hold all worker status information
 	workerStatuses := []tfv1.WorkerStatus{}
@@ -441,7 +735,6 @@ func (r *TensorFusionWorkloadReconciler) updateStatus(
 		case corev1.PodRunning:
 			if utils.IsPodConditionTrue(pod.Status.Conditions, corev1.PodReady) {
 				workerPhase = tfv1.WorkerRunning
-				readyReplicas++
 			} else {
 				workerPhase = tfv1.WorkerPending
 			}
@@ -451,6 +744,21 @@ func (r *TensorFusionWorkloadReconciler) updateStatus(
 		default:
 			workerPhase = tfv1.WorkerPending
 		}
+		// frozen workers are woken up on the next connection, they are counted as ready
+		if frozenPhase := frozenWorkerPhase(&pod); frozenPhase != "" && pod.Status.Phase == corev1.PodRunning {
+			workerPhase = frozenPhase
+		}
+
+		ready := workerPhase == tfv1.WorkerRunning || workerPhase == tfv1.WorkerFrozenToMem || workerPhase == tfv1.WorkerFrozenToDisk
+		if replicaID := pod.Labels[constants.WorkerReplicaLabel]; replicaID != "" {
+			if ready {
+				readyReplicaIDs[replicaID] = struct{}{}
+			} else {
+				notReadyReplicaIDs[replicaID] = struct{}{}
+			}
+		} else if ready {
+			readyReplicas++
+		}
 
 		// Get worker IP and port information from pod
 		ip := pod.Status.PodIP
@@ -468,14 +776,29 @@ func (r *TensorFusionWorkloadReconciler) updateStatus(
 			WorkerPort:      port,
 			NodeSelector:    pod.Spec.NodeSelector,
 			ResourceVersion: pod.ResourceVersion,
+			ReplicaID:       pod.Labels[constants.WorkerReplicaLabel],
+		}
+		if rank, ok := pod.Annotations[constants.WorkerRankAnnotation]; ok {
+			workerStatus.Rank, _ = strconv.Atoi(rank)
+		}
+		if gpuNames, ok := pod.Annotations[constants.GpuKey]; ok && gpuNames != "" {
+			workerStatus.GPUs = strings.Split(gpuNames, ",")
 		}
 
 		workerStatuses = append(workerStatuses, workerStatus)
 	}
 
-	// Determine workload phase
+	for replicaID := range readyReplicaIDs {
+		if _, notReady := notReadyReplicaIDs[replicaID]; !notReady {
+			readyReplicas++
+		}
+	}
+
+	// Determine workload phase, conditions other than Ready are kept as is
 	var phase tfv1.TensorFusionWorkloadPhase
-	var conditions []metav1.Condition
+	conditions := lo.Filter(workload.Status.Conditions, func(condition metav1.Condition, _ int) bool {
+		return condition.Type != constants.ConditionStatusTypeReady
+	})
 
 	// Update Ready condition based on readyReplicas and desired replicas
 	readyCondition := metav1.Condition{
@@ -483,7 +806,12 @@ func (r *TensorFusionWorkloadReconciler) updateStatus(
 		LastTransitionTime: metav1.Now(),
 	}
 
-	if workload.Spec.Replicas != nil && readyReplicas == *workload.Spec.Replicas {
+	if workload.Spec.EmbeddedWorker() {
+		phase = tfv1.TensorFusionWorkloadPhaseRunning
+		readyCondition.Status = metav1.ConditionTrue
+		readyCondition.Reason = "EmbeddedWorkers"
+		readyCondition.Message = "Workers are embedded in client pods"
+	} else if workload.Spec.Replicas != nil && readyReplicas == *workload.Spec.Replicas {
 		phase = tfv1.TensorFusionWorkloadPhaseRunning
 		readyCondition.Status = metav1.ConditionTrue
 		readyCondition.Reason = "WorkloadReady"
@@ -502,8 +830,11 @@ func (r *TensorFusionWorkloadReconciler) updateStatus(
 	}
 	conditions = append(conditions, readyCondition)
 
+	queuePosition := r.Allocator.QueuePosition(tfv1.NameNamespace{Namespace: workload.Namespace, Name: workload.Name})
+
 	// Check if we need to update status
 	statusChanged := workload.Status.ReadyReplicas != readyReplicas ||
+		workload.Status.QueuePosition != queuePosition ||
 		!equality.Semantic.DeepEqual(workload.Status.WorkerStatuses, workerStatuses) ||
 		workload.Status.Phase != phase ||
 		!equality.Semantic.DeepEqual(workload.Status.Conditions, conditions)
@@ -514,6 +845,7 @@ func (r *TensorFusionWorkloadReconciler) updateStatus(
 		workload.Status.Conditions = conditions
 		workload.Status.ReadyReplicas = readyReplicas
 		workload.Status.WorkerStatuses = workerStatuses
+		workload.Status.QueuePosition = queuePosition
 		if err := r.Status().Update(ctx, workload); err != nil {
 			return fmt.Errorf("update workload status: %w", err)
 		}
@@ -526,6 +858,7 @@ func (r *TensorFusionWorkloadReconciler) SetupWithManager(mgr ctrl.Manager) erro
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&tfv1.TensorFusionWorkload{}).
 		Owns(&corev1.Pod{}).
+		WatchesRawSource(source.Channel(r.Allocator.QueueEvents(), &handler.EnqueueRequestForObject{})).
 		Named("tensorfusionworkload").
 		Complete(r)
 }
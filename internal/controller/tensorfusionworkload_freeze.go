@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/NexusGPU/tensor-fusion/internal/gpuallocator"
+	"github.com/NexusGPU/tensor-fusion/internal/worker"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// frozenWorkerPhase returns the phase of a worker frozen by auto freeze, empty when the worker is not frozen
+func frozenWorkerPhase(pod *corev1.Pod) tfv1.WorkerPhase {
+	switch pod.Annotations[constants.WorkerFrozenAnnotation] {
+	case constants.FrozenToMem:
+		return tfv1.WorkerFrozenToMem
+	case constants.FrozenToDisk:
+		return tfv1.WorkerFrozenToDisk
+	default:
+		return ""
+	}
+}
+
+// frozenGPUsReleased returns whether GPUs of the frozen worker have been released after it confirmed the freeze
+func frozenGPUsReleased(pod *corev1.Pod) bool {
+	return pod.Annotations[constants.WorkerFrozenGPUReleasedAnnotation] == constants.TrueStringValue
+}
+
+// releaseFrozenWorkers releases GPUs of frozen workers once they confirm their GPU context is frozen,
+// the worker is marked before GPUs are released so that they are never released twice
+func (r *TensorFusionWorkloadReconciler) releaseFrozenWorkers(ctx context.Context, workload *tfv1.TensorFusionWorkload, pods []corev1.Pod) error {
+	for i := range pods {
+		pod := &pods[i]
+		if frozenWorkerPhase(pod) == "" || pod.Annotations[constants.WorkerFrozenConfirmedAnnotation] == "" ||
+			frozenGPUsReleased(pod) || pod.Annotations[constants.WorkerWakeUpAnnotation] != "" || !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		patch := client.MergeFromWithOptions(pod.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		pod.Annotations[constants.WorkerFrozenGPUReleasedAnnotation] = constants.TrueStringValue
+		if err := r.Patch(ctx, pod, patch); err != nil {
+			return fmt.Errorf("mark GPUs of frozen worker %s released: %w", pod.Name, err)
+		}
+		gpus := lo.Map(strings.Split(pod.Annotations[constants.GpuKey], ","), func(gpuName string, _ int) types.NamespacedName {
+			return types.NamespacedName{Name: gpuName}
+		})
+		r.Allocator.Dealloc(ctx, tfv1.NameNamespace{Namespace: workload.Namespace, Name: workload.Name}, worker.WorkerResources(pod), gpus)
+		r.Recorder.Eventf(workload, corev1.EventTypeNormal, "FrozenWorkerReleased", "GPUs %s of worker %s frozen to %s are released",
+			pod.Annotations[constants.GpuKey], pod.Name, pod.Annotations[constants.WorkerFrozenConfirmedAnnotation])
+	}
+	return nil
+}
+
+// wakeUpWorkers allocates GPUs again for frozen workers requested by connections, the worker restores
+// its GPU context once the frozen annotation is removed. When the GPUs it was started with are taken,
+// the worker is deleted and a new one is started on other GPUs by scaling. Workers whose GPUs are not
+// released yet still hold them and are woken up directly
+func (r *TensorFusionWorkloadReconciler) wakeUpWorkers(ctx context.Context, workload *tfv1.TensorFusionWorkload, pods []corev1.Pod) (bool, error) {
+	log := log.FromContext(ctx)
+	deleted := false
+	for i := range pods {
+		pod := &pods[i]
+		if frozenWorkerPhase(pod) == "" || pod.Annotations[constants.WorkerWakeUpAnnotation] == "" ||
+			!pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		gpus := lo.Map(strings.Split(pod.Annotations[constants.GpuKey], ","), func(gpuName string, _ int) types.NamespacedName {
+			return types.NamespacedName{Name: gpuName}
+		})
+		resources := worker.WorkerResources(pod)
+		req := gpuallocator.NewAllocRequest(workload)
+		req.Request = resources.Requests
+		req.Limit = resources.Limits
+		req.Count = uint(len(gpus))
+		req.CrossNode = false
+		released := frozenGPUsReleased(pod)
+		if released {
+			if _, err := r.Allocator.AllocOnGPUs(ctx, req, gpus); err != nil {
+				log.Info("GPUs of frozen worker are taken, restarting worker", "pod", pod.Name, "reason", err.Error())
+				r.Recorder.Eventf(workload, corev1.EventTypeWarning, "WakeUpFailed",
+					"Can not wake up worker %s on its GPUs, restarting it: %v", pod.Name, err)
+				if err := r.deletePod(ctx, pod); err != nil {
+					return false, err
+				}
+				deleted = true
+				continue
+			}
+		}
+
+		patch := client.MergeFromWithOptions(pod.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		delete(pod.Annotations, constants.WorkerFrozenAnnotation)
+		delete(pod.Annotations, constants.WorkerFrozenConfirmedAnnotation)
+		delete(pod.Annotations, constants.WorkerFrozenGPUReleasedAnnotation)
+		delete(pod.Annotations, constants.WorkerWakeUpAnnotation)
+		if err := r.Patch(ctx, pod, patch); err != nil {
+			if released {
+				r.Allocator.Dealloc(ctx, req.WorkloadNameNamespace, resources, gpus)
+			}
+			return false, fmt.Errorf("wake up worker %s: %w", pod.Name, err)
+		}
+		r.Recorder.Eventf(workload, corev1.EventTypeNormal, "WorkerWokenUp", "Worker %s is woken up on GPUs %s",
+			pod.Name, pod.Annotations[constants.GpuKey])
+	}
+	return deleted, nil
+}
@@ -0,0 +1,494 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/component"
+	"github.com/NexusGPU/tensor-fusion/internal/config"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/NexusGPU/tensor-fusion/internal/gpuallocator"
+	"github.com/NexusGPU/tensor-fusion/internal/gpuallocator/filter"
+	"github.com/NexusGPU/tensor-fusion/internal/portallocator"
+	"github.com/NexusGPU/tensor-fusion/internal/utils"
+	"github.com/NexusGPU/tensor-fusion/internal/worker"
+	"github.com/samber/lo"
+)
+
+const (
+	defaultMigrationStartTimeout = 5 * time.Minute
+	defaultMigrationDrainTimeout = 30 * time.Second
+)
+
+// WorkerMigrationReconciler reconciles a WorkerMigration object
+type WorkerMigrationReconciler struct {
+	client.Client
+	Scheme        *runtime.Scheme
+	Recorder      record.EventRecorder
+	Allocator     *gpuallocator.GpuAllocator
+	PortAllocator *portallocator.PortAllocator
+	GpuInfos      *[]config.GpuInfo
+}
+
+// +kubebuilder:rbac:groups=tensor-fusion.ai,resources=workermigrations,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=tensor-fusion.ai,resources=workermigrations/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=tensor-fusion.ai,resources=workermigrations/finalizers,verbs=update
+
+// Move a worker to other GPUs: start a new worker on the target GPUs, switch connections of the old worker
+// to the new one, then delete the old worker after draining. The new worker is deleted and connections are
+// switched back when it fails to become ready
+func (r *WorkerMigrationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+	log.Info("Reconciling WorkerMigration", "name", req.Name, "namespace", req.Namespace)
+
+	migration := &tfv1.WorkerMigration{}
+	if err := r.Get(ctx, req.NamespacedName, migration); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	shouldReturn, err := utils.HandleFinalizer(ctx, migration, r.Client, func(ctx context.Context, migration *tfv1.WorkerMigration) (bool, error) {
+		// migration deleted halfway, the old worker keeps serving
+		if !isMigrationFinished(migration) {
+			if err := r.revert(ctx, migration); err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("handle finalizer: %w", err)
+	}
+	if shouldReturn || !migration.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	switch migration.Status.Phase {
+	case "", tfv1.WorkerMigrationPending:
+		return r.startTargetWorker(ctx, migration)
+	case tfv1.WorkerMigrationStartingWorker:
+		return r.waitTargetWorker(ctx, migration)
+	case tfv1.WorkerMigrationSwitchingConnections:
+		return r.switchConnections(ctx, migration)
+	case tfv1.WorkerMigrationDraining:
+		return r.drainSourceWorker(ctx, migration)
+	case tfv1.WorkerMigrationRollingBack:
+		return r.rollBack(ctx, migration)
+	default:
+		return ctrl.Result{}, nil
+	}
+}
+
+// startTargetWorker allocates the target GPUs and starts the new worker on them, the name of the new worker
+// is derived from the migration, so that a worker started by an earlier reconcile whose status update failed
+// is taken over instead of started again
+func (r *WorkerMigrationReconciler) startTargetWorker(ctx context.Context, migration *tfv1.WorkerMigration) (ctrl.Result, error) {
+	source := &corev1.Pod{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: migration.Namespace, Name: migration.Spec.WorkerName}, source); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, r.setPhase(ctx, migration, tfv1.WorkerMigrationFailed, "worker not found")
+		}
+		return ctrl.Result{}, fmt.Errorf("get worker: %w", err)
+	}
+	sourceGPUs := strings.Split(source.Annotations[constants.GpuKey], ",")
+
+	workloadName := source.Labels[constants.WorkloadKey]
+	targetName := migrationTargetName(migration, workloadName)
+	existing, err := r.getWorker(ctx, migration.Namespace, targetName)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if existing != nil {
+		return r.recordTargetWorker(ctx, migration, workloadName, sourceGPUs, existing)
+	}
+
+	if reason := unmovableReason(source); reason != "" {
+		return ctrl.Result{}, r.setPhase(ctx, migration, tfv1.WorkerMigrationFailed, reason)
+	}
+	// the worker is still the target of another migration while the old worker of that migration exists
+	if previousSource := source.Annotations[constants.MigrationSourceAnnotation]; previousSource != "" {
+		previous, err := r.getWorker(ctx, source.Namespace, previousSource)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if previous != nil {
+			return ctrl.Result{}, r.setPhase(ctx, migration, tfv1.WorkerMigrationFailed, "worker is the target of another migration")
+		}
+	}
+
+	workload := &tfv1.TensorFusionWorkload{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: source.Namespace, Name: workloadName}, workload); err != nil {
+		return ctrl.Result{}, fmt.Errorf("get workload: %w", err)
+	}
+	pool := &tfv1.GPUPool{}
+	if err := r.Get(ctx, client.ObjectKey{Name: workload.Spec.PoolName}, pool); err != nil {
+		return ctrl.Result{}, fmt.Errorf("get pool: %w", err)
+	}
+	workerGenerator := &worker.WorkerGenerator{WorkerConfig: component.ActiveWorkerConfig(pool), GpuInfos: r.GpuInfos}
+	hash, err := workerGenerator.PodTemplateHash(workload.Spec)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("get pod template hash: %w", err)
+	}
+	if source.Labels[constants.LabelKeyPodTemplateHash] != hash {
+		return ctrl.Result{}, r.setPhase(ctx, migration, tfv1.WorkerMigrationFailed, "worker is outdated and will be replaced by the workload")
+	}
+
+	gpus, err := r.allocTargetGPUs(ctx, migration, gpuallocator.NewAllocRequest(workload), sourceGPUs)
+	if err != nil {
+		return ctrl.Result{}, r.setPhase(ctx, migration, tfv1.WorkerMigrationFailed, fmt.Sprintf("allocate target GPUs: %v", err))
+	}
+	gpuKeys := lo.Map(gpus, func(gpu *tfv1.GPU, _ int) types.NamespacedName {
+		return client.ObjectKeyFromObject(gpu)
+	})
+
+	target, err := newWorkerPod(r.Scheme, r.PortAllocator, workerGenerator, gpus, workload, hash)
+	if err == nil {
+		target.Name = targetName
+		target.Annotations[constants.MigrationSourceAnnotation] = source.Name
+		err = r.Create(ctx, target)
+	}
+	if err != nil {
+		r.Allocator.Dealloc(ctx, tfv1.NameNamespace{Namespace: workload.Namespace, Name: workload.Name}, workload.Spec.Resources, gpuKeys)
+		// started by an earlier reconcile but not in cache yet, it is taken over on the next reconcile
+		if errors.IsAlreadyExists(err) {
+			return ctrl.Result{RequeueAfter: constants.PendingRequeueDuration}, nil
+		}
+		return ctrl.Result{}, r.setPhase(ctx, migration, tfv1.WorkerMigrationFailed, fmt.Sprintf("start new worker: %v", err))
+	}
+	return r.recordTargetWorker(ctx, migration, workload.Name, sourceGPUs, target)
+}
+
+// migrationTargetName returns the name of the new worker started by the migration
+func migrationTargetName(migration *tfv1.WorkerMigration, workloadName string) string {
+	return fmt.Sprintf("%s-tf-worker-%s", workloadName, utils.GetObjectHash(migration.UID))
+}
+
+// recordTargetWorker records the new worker in migration status and waits for it to become ready
+func (r *WorkerMigrationReconciler) recordTargetWorker(
+	ctx context.Context,
+	migration *tfv1.WorkerMigration,
+	workloadName string,
+	sourceGPUs []string,
+	target *corev1.Pod,
+) (ctrl.Result, error) {
+	now := metav1.Now()
+	migration.Status.WorkloadName = workloadName
+	migration.Status.SourceGPUs = sourceGPUs
+	migration.Status.TargetWorkerName = target.Name
+	migration.Status.TargetGPUs = strings.Split(target.Annotations[constants.GpuKey], ",")
+	migration.Status.StartTime = &now
+	if err := r.setPhase(ctx, migration, tfv1.WorkerMigrationStartingWorker,
+		fmt.Sprintf("starting worker %s on GPUs %s", target.Name, strings.Join(migration.Status.TargetGPUs, ","))); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: constants.StatusCheckInterval}, nil
+}
+
+// unmovableReason returns why the worker can not be migrated, empty when it can
+func unmovableReason(pod *corev1.Pod) string {
+	switch {
+	case pod.Labels[constants.LabelComponent] != constants.ComponentWorker:
+		return "pod is not a worker"
+	case !pod.DeletionTimestamp.IsZero():
+		return "worker is being deleted"
+	case frozenWorkerPhase(pod) != "":
+		return "worker is frozen"
+	case pod.Labels[constants.WorkerReplicaLabel] != "":
+		return "workers of a cross-node replica can not be migrated alone"
+	default:
+		return ""
+	}
+}
+
+// allocTargetGPUs allocates the target GPUs of the spec, or GPUs other than the source and excluded ones when not specified
+func (r *WorkerMigrationReconciler) allocTargetGPUs(
+	ctx context.Context,
+	migration *tfv1.WorkerMigration,
+	req gpuallocator.AllocRequest,
+	sourceGPUs []string,
+) ([]*tfv1.GPU, error) {
+	if len(migration.Spec.TargetGPUs) > 0 {
+		if len(migration.Spec.TargetGPUs) != int(req.Count) {
+			return nil, fmt.Errorf("workload requires %d GPUs, got %d target GPUs", req.Count, len(migration.Spec.TargetGPUs))
+		}
+		return r.Allocator.AllocOnGPUs(ctx, req, lo.Map(migration.Spec.TargetGPUs, func(name string, _ int) types.NamespacedName {
+			return types.NamespacedName{Name: name}
+		}))
+	}
+
+	excludedUUIDs := make([]string, 0, len(sourceGPUs)+len(migration.Spec.ExcludedGPUs))
+	for _, name := range lo.Uniq(append(slices.Clone(sourceGPUs), migration.Spec.ExcludedGPUs...)) {
+		gpu := &tfv1.GPU{}
+		if err := r.Get(ctx, client.ObjectKey{Name: name}, gpu); err != nil {
+			if errors.IsNotFound(err) && !slices.Contains(sourceGPUs, name) {
+				continue
+			}
+			return nil, fmt.Errorf("get excluded gpu %s: %w", name, err)
+		}
+		excludedUUIDs = append(excludedUUIDs, gpu.Status.UUID)
+	}
+	filters := []filter.GPUFilter{filter.NewExcludeUUIDsFilter(excludedUUIDs)}
+	if migration.Spec.TargetNode != "" {
+		filters = append(filters, filter.NewMatchLabelsFilter(map[string]string{constants.LabelKeyOwner: migration.Spec.TargetNode}))
+	}
+	return r.Allocator.AllocWithFilters(ctx, req, filters...)
+}
+
+// waitTargetWorker waits for the new worker to become ready, rolls back when it fails or times out
+func (r *WorkerMigrationReconciler) waitTargetWorker(ctx context.Context, migration *tfv1.WorkerMigration) (ctrl.Result, error) {
+	target, err := r.getWorker(ctx, migration.Namespace, migration.Status.TargetWorkerName)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	switch {
+	case target == nil || !target.DeletionTimestamp.IsZero():
+		return ctrl.Result{}, r.setPhase(ctx, migration, tfv1.WorkerMigrationRollingBack, "new worker is deleted")
+	case target.Status.Phase == corev1.PodFailed:
+		return ctrl.Result{}, r.setPhase(ctx, migration, tfv1.WorkerMigrationRollingBack, "new worker failed")
+	case target.Status.Phase == corev1.PodRunning && utils.IsPodConditionTrue(target.Status.Conditions, corev1.PodReady):
+		return ctrl.Result{}, r.setPhase(ctx, migration, tfv1.WorkerMigrationSwitchingConnections,
+			fmt.Sprintf("new worker %s is ready", target.Name))
+	}
+
+	startTimeout, err := parseMigrationTimeout(migration.Spec.StartTimeout, defaultMigrationStartTimeout)
+	if err != nil {
+		return ctrl.Result{}, r.setPhase(ctx, migration, tfv1.WorkerMigrationRollingBack, err.Error())
+	}
+	if time.Since(migration.Status.StartTime.Time) > startTimeout {
+		return ctrl.Result{}, r.setPhase(ctx, migration, tfv1.WorkerMigrationRollingBack,
+			fmt.Sprintf("new worker is not ready within %s", startTimeout))
+	}
+	return ctrl.Result{RequeueAfter: constants.StatusCheckInterval}, nil
+}
+
+// switchConnections points connections of the old worker to the new one
+func (r *WorkerMigrationReconciler) switchConnections(ctx context.Context, migration *tfv1.WorkerMigration) (ctrl.Result, error) {
+	switched, err := r.moveConnections(ctx, migration, migration.Spec.WorkerName, migration.Status.TargetWorkerName)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if switched == nil {
+		// status of the new worker is not reported by the workload yet
+		return ctrl.Result{RequeueAfter: constants.PendingRequeueDuration}, nil
+	}
+	migration.Status.SwitchedConnections = lo.Uniq(append(migration.Status.SwitchedConnections, switched...))
+	if err := r.setPhase(ctx, migration, tfv1.WorkerMigrationDraining,
+		fmt.Sprintf("switched %d connections to new worker", len(switched))); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: constants.PendingRequeueDuration}, nil
+}
+
+// drainSourceWorker deletes the old worker once drain timeout passes, its GPUs are released by finalizer
+func (r *WorkerMigrationReconciler) drainSourceWorker(ctx context.Context, migration *tfv1.WorkerMigration) (ctrl.Result, error) {
+	drainTimeout, err := parseMigrationTimeout(migration.Spec.DrainTimeout, defaultMigrationDrainTimeout)
+	if err != nil {
+		drainTimeout = defaultMigrationDrainTimeout
+	}
+	if remaining := drainTimeout - time.Since(migration.Status.LastTransitionTime.Time); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	// connections selected the old worker while switching are moved as well
+	switched, err := r.moveConnections(ctx, migration, migration.Spec.WorkerName, migration.Status.TargetWorkerName)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if switched == nil {
+		return ctrl.Result{RequeueAfter: constants.PendingRequeueDuration}, nil
+	}
+	migration.Status.SwitchedConnections = lo.Uniq(append(migration.Status.SwitchedConnections, switched...))
+
+	source, err := r.getWorker(ctx, migration.Namespace, migration.Spec.WorkerName)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if source != nil && source.DeletionTimestamp.IsZero() {
+		if err := r.Delete(ctx, source); err != nil && !errors.IsNotFound(err) {
+			return ctrl.Result{}, fmt.Errorf("delete old worker: %w", err)
+		}
+	}
+
+	// the new worker is an ordinary worker of the workload from now on, and can be migrated again
+	target, err := r.getWorker(ctx, migration.Namespace, migration.Status.TargetWorkerName)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if target != nil {
+		if _, ok := target.Annotations[constants.MigrationSourceAnnotation]; ok {
+			patch := client.MergeFrom(target.DeepCopy())
+			delete(target.Annotations, constants.MigrationSourceAnnotation)
+			if err := r.Patch(ctx, target, patch); err != nil && !errors.IsNotFound(err) {
+				return ctrl.Result{}, fmt.Errorf("remove migration source of new worker: %w", err)
+			}
+		}
+	}
+	return ctrl.Result{}, r.setPhase(ctx, migration, tfv1.WorkerMigrationSucceeded,
+		fmt.Sprintf("worker migrated to %s", migration.Status.TargetWorkerName))
+}
+
+// rollBack switches connections back to the old worker and deletes the new worker
+func (r *WorkerMigrationReconciler) rollBack(ctx context.Context, migration *tfv1.WorkerMigration) (ctrl.Result, error) {
+	if err := r.revert(ctx, migration); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, r.setPhase(ctx, migration, tfv1.WorkerMigrationFailed, migration.Status.Message)
+}
+
+func (r *WorkerMigrationReconciler) revert(ctx context.Context, migration *tfv1.WorkerMigration) error {
+	if migration.Status.TargetWorkerName == "" {
+		return nil
+	}
+	if len(migration.Status.SwitchedConnections) > 0 {
+		// when the old worker is gone, connections select another worker by themselves
+		if _, err := r.moveConnections(ctx, migration, migration.Status.TargetWorkerName, migration.Spec.WorkerName); err != nil {
+			return err
+		}
+	}
+	target, err := r.getWorker(ctx, migration.Namespace, migration.Status.TargetWorkerName)
+	if err != nil {
+		return err
+	}
+	if target != nil && target.DeletionTimestamp.IsZero() {
+		if err := r.Delete(ctx, target); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("delete new worker: %w", err)
+		}
+	}
+	return nil
+}
+
+// moveConnections switches connections of the workload from one worker to another with the worker's
+// connection URL, returns nil when the destination worker is not reported in workload status yet
+func (r *WorkerMigrationReconciler) moveConnections(ctx context.Context, migration *tfv1.WorkerMigration, from, to string) ([]string, error) {
+	workload := &tfv1.TensorFusionWorkload{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: migration.Namespace, Name: migration.Status.WorkloadName}, workload); err != nil {
+		return nil, fmt.Errorf("get workload: %w", err)
+	}
+	toStatus, ok := lo.Find(workload.Status.WorkerStatuses, func(status tfv1.WorkerStatus) bool {
+		return status.WorkerName == to
+	})
+	if !ok {
+		return nil, nil
+	}
+
+	connections := &tfv1.TensorFusionConnectionList{}
+	if err := r.List(ctx, connections,
+		client.InNamespace(migration.Namespace),
+		client.MatchingLabels{constants.WorkloadKey: workload.Name}); err != nil {
+		return nil, fmt.Errorf("list connections: %w", err)
+	}
+	switched := []string{}
+	for i := range connections.Items {
+		connection := &connections.Items[i]
+		if connection.Status.WorkerName != from {
+			continue
+		}
+		connection.Status.WorkerName = to
+		connection.Status.Phase = toStatus.WorkerPhase
+		connection.Status.ConnectionURL = connectionURL([]tfv1.WorkerStatus{toStatus})
+		if err := r.Status().Update(ctx, connection); err != nil {
+			return nil, fmt.Errorf("update connection %s: %w", connection.Name, err)
+		}
+		r.Recorder.Eventf(connection, corev1.EventTypeNormal, "WorkerSwitched", "Connection switched from worker %s to %s", from, to)
+		switched = append(switched, connection.Name)
+	}
+	return switched, nil
+}
+
+func (r *WorkerMigrationReconciler) getWorker(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+	pod := &corev1.Pod{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, pod); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get worker %s: %w", name, err)
+	}
+	return pod, nil
+}
+
+func (r *WorkerMigrationReconciler) setPhase(ctx context.Context, migration *tfv1.WorkerMigration, phase tfv1.WorkerMigrationPhase, message string) error {
+	now := metav1.Now()
+	migration.Status.Phase = phase
+	migration.Status.Message = message
+	migration.Status.LastTransitionTime = &now
+	if isMigrationFinished(migration) {
+		migration.Status.CompletionTime = &now
+	}
+	if err := r.Status().Update(ctx, migration); err != nil {
+		return fmt.Errorf("update migration status: %w", err)
+	}
+
+	eventType := corev1.EventTypeNormal
+	if phase == tfv1.WorkerMigrationRollingBack || phase == tfv1.WorkerMigrationFailed {
+		eventType = corev1.EventTypeWarning
+	}
+	r.Recorder.Eventf(migration, eventType, string(phase), "Worker %s: %s", migration.Spec.WorkerName, message)
+	log.FromContext(ctx).Info("worker migration phase changed", "migration", migration.Name, "phase", phase, "message", message)
+	return nil
+}
+
+func isMigrationFinished(migration *tfv1.WorkerMigration) bool {
+	return migration.Status.Phase == tfv1.WorkerMigrationSucceeded || migration.Status.Phase == tfv1.WorkerMigrationFailed
+}
+
+// isMigrationTarget returns whether the pod is the new worker of a migration whose old worker is still running
+func isMigrationTarget(pod *corev1.Pod, pods []corev1.Pod) bool {
+	source, ok := pod.Annotations[constants.MigrationSourceAnnotation]
+	if !ok {
+		return false
+	}
+	return slices.ContainsFunc(pods, func(other corev1.Pod) bool {
+		return other.Name == source
+	})
+}
+
+func parseMigrationTimeout(value string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q: %w", value, err)
+	}
+	return timeout, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *WorkerMigrationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&tfv1.WorkerMigration{}).
+		Named("workermigration").
+		Complete(r)
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+)
+
+var _ = Describe("WorkerMigration Controller", func() {
+	var tfEnv *TensorFusionEnv
+	key := client.ObjectKey{Name: "mock", Namespace: "default"}
+	migrationKey := client.ObjectKey{Name: "test-migration", Namespace: "default"}
+
+	BeforeEach(func() {
+		tfEnv = NewTensorFusionEnvBuilder().
+			AddPoolWithNodeCount(1).SetGpuCountPerNode(2).
+			Build()
+	})
+	AfterEach(func() {
+		migration := &tfv1.WorkerMigration{}
+		if err := k8sClient.Get(ctx, migrationKey, migration); err == nil {
+			Expect(k8sClient.Delete(ctx, migration)).To(Succeed())
+		}
+		cleanupWorkload(key)
+		tfEnv.Cleanup()
+	})
+
+	It("should move the worker to other GPUs", func() {
+		workload := createTensorFusionWorkload(tfEnv.GetGPUPool(0).Name, key, 1)
+		checkWorkerPodCount(workload)
+		source := getWorkerPods(key)[0]
+
+		createWorkerMigration(migrationKey, source.Name, "")
+
+		By("starting the new worker on other GPUs without counting it as a replica")
+		var target corev1.Pod
+		Eventually(func(g Gomega) {
+			pods := getWorkerPods(key)
+			g.Expect(pods).Should(HaveLen(2))
+			for _, pod := range pods {
+				if pod.Annotations[constants.MigrationSourceAnnotation] == source.Name {
+					target = pod
+				}
+			}
+			g.Expect(target.Name).ShouldNot(BeEmpty())
+			// named after the migration so that it is started only once
+			migration := &tfv1.WorkerMigration{}
+			g.Expect(k8sClient.Get(ctx, migrationKey, migration)).Should(Succeed())
+			g.Expect(target.Name).Should(Equal(migrationTargetName(migration, workload.Name)))
+			g.Expect(target.Annotations[constants.GpuKey]).ShouldNot(Equal(source.Annotations[constants.GpuKey]))
+		}).Should(Succeed())
+		checkWorkloadStatus(workload)
+
+		By("deleting the old worker once the new worker is ready")
+		target.Status.Phase = corev1.PodRunning
+		target.Status.Conditions = append(target.Status.Conditions, corev1.PodCondition{Type: corev1.PodReady, Status: corev1.ConditionTrue})
+		Expect(k8sClient.Status().Update(ctx, &target)).Should(Succeed())
+
+		Eventually(func(g Gomega) {
+			migration := &tfv1.WorkerMigration{}
+			g.Expect(k8sClient.Get(ctx, migrationKey, migration)).Should(Succeed())
+			g.Expect(migration.Status.Phase).Should(Equal(tfv1.WorkerMigrationSucceeded))
+			g.Expect(migration.Status.TargetWorkerName).Should(Equal(target.Name))
+
+			pods := getWorkerPods(key)
+			g.Expect(pods).Should(HaveLen(1))
+			g.Expect(pods[0].Name).Should(Equal(target.Name))
+			g.Expect(pods[0].Annotations).ShouldNot(HaveKey(constants.MigrationSourceAnnotation))
+		}).Should(Succeed())
+	})
+
+	It("should roll back when the new worker is not ready in time", func() {
+		workload := createTensorFusionWorkload(tfEnv.GetGPUPool(0).Name, key, 1)
+		checkWorkerPodCount(workload)
+		source := getWorkerPods(key)[0]
+
+		createWorkerMigration(migrationKey, source.Name, "1s")
+
+		Eventually(func(g Gomega) {
+			migration := &tfv1.WorkerMigration{}
+			g.Expect(k8sClient.Get(ctx, migrationKey, migration)).Should(Succeed())
+			g.Expect(migration.Status.Phase).Should(Equal(tfv1.WorkerMigrationFailed))
+			g.Expect(migration.Status.Message).Should(ContainSubstring("not ready"))
+
+			pods := getWorkerPods(key)
+			g.Expect(pods).Should(HaveLen(1))
+			g.Expect(pods[0].Name).Should(Equal(source.Name))
+		}).Should(Succeed())
+	})
+})
+
+func createWorkerMigration(key client.ObjectKey, workerName string, startTimeout string) {
+	GinkgoHelper()
+	migration := &tfv1.WorkerMigration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+		},
+		Spec: tfv1.WorkerMigrationSpec{
+			WorkerName:   workerName,
+			StartTimeout: startTimeout,
+			DrainTimeout: "0s",
+		},
+	}
+	Expect(k8sClient.Create(ctx, migration)).To(Succeed())
+}
+
+func getWorkerPods(workloadKey client.ObjectKey) []corev1.Pod {
+	GinkgoHelper()
+	podList := &corev1.PodList{}
+	Expect(k8sClient.List(ctx, podList,
+		client.InNamespace(workloadKey.Namespace),
+		client.MatchingLabels{constants.WorkloadKey: workloadKey.Name})).Should(Succeed())
+	return podList.Items
+}
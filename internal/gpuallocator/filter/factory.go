@@ -0,0 +1,67 @@
+package filter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// FilterFactory creates a GPUFilter from the params of a SchedulingConfigTemplate GPU filter
+type FilterFactory func(params runtime.RawExtension) (GPUFilter, error)
+
+var (
+	factoriesLock sync.RWMutex
+	factories     = map[string]FilterFactory{
+		AvoidTooManyLocalProcessesFilterType: newAvoidTooManyLocalProcessesFilter,
+		TemperatureCeilingFilterType:         newTemperatureCeilingFilter,
+		MatchLabelsFilterType:                newMatchLabelsFilter,
+		ExcludeUUIDsFilterType:               newExcludeUUIDsFilter,
+	}
+)
+
+// RegisterFilterFactory registers a factory for the given filter type,
+// an existing factory with the same type will be replaced
+func RegisterFilterFactory(filterType string, factory FilterFactory) {
+	factoriesLock.Lock()
+	defer factoriesLock.Unlock()
+	factories[filterType] = factory
+}
+
+// NewFiltersFromConfig converts the GPU filters declared in a SchedulingConfigTemplate
+// into GPUFilter instances, keeping the declared order
+func NewFiltersFromConfig(configs []tfv1.GPUFilter) ([]GPUFilter, error) {
+	factoriesLock.RLock()
+	defer factoriesLock.RUnlock()
+
+	filters := make([]GPUFilter, 0, len(configs))
+	for i, config := range configs {
+		factory, ok := factories[config.Type]
+		if !ok {
+			return nil, fmt.Errorf("gpu filter #%d: unknown filter type %q", i, config.Type)
+		}
+		f, err := factory(config.Params)
+		if err != nil {
+			return nil, fmt.Errorf("gpu filter #%d (%s): %w", i, config.Type, err)
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+// decodeParams decodes the raw params into out, unknown fields are rejected
+// so that typos in the template surface as errors instead of being ignored
+func decodeParams(params runtime.RawExtension, out any) error {
+	if len(params.Raw) == 0 {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(params.Raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(out); err != nil {
+		return fmt.Errorf("decode params: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package filter
+
+import (
+	"context"
+	"testing"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+)
+
+func TestNewFiltersFromConfig(t *testing.T) {
+	gpus := []tfv1.GPU{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "gpu-1",
+				Labels: map[string]string{"zone": "a"},
+			},
+			Status: tfv1.GPUStatus{
+				UUID:        "GPU-1",
+				Temperature: ptr.To(int32(60)),
+				RunningApps: []*tfv1.RunningAppDetail{{Name: "app", Namespace: "default", Count: 3}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "gpu-2",
+				Labels: map[string]string{"zone": "b"},
+			},
+			Status: tfv1.GPUStatus{
+				UUID:        "gpu-2",
+				Temperature: ptr.To(int32(90)),
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "gpu-3",
+				Labels: map[string]string{"zone": "a"},
+			},
+			Status: tfv1.GPUStatus{
+				UUID: "gpu-3",
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		configs []tfv1.GPUFilter
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "avoid too many local processes",
+			configs: []tfv1.GPUFilter{
+				{Type: AvoidTooManyLocalProcessesFilterType, Params: runtime.RawExtension{Raw: []byte(`{"maxProcesses":3}`)}},
+			},
+			want: []string{"gpu-2", "gpu-3"},
+		},
+		{
+			name: "temperature ceiling keeps gpus without temperature",
+			configs: []tfv1.GPUFilter{
+				{Type: TemperatureCeilingFilterType, Params: runtime.RawExtension{Raw: []byte(`{"maxTemperature":85}`)}},
+			},
+			want: []string{"gpu-1", "gpu-3"},
+		},
+		{
+			name: "match labels and exclude uuids in order",
+			configs: []tfv1.GPUFilter{
+				{Type: MatchLabelsFilterType, Params: runtime.RawExtension{Raw: []byte(`{"labels":{"zone":"a"}}`)}},
+				{Type: ExcludeUUIDsFilterType, Params: runtime.RawExtension{Raw: []byte(`{"uuids":["gpu-1"]}`)}},
+			},
+			want: []string{"gpu-3"},
+		},
+		{
+			name: "unknown filter type",
+			configs: []tfv1.GPUFilter{
+				{Type: "notExist"},
+			},
+			wantErr: `unknown filter type "notExist"`,
+		},
+		{
+			name: "unknown params field",
+			configs: []tfv1.GPUFilter{
+				{Type: ExcludeUUIDsFilterType, Params: runtime.RawExtension{Raw: []byte(`{"uuid":["gpu-1"]}`)}},
+			},
+			wantErr: "decode params",
+		},
+		{
+			name: "missing required params",
+			configs: []tfv1.GPUFilter{
+				{Type: AvoidTooManyLocalProcessesFilterType},
+			},
+			wantErr: "maxProcesses must be greater than 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters, err := NewFiltersFromConfig(tt.configs)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			result, err := NewFilterRegistry().With(filters...).Apply(context.Background(), gpus)
+			require.NoError(t, err)
+			names := make([]string, 0, len(result))
+			for _, gpu := range result {
+				names = append(names, gpu.Name)
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
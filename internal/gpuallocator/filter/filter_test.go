@@ -104,6 +104,41 @@ func TestFilters(t *testing.T) {
 		assert.ElementsMatch(t, []string{"gpu-1", "gpu-3"}, []string{result[0].Name, result[1].Name})
 	})
 
+	t.Run("OversoldResourceFilter", func(t *testing.T) {
+		oversoldGPUs := []tfv1.GPU{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "gpu-oversold"},
+				Status: tfv1.GPUStatus{
+					Available: &tfv1.Resource{
+						Tflops: resource.MustParse("2"),
+						Vram:   resource.MustParse("4Gi"),
+					},
+					VirtualAvailable: &tfv1.Resource{
+						Tflops: resource.MustParse("12"),
+						Vram:   resource.MustParse("40Gi"),
+					},
+				},
+			},
+		}
+		required := tfv1.Resource{
+			Tflops: resource.MustParse("8"),
+			Vram:   resource.MustParse("30Gi"),
+		}
+
+		result, err := NewResourceFilter(required).Filter(ctx, oversoldGPUs)
+		assert.NoError(t, err)
+		assert.Empty(t, result)
+
+		result, err = NewOversoldResourceFilter(required).Filter(ctx, oversoldGPUs)
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+
+		// Falls back to physical available when virtual capacity is not calculated
+		result, err = NewOversoldResourceFilter(required).Filter(ctx, gpus)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"gpu-1", "gpu-3"}, []string{result[0].Name, result[1].Name})
+	})
+
 	t.Run("FilterRegistry with multiple filters", func(t *testing.T) {
 		// Create registry and chain filters with With method
 		registry := NewFilterRegistry().
@@ -0,0 +1,50 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const MatchLabelsFilterType = "matchLabels"
+
+type matchLabelsParams struct {
+	Labels map[string]string `json:"labels"`
+}
+
+// MatchLabelsFilter filters GPUs based on their labels
+type MatchLabelsFilter struct {
+	selector labels.Selector
+}
+
+// NewMatchLabelsFilter creates a new filter that only keeps GPUs carrying all the given labels
+func NewMatchLabelsFilter(matchLabels map[string]string) *MatchLabelsFilter {
+	return &MatchLabelsFilter{
+		selector: labels.SelectorFromSet(matchLabels),
+	}
+}
+
+func newMatchLabelsFilter(raw runtime.RawExtension) (GPUFilter, error) {
+	params := matchLabelsParams{}
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	if len(params.Labels) == 0 {
+		return nil, fmt.Errorf("labels must not be empty")
+	}
+	return NewMatchLabelsFilter(params.Labels), nil
+}
+
+// Filter implements GPUFilter.Filter
+func (f *MatchLabelsFilter) Filter(_ context.Context, gpus []tfv1.GPU) ([]tfv1.GPU, error) {
+	var filtered []tfv1.GPU
+	for _, gpu := range gpus {
+		if f.selector.Matches(labels.Set(gpu.Labels)) {
+			filtered = append(filtered, gpu)
+		}
+	}
+	return filtered, nil
+}
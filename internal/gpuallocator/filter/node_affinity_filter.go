@@ -16,6 +16,9 @@ type NodeAffinityFilter struct {
 	client       client.Reader
 	nodeSelector *corev1.NodeSelector
 	preferred    []corev1.PreferredSchedulingTerm
+
+	// scores of nodes matching the required terms, set by Resolve
+	nodeScores map[string]int32
 }
 
 type gpuScore struct {
@@ -31,11 +34,44 @@ func NewNodeAffinityFilter(c client.Reader, nodeAffinity *corev1.NodeAffinity) *
 	}
 }
 
+// Resolve matches all nodes against the affinity once, so that Filter no longer reads nodes
+// and can be applied while the allocator store is locked
+func (f *NodeAffinityFilter) Resolve(ctx context.Context) error {
+	nodes := &corev1.NodeList{}
+	if err := f.client.List(ctx, nodes); err != nil {
+		return fmt.Errorf("list nodes: %w", err)
+	}
+
+	nodeScores := make(map[string]int32, len(nodes.Items))
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if f.nodeSelector != nil {
+			matches, err := schedulingcorev1.MatchNodeSelectorTerms(node, f.nodeSelector)
+			if err != nil {
+				return fmt.Errorf("match node selector terms: %w", err)
+			}
+			if !matches {
+				continue
+			}
+		}
+		score, err := f.preferredScore(node)
+		if err != nil {
+			return err
+		}
+		nodeScores[node.Name] = score
+	}
+	f.nodeScores = nodeScores
+	return nil
+}
+
 // Filter
 func (f *NodeAffinityFilter) Filter(ctx context.Context, gpus []tfv1.GPU) ([]tfv1.GPU, error) {
 	if f.nodeSelector == nil && len(f.preferred) == 0 {
 		return gpus, nil
 	}
+	if f.nodeScores != nil {
+		return f.filterResolved(gpus), nil
+	}
 
 	// requiredDuringSchedulingIgnoredDuringExecution
 	if f.nodeSelector != nil {
@@ -106,19 +142,9 @@ func (f *NodeAffinityFilter) filterPreferred(ctx context.Context, gpus []tfv1.GP
 		}
 		node := fetched
 
-		var totalScore int32
-		// Only match the preferred node selector terms
-		// If not match, the score is 0, that means if a lot of GPU not match the score will all be 0
-		for _, term := range f.preferred {
-			matches, err := schedulingcorev1.MatchNodeSelectorTerms(node, &corev1.NodeSelector{
-				NodeSelectorTerms: []corev1.NodeSelectorTerm{term.Preference},
-			})
-			if err != nil {
-				return nil, fmt.Errorf("match preferred node selector terms: %w", err)
-			}
-			if matches {
-				totalScore += term.Weight
-			}
+		totalScore, err := f.preferredScore(node)
+		if err != nil {
+			return nil, err
 		}
 
 		gpuScores = append(gpuScores, gpuScore{
@@ -139,3 +165,44 @@ func (f *NodeAffinityFilter) filterPreferred(ctx context.Context, gpus []tfv1.GP
 	}
 	return result, nil
 }
+
+// filterResolved keeps GPUs on nodes matched by Resolve, sorted by score of their nodes
+func (f *NodeAffinityFilter) filterResolved(gpus []tfv1.GPU) []tfv1.GPU {
+	gpuScores := make([]gpuScore, 0, len(gpus))
+	for _, gpu := range gpus {
+		score, matched := f.nodeScores[gpu.Labels[constants.LabelKeyOwner]]
+		if !matched {
+			continue
+		}
+		gpuScores = append(gpuScores, gpuScore{gpu: gpu, score: score})
+	}
+
+	sort.SliceStable(gpuScores, func(i, j int) bool {
+		return gpuScores[i].score > gpuScores[j].score
+	})
+
+	result := make([]tfv1.GPU, len(gpuScores))
+	for i, score := range gpuScores {
+		result[i] = score.gpu
+	}
+	return result
+}
+
+// preferredScore sums weights of the preferred terms matched by the node
+func (f *NodeAffinityFilter) preferredScore(node *corev1.Node) (int32, error) {
+	var totalScore int32
+	// Only match the preferred node selector terms
+	// If not match, the score is 0, that means if a lot of GPU not match the score will all be 0
+	for _, term := range f.preferred {
+		matches, err := schedulingcorev1.MatchNodeSelectorTerms(node, &corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{term.Preference},
+		})
+		if err != nil {
+			return 0, fmt.Errorf("match preferred node selector terms: %w", err)
+		}
+		if matches {
+			totalScore += term.Weight
+		}
+	}
+	return totalScore, nil
+}
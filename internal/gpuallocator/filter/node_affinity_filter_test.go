@@ -264,6 +264,22 @@ func TestNodeAffinityFilter(t *testing.T) {
 			assert.NoError(t, err)
 			assert.Len(t, got, tt.want)
 
+			// resolved nodes give the same result without reading nodes again
+			resolved := NewNodeAffinityFilter(fakeClient, &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution:  tt.nodeSelector,
+				PreferredDuringSchedulingIgnoredDuringExecution: tt.preferred,
+			})
+			assert.NoError(t, resolved.Resolve(context.Background()))
+			for _, obj := range objs {
+				assert.NoError(t, fakeClient.Delete(context.Background(), obj.(*corev1.Node)))
+			}
+			gotResolved, err := resolved.Filter(context.Background(), tt.gpus)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, gpuNames(got), gpuNames(gotResolved))
+			for i := 1; i < len(gotResolved); i++ {
+				assert.LessOrEqual(t, calculateScore(gotResolved[i], tt.preferred), calculateScore(gotResolved[i-1], tt.preferred))
+			}
+
 			// For soft affinity requirements, verify sorting
 			if len(tt.preferred) > 0 {
 				// Get the score of the first GPU
@@ -298,3 +314,11 @@ func calculateScore(gpu tfv1.GPU, preferred []corev1.PreferredSchedulingTerm) in
 	}
 	return totalScore
 }
+
+func gpuNames(gpus []tfv1.GPU) []string {
+	names := make([]string, len(gpus))
+	for i, gpu := range gpus {
+		names[i] = gpu.Name
+	}
+	return names
+}
@@ -0,0 +1,56 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const AvoidTooManyLocalProcessesFilterType = "avoidTooManyLocalProcesses"
+
+type avoidTooManyLocalProcessesParams struct {
+	MaxProcesses int `json:"maxProcesses"`
+}
+
+// AvoidTooManyLocalProcessesFilter filters out GPUs which already run too many worker processes
+type AvoidTooManyLocalProcessesFilter struct {
+	maxProcesses int
+}
+
+// NewAvoidTooManyLocalProcessesFilter creates a new filter that only keeps GPUs
+// running less than maxProcesses workers
+func NewAvoidTooManyLocalProcessesFilter(maxProcesses int) *AvoidTooManyLocalProcessesFilter {
+	return &AvoidTooManyLocalProcessesFilter{
+		maxProcesses: maxProcesses,
+	}
+}
+
+func newAvoidTooManyLocalProcessesFilter(raw runtime.RawExtension) (GPUFilter, error) {
+	params := avoidTooManyLocalProcessesParams{}
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	if params.MaxProcesses <= 0 {
+		return nil, fmt.Errorf("maxProcesses must be greater than 0, got %d", params.MaxProcesses)
+	}
+	return NewAvoidTooManyLocalProcessesFilter(params.MaxProcesses), nil
+}
+
+// Filter implements GPUFilter.Filter
+func (f *AvoidTooManyLocalProcessesFilter) Filter(_ context.Context, gpus []tfv1.GPU) ([]tfv1.GPU, error) {
+	var filtered []tfv1.GPU
+	for _, gpu := range gpus {
+		processes := 0
+		for _, app := range gpu.Status.RunningApps {
+			if app != nil {
+				processes += app.Count
+			}
+		}
+		if processes < f.maxProcesses {
+			filtered = append(filtered, gpu)
+		}
+	}
+	return filtered, nil
+}
@@ -10,6 +10,8 @@ import (
 // ResourceFilter filters GPUs based on available resources
 type ResourceFilter struct {
 	requiredResource tfv1.Resource
+	// check against VirtualAvailable instead of physical Available
+	allowOversold bool
 }
 
 // NewResourceFilter creates a new ResourceFilter with the specified resource requirements
@@ -19,17 +21,31 @@ func NewResourceFilter(required tfv1.Resource) *ResourceFilter {
 	}
 }
 
+// NewOversoldResourceFilter creates a new ResourceFilter which admits requests against
+// the oversold virtual capacity, falls back to physical Available when it's not calculated yet
+func NewOversoldResourceFilter(required tfv1.Resource) *ResourceFilter {
+	return &ResourceFilter{
+		requiredResource: required,
+		allowOversold:    true,
+	}
+}
+
 // Filter implements GPUFilter.Filter
 func (f *ResourceFilter) Filter(_ context.Context, gpus []tfv1.GPU) ([]tfv1.GPU, error) {
 	return lo.Filter(gpus, func(gpu tfv1.GPU, _ int) bool {
+		available := gpu.Status.Available
+		if f.allowOversold && gpu.Status.VirtualAvailable != nil {
+			available = gpu.Status.VirtualAvailable
+		}
+
 		// Check if GPU has enough resources available
-		if gpu.Status.Available == nil {
+		if available == nil {
 			return false
 		}
 
 		// Check TFlops and VRAM availability
-		hasTflops := gpu.Status.Available.Tflops.Cmp(f.requiredResource.Tflops) >= 0
-		hasVram := gpu.Status.Available.Vram.Cmp(f.requiredResource.Vram) >= 0
+		hasTflops := available.Tflops.Cmp(f.requiredResource.Tflops) >= 0
+		hasVram := available.Vram.Cmp(f.requiredResource.Vram) >= 0
 
 		return hasTflops && hasVram
 	}), nil
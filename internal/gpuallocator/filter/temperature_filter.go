@@ -0,0 +1,51 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const TemperatureCeilingFilterType = "temperatureCeiling"
+
+type temperatureCeilingParams struct {
+	MaxTemperature int32 `json:"maxTemperature"`
+}
+
+// TemperatureCeilingFilter filters out GPUs hotter than the ceiling,
+// GPUs without a reported temperature are kept
+type TemperatureCeilingFilter struct {
+	maxTemperature int32
+}
+
+// NewTemperatureCeilingFilter creates a new filter that only keeps GPUs
+// whose temperature in Celsius does not exceed maxTemperature
+func NewTemperatureCeilingFilter(maxTemperature int32) *TemperatureCeilingFilter {
+	return &TemperatureCeilingFilter{
+		maxTemperature: maxTemperature,
+	}
+}
+
+func newTemperatureCeilingFilter(raw runtime.RawExtension) (GPUFilter, error) {
+	params := temperatureCeilingParams{}
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	if params.MaxTemperature <= 0 {
+		return nil, fmt.Errorf("maxTemperature must be greater than 0, got %d", params.MaxTemperature)
+	}
+	return NewTemperatureCeilingFilter(params.MaxTemperature), nil
+}
+
+// Filter implements GPUFilter.Filter
+func (f *TemperatureCeilingFilter) Filter(_ context.Context, gpus []tfv1.GPU) ([]tfv1.GPU, error) {
+	var filtered []tfv1.GPU
+	for _, gpu := range gpus {
+		if gpu.Status.Temperature == nil || *gpu.Status.Temperature <= f.maxTemperature {
+			filtered = append(filtered, gpu)
+		}
+	}
+	return filtered, nil
+}
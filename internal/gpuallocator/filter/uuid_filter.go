@@ -0,0 +1,54 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const ExcludeUUIDsFilterType = "excludeUUIDs"
+
+type excludeUUIDsParams struct {
+	UUIDs []string `json:"uuids"`
+}
+
+// ExcludeUUIDsFilter filters out GPUs with the given UUIDs
+type ExcludeUUIDsFilter struct {
+	uuids map[string]struct{}
+}
+
+// NewExcludeUUIDsFilter creates a new filter that drops GPUs whose UUID is in the list,
+// UUIDs are compared case-insensitively
+func NewExcludeUUIDsFilter(uuids []string) *ExcludeUUIDsFilter {
+	return &ExcludeUUIDsFilter{
+		uuids: lo.SliceToMap(uuids, func(uuid string) (string, struct{}) {
+			return strings.ToLower(uuid), struct{}{}
+		}),
+	}
+}
+
+func newExcludeUUIDsFilter(raw runtime.RawExtension) (GPUFilter, error) {
+	params := excludeUUIDsParams{}
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	if len(params.UUIDs) == 0 {
+		return nil, fmt.Errorf("uuids must not be empty")
+	}
+	return NewExcludeUUIDsFilter(params.UUIDs), nil
+}
+
+// Filter implements GPUFilter.Filter
+func (f *ExcludeUUIDsFilter) Filter(_ context.Context, gpus []tfv1.GPU) ([]tfv1.GPU, error) {
+	var filtered []tfv1.GPU
+	for _, gpu := range gpus {
+		if _, excluded := f.uuids[strings.ToLower(gpu.Status.UUID)]; !excluded {
+			filtered = append(filtered, gpu)
+		}
+	}
+	return filtered, nil
+}
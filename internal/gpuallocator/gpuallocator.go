@@ -3,6 +3,7 @@ package gpuallocator
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -13,12 +14,14 @@ import (
 	"github.com/NexusGPU/tensor-fusion/internal/gpuallocator/filter"
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
@@ -51,6 +54,14 @@ type GpuAllocator struct {
 	// Queue for tracking modified GPUs that need to be synced
 	dirtyQueue     map[types.NamespacedName]struct{}
 	dirtyQueueLock sync.Mutex
+
+	// Requests waiting for capacity of each pool and GPUs reserved for them, guarded by storeMutex
+	pendingQueues map[string][]*pendingRequest
+	reservations  map[tfv1.NameNamespace]*reservation
+	queueEvents   chan event.GenericEvent
+
+	// GPU resources consumed by each namespace from each pool, guarded by storeMutex
+	quotaUsage map[quotaUsageKey]*tfv1.GPUResourceQuotaAmounts
 }
 
 // AllocRequest encapsulates all parameters needed for GPU allocation
@@ -61,35 +72,225 @@ type AllocRequest struct {
 	WorkloadNameNamespace tfv1.NameNamespace
 	// Resource requirements for the allocation
 	Request tfv1.Resource
+	// Resource limits on each GPU, only counted by GPU resource quotas
+	Limit tfv1.Resource
 	// Number of GPUs to allocate
 	Count uint
 	// Specific GPU model to allocate, empty string means any model
 	GPUModel string
 	// Node affinity requirements
 	NodeAffinity *v1.NodeAffinity
+	// QoS level of the workload, decides whether oversold capacity can be used, empty means pool's default QoS
+	QoS tfv1.QoSLevel
+	// Allow GPUs to be spread over multiple nodes when Count > 1
+	CrossNode bool
+	// The client is placed on the node of the allocated GPUs, refused when the pool's
+	// scheduling config template does not allow using local GPU
+	LocalGPU bool
+}
+
+// NewAllocRequest returns the request to allocate GPUs for one replica of the workload
+func NewAllocRequest(workload *tfv1.TensorFusionWorkload) AllocRequest {
+	return AllocRequest{
+		PoolName:              workload.Spec.PoolName,
+		WorkloadNameNamespace: tfv1.NameNamespace{Namespace: workload.Namespace, Name: workload.Name},
+		Request:               workload.Spec.Resources.Requests,
+		Limit:                 workload.Spec.Resources.Limits,
+		Count:                 workload.Spec.GPUCount,
+		GPUModel:              workload.Spec.GPUModel,
+		NodeAffinity:          workload.Spec.NodeAffinity,
+		QoS:                   workload.Spec.Qos,
+		CrossNode:             workload.Spec.CrossNodeGPUs,
+		LocalGPU:              workload.Spec.IsLocalGPU,
+	}
 }
 
-// Alloc allocates a request to a gpu or multiple gpus from the same node.
+func (req AllocRequest) resources() tfv1.Resources {
+	return tfv1.Resources{Requests: req.Request, Limits: req.Limit}
+}
+
+// Alloc allocates a request to a gpu or multiple gpus from the same node,
+// GPUs can come from multiple nodes when CrossNode is set.
+// When no GPU fits, the request is queued in its pool and GPUs are reserved for it
+// once capacity is freed, the reservation is returned by the next Alloc of the workload.
 func (s *GpuAllocator) Alloc(ctx context.Context, req AllocRequest) ([]*tfv1.GPU, error) {
-	// Get GPUs from the pool using the in-memory store
-	poolGPUs := s.listGPUsFromPool(req.PoolName)
+	pool, filterRegistry, strategy, err := s.prepareAlloc(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	quotas, err := s.listQuotas(ctx, req.WorkloadNameNamespace.Namespace, req.PoolName)
+	if err != nil {
+		return nil, err
+	}
 
-	// Add SameNodeFilter if count > 1 to ensure GPUs are from the same node
-	filterRegistry := s.filterRegistry.With(filter.NewResourceFilter(req.Request))
+	s.storeMutex.Lock()
+	defer s.storeMutex.Unlock()
+
+	if reserved, ok := s.takeReservationLocked(req.WorkloadNameNamespace); ok {
+		if reserved.gangReplicas == 0 && reserved.matches(req) {
+			return reserved.replicaGPUs[0], nil
+		}
+		// request changed since it was queued, give the reserved GPUs back
+		s.deallocLocked(ctx, req.WorkloadNameNamespace, reserved.req.resources(), reserved.gpuKeys())
+	}
+
+	gpus, err := s.allocLocked(ctx, req, quotas, filterRegistry, strategy)
+	// freed capacity does not help a request beyond its quota, it is not queued
+	if errors.Is(err, ErrQuotaExceeded) {
+		s.dequeueLocked(req.WorkloadNameNamespace)
+		return nil, err
+	}
+	if err != nil {
+		s.enqueueLocked(&pendingRequest{
+			req:            req,
+			priority:       QoSPriority(pool, req.QoS),
+			enqueuedAt:     time.Now(),
+			filterRegistry: filterRegistry,
+			strategy:       strategy,
+			quotas:         quotas,
+		})
+		return nil, err
+	}
+	s.dequeueLocked(req.WorkloadNameNamespace)
+	return gpus, nil
+}
+
+// AllocGang allocates GPUs for all replicas of a request in an all-or-nothing manner,
+// when any replica can not be satisfied, replicas already allocated are rolled back.
+// Like Alloc, the gang is queued when capacity is short and GPUs of all replicas are reserved
+// at once when it fits, the reservation is returned by the next AllocGang of the workload.
+func (s *GpuAllocator) AllocGang(ctx context.Context, req AllocRequest, replicas int) ([][]*tfv1.GPU, error) {
+	pool, filterRegistry, strategy, err := s.prepareAlloc(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	quotas, err := s.listQuotas(ctx, req.WorkloadNameNamespace.Namespace, req.PoolName)
+	if err != nil {
+		return nil, err
+	}
+
+	s.storeMutex.Lock()
+	defer s.storeMutex.Unlock()
+
+	if reserved, ok := s.takeReservationLocked(req.WorkloadNameNamespace); ok {
+		if reserved.gangReplicas == replicas && reserved.matches(req) {
+			return reserved.replicaGPUs, nil
+		}
+		// request or the number of missing replicas changed since it was queued, give the reserved GPUs back
+		s.deallocLocked(ctx, req.WorkloadNameNamespace, reserved.req.resources(), reserved.gpuKeys())
+	}
+
+	result, err := s.allocGangLocked(ctx, req, replicas, quotas, filterRegistry, strategy)
+	if errors.Is(err, ErrQuotaExceeded) {
+		s.dequeueLocked(req.WorkloadNameNamespace)
+		return nil, err
+	}
+	if err != nil {
+		s.enqueueLocked(&pendingRequest{
+			req:            req,
+			priority:       QoSPriority(pool, req.QoS),
+			enqueuedAt:     time.Now(),
+			gangReplicas:   replicas,
+			filterRegistry: filterRegistry,
+			strategy:       strategy,
+			quotas:         quotas,
+		})
+		return nil, err
+	}
+	s.dequeueLocked(req.WorkloadNameNamespace)
+	return result, nil
+}
+
+// allocGangLocked allocates GPUs for all replicas or none of them
+func (s *GpuAllocator) allocGangLocked(
+	ctx context.Context, req AllocRequest, replicas int, quotas []tfv1.GPUResourceQuota,
+	filterRegistry *filter.FilterRegistry, strategy Strategy,
+) ([][]*tfv1.GPU, error) {
+	result := make([][]*tfv1.GPU, 0, replicas)
+	for i := range replicas {
+		gpus, err := s.allocLocked(ctx, req, quotas, filterRegistry, strategy)
+		if err == nil {
+			result = append(result, gpus)
+			continue
+		}
+
+		// capacity taken by the gang itself is given back, there is nothing new for the queue
+		for _, allocated := range result {
+			s.releaseLocked(ctx, req.WorkloadNameNamespace, req.resources(), lo.Map(allocated, func(gpu *tfv1.GPU, _ int) types.NamespacedName {
+				return client.ObjectKeyFromObject(gpu)
+			}))
+		}
+		return nil, fmt.Errorf("gang allocation failed at replica %d/%d: %w", i+1, replicas, err)
+	}
+	return result, nil
+}
+
+// prepareAlloc builds filters and placement strategy of the request from its pool and scheduling config template
+func (s *GpuAllocator) prepareAlloc(ctx context.Context, req AllocRequest) (*tfv1.GPUPool, *filter.FilterRegistry, Strategy, error) {
+	pool := &tfv1.GPUPool{}
+	if err := s.Get(ctx, client.ObjectKey{Name: req.PoolName}, pool); err != nil {
+		return nil, nil, nil, fmt.Errorf("get pool %s: %w", req.PoolName, err)
+	}
+
+	schedulingConfigTemplate := &tfv1.SchedulingConfigTemplate{}
+	if pool.Spec.SchedulingConfigTemplate != nil {
+		if err := s.Get(ctx, client.ObjectKey{Name: *pool.Spec.SchedulingConfigTemplate}, schedulingConfigTemplate); err != nil {
+			return nil, nil, nil, fmt.Errorf("get scheduling config template %s: %w", *pool.Spec.SchedulingConfigTemplate, err)
+		}
+	}
+	if err := checkLocalGPU(req, schedulingConfigTemplate); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Only low and medium QoS workloads can land on oversold capacity
+	var filterRegistry *filter.FilterRegistry
+	if canUseOversoldCapacity(resolveQoS(req.QoS, pool)) {
+		filterRegistry = s.filterRegistry.With(filter.NewOversoldResourceFilter(req.Request))
+	} else {
+		filterRegistry = s.filterRegistry.With(filter.NewResourceFilter(req.Request))
+	}
 
 	// Add GPU model filter if specified
 	if req.GPUModel != "" {
 		filterRegistry = filterRegistry.With(filter.NewGPUModelFilter(req.GPUModel))
 	}
 
-	if req.Count > 1 {
+	// Add filters declared in the pool's scheduling config template
+	templateFilters, err := filter.NewFiltersFromConfig(schedulingConfigTemplate.Spec.Placement.GPUFilters)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("build gpu filters of scheduling config template %s: %w", schedulingConfigTemplate.Name, err)
+	}
+	filterRegistry = filterRegistry.With(templateFilters...)
+
+	// Add SameNodeFilter if count > 1 to ensure GPUs are from the same node
+	if req.Count > 1 && !req.CrossNode {
 		filterRegistry = filterRegistry.With(filter.NewSameNodeFilter(req.Count))
 	}
-	// Add NodeAffinityFilter if specified
+	// Add NodeAffinityFilter if specified, nodes are resolved here since filters run with storeMutex held,
+	// including when queued requests are retried
 	if req.NodeAffinity != nil {
-		filterRegistry = filterRegistry.With(filter.NewNodeAffinityFilter(s.Client, req.NodeAffinity))
+		nodeAffinityFilter := filter.NewNodeAffinityFilter(s.Client, req.NodeAffinity)
+		if err := nodeAffinityFilter.Resolve(ctx); err != nil {
+			return nil, nil, nil, fmt.Errorf("resolve node affinity: %w", err)
+		}
+		filterRegistry = filterRegistry.With(nodeAffinityFilter)
 	}
 
+	return pool, filterRegistry, NewStrategy(schedulingConfigTemplate.Spec.Placement.Mode), nil
+}
+
+// allocLocked filters, selects and reserves GPUs for one replica, must be called with storeMutex held
+// so that the selection, the quota check and the reservation see the same store state
+func (s *GpuAllocator) allocLocked(
+	ctx context.Context,
+	req AllocRequest,
+	quotas []tfv1.GPUResourceQuota,
+	filterRegistry *filter.FilterRegistry,
+	strategy Strategy,
+) ([]*tfv1.GPU, error) {
+	// Get GPUs from the pool using the in-memory store
+	poolGPUs := s.listGPUsFromPoolLocked(req.PoolName)
+
 	// Apply the filters in sequence
 	filteredGPUs, err := filterRegistry.Apply(ctx, poolGPUs)
 	if err != nil {
@@ -100,28 +301,25 @@ func (s *GpuAllocator) Alloc(ctx context.Context, req AllocRequest) ([]*tfv1.GPU
 		return nil, fmt.Errorf("no gpus available in pool %s after filtering", req.PoolName)
 	}
 
-	pool := &tfv1.GPUPool{}
-	if err := s.Get(ctx, client.ObjectKey{Name: req.PoolName}, pool); err != nil {
-		return nil, fmt.Errorf("get pool %s: %w", req.PoolName, err)
-	}
-
-	schedulingConfigTemplate := &tfv1.SchedulingConfigTemplate{}
-	if pool.Spec.SchedulingConfigTemplate != nil {
-		if err := s.Get(ctx, client.ObjectKey{Name: *pool.Spec.SchedulingConfigTemplate}, schedulingConfigTemplate); err != nil {
-			return nil, fmt.Errorf("get scheduling config template %s: %w", *pool.Spec.SchedulingConfigTemplate, err)
-		}
+	var selectedGPUs []*tfv1.GPU
+	if req.CrossNode && req.Count > 1 {
+		selectedGPUs, err = selectGPUsAcrossNodes(strategy, filteredGPUs, req.Count)
+	} else {
+		selectedGPUs, err = strategy.SelectGPUs(filteredGPUs, req.Count)
 	}
-
-	strategy := NewStrategy(schedulingConfigTemplate.Spec.Placement.Mode)
-	selectedGPUs, err := strategy.SelectGPUs(filteredGPUs, req.Count)
 	if err != nil {
 		return nil, fmt.Errorf("select GPU: %w", err)
 	}
+	if err := s.checkQuotaLocked(req, quotas, selectedGPUs); err != nil {
+		return nil, err
+	}
+	return s.reserveLocked(ctx, req, selectedGPUs), nil
+}
 
-	s.storeMutex.Lock()
-	defer s.storeMutex.Unlock()
-
-	appAdded := false
+// reserveLocked takes resources of the request from the selected GPUs, must be called with storeMutex held
+func (s *GpuAllocator) reserveLocked(ctx context.Context, req AllocRequest, selectedGPUs []*tfv1.GPU) []*tfv1.GPU {
+	// running app is recorded once per node, same as the worker started on each node
+	appAddedNodes := make(map[string]struct{})
 	for _, selectedGPU := range selectedGPUs {
 
 		// Get the GPU from the store
@@ -133,13 +331,15 @@ func (s *GpuAllocator) Alloc(ctx context.Context, req AllocRequest) ([]*tfv1.GPU
 			s.gpuStore[key] = gpu
 		}
 
-		// reduce available resource on the GPU status
+		// reduce available resource on the GPU status, physical available
+		// goes negative when the request lands on oversold capacity
 		gpu.Status.Available.Tflops.Sub(req.Request.Tflops)
 		gpu.Status.Available.Vram.Sub(req.Request.Vram)
+		refreshVirtualAvailable(gpu)
 
-		if !appAdded {
+		if _, appAdded := appAddedNodes[gpu.Labels[constants.LabelKeyOwner]]; !appAdded {
 			addRunningApp(ctx, gpu, req.WorkloadNameNamespace)
-			appAdded = true
+			appAddedNodes[gpu.Labels[constants.LabelKeyOwner]] = struct{}{}
 		}
 
 		s.markGPUDirty(key)
@@ -151,17 +351,92 @@ func (s *GpuAllocator) Alloc(ctx context.Context, req AllocRequest) ([]*tfv1.GPU
 		key := types.NamespacedName{Name: gpu.Name, Namespace: gpu.Namespace}
 		result[i] = s.gpuStore[key].DeepCopy()
 	}
+	s.chargeQuotaLocked(req.WorkloadNameNamespace.Namespace, req.resources(), result, false)
 
-	return result, nil
+	return result
+}
+
+// AllocOnGPUs allocates the request on exactly the given GPUs, used to wake up frozen workers
+// since their GPU context can only be restored to the GPUs they were started with
+func (s *GpuAllocator) AllocOnGPUs(ctx context.Context, req AllocRequest, gpus []types.NamespacedName) ([]*tfv1.GPU, error) {
+	_, filterRegistry, _, err := s.prepareAlloc(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	quotas, err := s.listQuotas(ctx, req.WorkloadNameNamespace.Namespace, req.PoolName)
+	if err != nil {
+		return nil, err
+	}
+
+	s.storeMutex.Lock()
+	defer s.storeMutex.Unlock()
+
+	candidates := make([]tfv1.GPU, 0, len(gpus))
+	for _, key := range gpus {
+		gpu, ok := s.gpuStore[key]
+		if !ok {
+			return nil, fmt.Errorf("gpu %s not found in store", key.Name)
+		}
+		candidates = append(candidates, *gpu)
+	}
+	filteredGPUs, err := filterRegistry.Apply(ctx, candidates)
+	if err != nil {
+		return nil, fmt.Errorf("apply filters: %w", err)
+	}
+	if len(filteredGPUs) != len(candidates) {
+		return nil, fmt.Errorf("only %d of %d gpus can hold the request", len(filteredGPUs), len(candidates))
+	}
+	if err := s.checkQuotaLocked(req, quotas, lo.ToSlicePtr(filteredGPUs)); err != nil {
+		return nil, err
+	}
+	return s.reserveLocked(ctx, req, lo.ToSlicePtr(filteredGPUs)), nil
+}
+
+// AllocWithFilters allocates the request on GPUs passing the extra filters, used to move workers to other GPUs,
+// unlike Alloc, the request is not queued when nothing fits
+func (s *GpuAllocator) AllocWithFilters(ctx context.Context, req AllocRequest, filters ...filter.GPUFilter) ([]*tfv1.GPU, error) {
+	_, filterRegistry, strategy, err := s.prepareAlloc(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	quotas, err := s.listQuotas(ctx, req.WorkloadNameNamespace.Namespace, req.PoolName)
+	if err != nil {
+		return nil, err
+	}
+	filterRegistry = filterRegistry.With(filters...)
+
+	s.storeMutex.Lock()
+	defer s.storeMutex.Unlock()
+
+	return s.allocLocked(ctx, req, quotas, filterRegistry, strategy)
 }
 
 // Dealloc a request from gpu to release available resources on it.
-func (s *GpuAllocator) Dealloc(ctx context.Context, workloadNameNamespace tfv1.NameNamespace, request tfv1.Resource, gpus []types.NamespacedName) {
-	log := log.FromContext(ctx)
+func (s *GpuAllocator) Dealloc(ctx context.Context, workloadNameNamespace tfv1.NameNamespace, resources tfv1.Resources, gpus []types.NamespacedName) {
 	s.storeMutex.Lock()
 	defer s.storeMutex.Unlock()
+	s.deallocLocked(ctx, workloadNameNamespace, resources, gpus)
+}
+
+// deallocLocked releases resources of a request, must be called with storeMutex held
+func (s *GpuAllocator) deallocLocked(ctx context.Context, workloadNameNamespace tfv1.NameNamespace, resources tfv1.Resources, gpus []types.NamespacedName) {
+	// freed capacity goes to queued requests first
+	for poolName := range s.releaseLocked(ctx, workloadNameNamespace, resources, gpus) {
+		s.drainQueueLocked(ctx, poolName)
+	}
+}
+
+// releaseLocked releases resources of a request without draining pending queues and returns pools of the
+// released GPUs, used to roll back allocations which freed no capacity, must be called with storeMutex held
+func (s *GpuAllocator) releaseLocked(
+	ctx context.Context, workloadNameNamespace tfv1.NameNamespace, resources tfv1.Resources, gpus []types.NamespacedName,
+) map[string]struct{} {
+	log := log.FromContext(ctx)
+	request := resources.Requests
 
-	appRemoved := false
+	appRemovedNodes := make(map[string]struct{})
+	drainPools := make(map[string]struct{})
+	releasedGPUs := make([]*tfv1.GPU, 0, len(gpus))
 	for _, gpu := range gpus {
 		// Get the GPU from the store
 		storeGPU, exists := s.gpuStore[gpu]
@@ -173,14 +448,18 @@ func (s *GpuAllocator) Dealloc(ctx context.Context, workloadNameNamespace tfv1.N
 		// Add resources back to the GPU
 		storeGPU.Status.Available.Tflops.Add(request.Tflops)
 		storeGPU.Status.Available.Vram.Add(request.Vram)
-		if !appRemoved {
+		refreshVirtualAvailable(storeGPU)
+		if _, appRemoved := appRemovedNodes[storeGPU.Labels[constants.LabelKeyOwner]]; !appRemoved {
 			removeRunningApp(ctx, storeGPU, workloadNameNamespace)
-			appRemoved = true
+			appRemovedNodes[storeGPU.Labels[constants.LabelKeyOwner]] = struct{}{}
 		}
 
 		s.markGPUDirty(gpu)
+		drainPools[storeGPU.Labels[constants.GpuPoolKey]] = struct{}{}
+		releasedGPUs = append(releasedGPUs, storeGPU)
 	}
-
+	s.chargeQuotaLocked(workloadNameNamespace.Namespace, resources, releasedGPUs, true)
+	return drainPools
 }
 
 func NewGpuAllocator(ctx context.Context, client client.Client, syncInterval time.Duration) *GpuAllocator {
@@ -202,6 +481,10 @@ func NewGpuAllocator(ctx context.Context, client client.Client, syncInterval tim
 		gpuStore:       make(map[types.NamespacedName]*tfv1.GPU),
 		syncInterval:   syncInterval,
 		dirtyQueue:     make(map[types.NamespacedName]struct{}),
+		pendingQueues:  make(map[string][]*pendingRequest),
+		reservations:   make(map[tfv1.NameNamespace]*reservation),
+		queueEvents:    make(chan event.GenericEvent, queueEventsBufferSize),
+		quotaUsage:     make(map[quotaUsageKey]*tfv1.GPUResourceQuotaAmounts),
 	}
 
 	return allocator
@@ -361,6 +644,9 @@ func (s *GpuAllocator) handleGPUCreate(ctx context.Context, gpu *tfv1.GPU) {
 	// Add GPU to store
 	s.gpuStore[key] = gpu.DeepCopy()
 	log.V(4).Info("Added GPU to store", "name", key.Name, "phase", gpu.Status.Phase)
+
+	// new GPU may satisfy requests waiting in its pool
+	s.drainQueueLocked(ctx, gpu.Labels[constants.GpuPoolKey])
 }
 
 // handleGPUDelete handles GPU deletion events
@@ -390,6 +676,8 @@ func (s *GpuAllocator) handleGPUUpdate(ctx context.Context, gpu *tfv1.GPU) {
 		if old.Status.Available != nil {
 			newGpu.Status.Available = old.Status.Available
 		}
+		// VirtualCapacity may be changed by GPUNode controller, re-derive the oversold part
+		refreshVirtualAvailable(newGpu)
 		s.gpuStore[key] = newGpu
 		log.V(4).Info("Updated GPU in store (preserve Available)", "name", key.Name, "phase", gpu.Status.Phase)
 	} else {
@@ -485,11 +773,9 @@ func (s *GpuAllocator) syncToK8s(ctx context.Context) {
 	}
 }
 
-// listGPUsFromPool gets GPUs from the specified pool using the in-memory store
-func (s *GpuAllocator) listGPUsFromPool(poolName string) []tfv1.GPU {
-	s.storeMutex.RLock()
-	defer s.storeMutex.RUnlock()
-
+// listGPUsFromPoolLocked gets GPUs from the specified pool using the in-memory store,
+// must be called with storeMutex held
+func (s *GpuAllocator) listGPUsFromPoolLocked(poolName string) []tfv1.GPU {
 	result := make([]tfv1.GPU, 0, len(s.gpuStore)/2)
 	for _, gpu := range s.gpuStore {
 		if gpu.Labels[constants.GpuPoolKey] == poolName {
@@ -521,6 +807,15 @@ func (s *GpuAllocator) reconcileAllocationState(ctx context.Context) {
 		logger.Error(err, "Failed to list Workloads to reconcile allocation state")
 		return
 	}
+	// client pods with an embedded worker hold GPUs allocated by the pod controller, none while still gated
+	embeddedWorkers := &v1.PodList{}
+	if err := s.List(ctx, embeddedWorkers, client.MatchingLabels(map[string]string{
+		constants.EmbeddedWorkerLabel: constants.TrueStringValue,
+	})); err != nil {
+		logger.Error(err, "Failed to list embedded workers to reconcile allocation state")
+		return
+	}
+	workers.Items = append(workers.Items, embeddedWorkers.Items...)
 
 	tflopsCapacityMap := make(map[types.NamespacedName]resource.Quantity)
 	vramCapacityMap := make(map[types.NamespacedName]resource.Quantity)
@@ -538,27 +833,40 @@ func (s *GpuAllocator) reconcileAllocationState(ctx context.Context) {
 		}
 	}
 
+	s.quotaUsage = make(map[quotaUsageKey]*tfv1.GPUResourceQuotaAmounts)
 	for _, worker := range workers.Items {
-		if !worker.DeletionTimestamp.IsZero() {
+		// frozen workers hold no GPU resources once released, until then the worker may still hold VRAM
+		if !worker.DeletionTimestamp.IsZero() || worker.Annotations[constants.WorkerFrozenGPUReleasedAnnotation] == constants.TrueStringValue {
 			continue
 		}
+		// embedded workers carry the workload in annotation, a worker label would make them worker pods of the workload
+		workloadName := lo.CoalesceOrEmpty(worker.Labels[constants.WorkloadKey], worker.Annotations[constants.WorkloadKey])
 		tflopsRequest, _ := resource.ParseQuantity(worker.Annotations[constants.TFLOPSRequestAnnotation])
 		vramRequest, _ := resource.ParseQuantity(worker.Annotations[constants.VRAMRequestAnnotation])
+		tflopsLimit, _ := resource.ParseQuantity(worker.Annotations[constants.TFLOPSLimitAnnotation])
+		vramLimit, _ := resource.ParseQuantity(worker.Annotations[constants.VRAMLimitAnnotation])
 		gpuIds := worker.Annotations[constants.GpuKey]
 		gpuIdsList := strings.Split(gpuIds, ",")
+		s.chargeQuotaLocked(worker.Namespace, tfv1.Resources{
+			Requests: tfv1.Resource{Tflops: tflopsRequest, Vram: vramRequest},
+			Limits:   tfv1.Resource{Tflops: tflopsLimit, Vram: vramLimit},
+		}, lo.FilterMap(gpuIdsList, func(gpuId string, _ int) (*tfv1.GPU, bool) {
+			gpu, ok := gpuMap[types.NamespacedName{Name: gpuId}]
+			return gpu, ok
+		}), false)
 		appAdded := false
 		for _, gpuId := range gpuIdsList {
 			gpuKey := types.NamespacedName{Name: gpuId}
-			gpuCapacity, ok := tflopsCapacityMap[gpuKey]
-			if ok {
+			if gpuCapacity, ok := tflopsCapacityMap[gpuKey]; ok {
 				gpuCapacity.Sub(tflopsRequest)
+				tflopsCapacityMap[gpuKey] = gpuCapacity
 			}
-			gpuCapacity, ok = vramCapacityMap[gpuKey]
-			if ok {
+			if gpuCapacity, ok := vramCapacityMap[gpuKey]; ok {
 				gpuCapacity.Sub(vramRequest)
+				vramCapacityMap[gpuKey] = gpuCapacity
 			}
 			if !appAdded {
-				addRunningApp(ctx, gpuMap[gpuKey], tfv1.NameNamespace{Namespace: worker.Namespace, Name: worker.Labels[constants.WorkloadKey]})
+				addRunningApp(ctx, gpuMap[gpuKey], tfv1.NameNamespace{Namespace: worker.Namespace, Name: workloadName})
 				appAdded = true
 			}
 		}
@@ -569,11 +877,17 @@ func (s *GpuAllocator) reconcileAllocationState(ctx context.Context) {
 			log.FromContext(ctx).Info("[Warning] GPU capacity is nil, skip reconcile", "gpu", gpuKey.Name)
 			continue
 		}
+		if gpu.Status.Available == nil {
+			gpu.Status.Available = gpu.Status.Capacity.DeepCopy()
+		}
+		virtualAvailable := gpu.Status.VirtualAvailable
 		sameTflops := gpu.Status.Available.Tflops.Equal(tflopsCapacityMap[gpuKey])
 		sameVRAM := gpu.Status.Available.Vram.Equal(vramCapacityMap[gpuKey])
-		if !sameTflops || !sameVRAM {
-			gpu.Status.Available.Tflops = tflopsCapacityMap[gpuKey]
-			gpu.Status.Available.Vram = vramCapacityMap[gpuKey]
+		gpu.Status.Available.Tflops = tflopsCapacityMap[gpuKey]
+		gpu.Status.Available.Vram = vramCapacityMap[gpuKey]
+		refreshVirtualAvailable(gpu)
+		sameVirtual := equality.Semantic.DeepEqual(virtualAvailable, gpu.Status.VirtualAvailable)
+		if !sameTflops || !sameVRAM || !sameVirtual {
 			s.markGPUDirtyLoced(gpuKey)
 			log.FromContext(ctx).Info("Correcting gpu available resources", "gpu", gpuKey.Name, "tflops", gpu.Status.Available.Tflops.String(), "vram", gpu.Status.Available.Vram.String())
 		}
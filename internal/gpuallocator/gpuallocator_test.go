@@ -48,7 +48,7 @@ var _ = Describe("GPU Allocator", func() {
 	}
 
 	deallocateAndSync := func(gpus []*tfv1.GPU, request tfv1.Resource) {
-		allocator.Dealloc(ctx, workloadNameNs, request, lo.Map(gpus, func(gpu *tfv1.GPU, _ int) types.NamespacedName {
+		allocator.Dealloc(ctx, workloadNameNs, tfv1.Resources{Requests: request}, lo.Map(gpus, func(gpu *tfv1.GPU, _ int) types.NamespacedName {
 			return client.ObjectKeyFromObject(gpu)
 		}))
 		allocator.syncToK8s(ctx)
@@ -170,6 +170,74 @@ var _ = Describe("GPU Allocator", func() {
 			_, err = allocateAndSync("test-pool", request, 1, "NonExistentModel")
 			Expect(err).To(HaveOccurred())
 		})
+
+		It("should allocate all replicas of a gang or none of them", func() {
+			request := tfv1.Resource{
+				Tflops: resource.MustParse("5"),
+				Vram:   resource.MustParse("1Gi"),
+			}
+			req := AllocRequest{
+				PoolName:              "test-pool",
+				WorkloadNameNamespace: workloadNameNs,
+				Request:               request,
+				Count:                 1,
+			}
+
+			replicaGPUs, err := allocator.AllocGang(ctx, req, 2)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(replicaGPUs).To(HaveLen(2))
+			for _, gpus := range replicaGPUs {
+				deallocateAndSync(gpus, request)
+			}
+
+			availableBefore := lo.MapValues(lo.KeyBy(allocator.listGPUsFromPoolLocked("test-pool"), func(gpu tfv1.GPU) string {
+				return gpu.Name
+			}), func(gpu tfv1.GPU, _ string) tfv1.Resource {
+				return *gpu.Status.Available.DeepCopy()
+			})
+
+			// Far more replicas than the pool can hold, nothing should be reserved
+			req.Request = tfv1.Resource{
+				Tflops: resource.MustParse("60"),
+				Vram:   resource.MustParse("1Gi"),
+			}
+			_, err = allocator.AllocGang(ctx, req, 10)
+			Expect(err).To(HaveOccurred())
+			Expect(allocator.QueuePosition(workloadNameNs)).To(Equal(int32(1)), "the gang waits in the queue")
+			allocator.CancelPending(ctx, workloadNameNs)
+
+			for _, gpu := range allocator.listGPUsFromPoolLocked("test-pool") {
+				Expect(gpu.Status.Available.Tflops.Equal(availableBefore[gpu.Name].Tflops)).To(BeTrue())
+				Expect(gpu.Status.Available.Vram.Equal(availableBefore[gpu.Name].Vram)).To(BeTrue())
+			}
+		})
+	})
+
+	Context("GPU Re-allocation", func() {
+		It("should allocate again on the same GPUs only when they still fit", func() {
+			request := tfv1.Resource{
+				Tflops: resource.MustParse("30"),
+				Vram:   resource.MustParse("6Gi"),
+			}
+			gpus, err := allocateAndSync("test-pool", request, 1, "")
+			Expect(err).NotTo(HaveOccurred())
+			keys := lo.Map(gpus, func(gpu *tfv1.GPU, _ int) types.NamespacedName {
+				return client.ObjectKeyFromObject(gpu)
+			})
+			deallocateAndSync(gpus, request)
+
+			req := AllocRequest{PoolName: "test-pool", WorkloadNameNamespace: workloadNameNs, Request: request, Count: 1}
+			woken, err := allocator.AllocOnGPUs(ctx, req, keys)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(woken[0].Name).To(Equal(gpus[0].Name))
+			Expect(woken[0].Status.Available.Tflops.Cmp(gpus[0].Status.Available.Tflops)).To(Equal(0))
+
+			req.Request = tfv1.Resource{Tflops: resource.MustParse("100000"), Vram: resource.MustParse("6Gi")}
+			_, err = allocator.AllocOnGPUs(ctx, req, keys)
+			Expect(err).To(HaveOccurred())
+
+			deallocateAndSync(woken, request)
+		})
 	})
 
 	Context("GPU Deallocation", func() {
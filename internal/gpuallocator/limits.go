@@ -0,0 +1,86 @@
+package gpuallocator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+)
+
+// TflopsHeadroom returns the TFlops not allocated on the least available GPU of the given GPUs,
+// a running worker on these GPUs can not burst beyond its requests plus the headroom
+func (s *GpuAllocator) TflopsHeadroom(gpus []types.NamespacedName) (resource.Quantity, error) {
+	s.storeMutex.RLock()
+	defer s.storeMutex.RUnlock()
+
+	var headroom *resource.Quantity
+	for _, key := range gpus {
+		gpu, ok := s.gpuStore[key]
+		if !ok {
+			return resource.Quantity{}, fmt.Errorf("gpu %s not found in store", key.Name)
+		}
+		if gpu.Status.Available == nil {
+			return resource.Quantity{}, nil
+		}
+		if headroom == nil || gpu.Status.Available.Tflops.Cmp(*headroom) < 0 {
+			headroom = ptr.To(gpu.Status.Available.Tflops.DeepCopy())
+		}
+	}
+	if headroom == nil || headroom.Sign() < 0 {
+		return resource.Quantity{}, nil
+	}
+	return *headroom, nil
+}
+
+// UpdateLimits moves the quota charge of a running worker on the GPUs from old limits to new limits,
+// ErrQuotaExceeded is returned when raised limits go beyond a GPUResourceQuota of the namespace
+func (s *GpuAllocator) UpdateLimits(ctx context.Context, namespace string, gpus []types.NamespacedName, oldLimit, newLimit tfv1.Resource) error {
+	if len(gpus) == 0 {
+		return nil
+	}
+	s.storeMutex.RLock()
+	poolName := ""
+	if gpu, ok := s.gpuStore[gpus[0]]; ok {
+		poolName = gpu.Labels[constants.GpuPoolKey]
+	}
+	s.storeMutex.RUnlock()
+
+	quotas, err := s.listQuotas(ctx, namespace, poolName)
+	if err != nil {
+		return err
+	}
+
+	s.storeMutex.Lock()
+	defer s.storeMutex.Unlock()
+
+	storeGPUs := make([]*tfv1.GPU, 0, len(gpus))
+	for _, key := range gpus {
+		gpu, ok := s.gpuStore[key]
+		if !ok {
+			return fmt.Errorf("gpu %s not found in store", key.Name)
+		}
+		storeGPUs = append(storeGPUs, gpu)
+	}
+
+	if newLimit.Tflops.Cmp(oldLimit.Tflops) > 0 || newLimit.Vram.Cmp(oldLimit.Vram) > 0 {
+		gpuCount := int64(len(storeGPUs))
+		charge := tfv1.NewGPUResourceQuotaAmounts(tfv1.Resources{Limits: newLimit}, gpuCount, 0)
+		for _, quota := range quotas {
+			used := s.quotaUsageLocked(quota.Namespace, quota.Spec.PoolName)
+			used.Sub(tfv1.NewGPUResourceQuotaAmounts(tfv1.Resources{Limits: oldLimit}, gpuCount, 0))
+			if exceeded := quota.Exceeded(used, charge); len(exceeded) > 0 {
+				return fmt.Errorf("%w %s: %s", ErrQuotaExceeded, quota.Name, strings.Join(exceeded, ", "))
+			}
+		}
+	}
+
+	// workers and GPUs are released and charged again, only limits change
+	s.chargeQuotaLocked(namespace, tfv1.Resources{Limits: oldLimit}, storeGPUs, true)
+	s.chargeQuotaLocked(namespace, tfv1.Resources{Limits: newLimit}, storeGPUs, false)
+	return nil
+}
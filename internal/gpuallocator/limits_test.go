@@ -0,0 +1,65 @@
+package gpuallocator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestUpdateLimits(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, tfv1.AddToScheme(scheme))
+	quota := &tfv1.GPUResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: "team-a"},
+		Spec: tfv1.GPUResourceQuotaSpec{
+			Hard: tfv1.GPUResourceQuotaAmounts{LimitsTflops: ptr.To(resource.MustParse("100"))},
+		},
+	}
+	newGPU := func(name string, available string) *tfv1.GPU {
+		return &tfv1.GPU{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{constants.GpuPoolKey: "pool-a", constants.LabelKeyOwner: "node-1"},
+			},
+			Status: tfv1.GPUStatus{Available: &tfv1.Resource{Tflops: resource.MustParse(available)}},
+		}
+	}
+	allocator := &GpuAllocator{
+		Client:     fake.NewClientBuilder().WithScheme(scheme).WithObjects(quota).Build(),
+		gpuStore:   map[types.NamespacedName]*tfv1.GPU{},
+		quotaUsage: make(map[quotaUsageKey]*tfv1.GPUResourceQuotaAmounts),
+	}
+	gpus := []*tfv1.GPU{newGPU("gpu-1", "40"), newGPU("gpu-2", "25")}
+	keys := []types.NamespacedName{{Name: "gpu-1"}, {Name: "gpu-2"}}
+	for _, gpu := range gpus {
+		allocator.gpuStore[types.NamespacedName{Name: gpu.Name}] = gpu
+	}
+
+	headroom, err := allocator.TflopsHeadroom(keys)
+	require.NoError(t, err)
+	assert.Equal(t, "25", headroom.String())
+
+	oldLimit := tfv1.Resource{Tflops: resource.MustParse("30"), Vram: resource.MustParse("1Gi")}
+	allocator.chargeQuotaLocked("team-a", tfv1.Resources{Limits: oldLimit}, gpus, false)
+
+	newLimit := tfv1.Resource{Tflops: resource.MustParse("50"), Vram: resource.MustParse("1Gi")}
+	require.NoError(t, allocator.UpdateLimits(context.Background(), "team-a", keys, oldLimit, newLimit))
+	used := allocator.QuotaUsage("team-a", "pool-a")
+	assert.Equal(t, "100", used.LimitsTflops.String())
+	assert.Equal(t, int32(1), *used.Workers)
+
+	err = allocator.UpdateLimits(context.Background(), "team-a", keys, newLimit, tfv1.Resource{Tflops: resource.MustParse("60"), Vram: resource.MustParse("1Gi")})
+	assert.True(t, errors.Is(err, ErrQuotaExceeded))
+	assert.Equal(t, "100", allocator.QuotaUsage("team-a", "pool-a").LimitsTflops.String())
+}
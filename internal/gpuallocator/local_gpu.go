@@ -0,0 +1,21 @@
+package gpuallocator
+
+import (
+	"errors"
+	"fmt"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+)
+
+// ErrLocalGPUNotAllowed is returned by Alloc when a local GPU request is made to a pool
+// whose scheduling config template sets allowUsingLocalGPU to false
+var ErrLocalGPUNotAllowed = errors.New("local gpu is not allowed")
+
+// checkLocalGPU refuses local GPU requests unless the scheduling config template allows them, allowed by default
+func checkLocalGPU(req AllocRequest, template *tfv1.SchedulingConfigTemplate) error {
+	allowed := template.Spec.Placement.AllowUsingLocalGPU
+	if !req.LocalGPU || allowed == nil || *allowed {
+		return nil
+	}
+	return fmt.Errorf("%w by scheduling config template %s of pool %s", ErrLocalGPUNotAllowed, template.Name, req.PoolName)
+}
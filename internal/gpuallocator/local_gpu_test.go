@@ -0,0 +1,31 @@
+package gpuallocator
+
+import (
+	"errors"
+	"testing"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestCheckLocalGPU(t *testing.T) {
+	template := func(allowed *bool) *tfv1.SchedulingConfigTemplate {
+		return &tfv1.SchedulingConfigTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "template"},
+			Spec: tfv1.SchedulingConfigTemplateSpec{
+				Placement: tfv1.PlacementConfig{AllowUsingLocalGPU: allowed},
+			},
+		}
+	}
+	local := AllocRequest{PoolName: "pool", LocalGPU: true}
+	remote := AllocRequest{PoolName: "pool"}
+
+	assert.NoError(t, checkLocalGPU(local, template(nil)), "allowed by default")
+	assert.NoError(t, checkLocalGPU(local, template(ptr.To(true))))
+	assert.NoError(t, checkLocalGPU(remote, template(ptr.To(false))), "remote GPU is not restricted")
+
+	err := checkLocalGPU(local, template(ptr.To(false)))
+	assert.True(t, errors.Is(err, ErrLocalGPUNotAllowed))
+}
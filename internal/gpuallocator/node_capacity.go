@@ -47,6 +47,23 @@ func RefreshGPUNodeCapacity(ctx context.Context, k8sClient client.Client, node *
 		}
 	}
 
+	for i := range gpuList.Items {
+		gpu := &gpuList.Items[i]
+		if gpu.Status.Capacity == nil {
+			continue
+		}
+		virtualCapacity := calculateGPUVirtualCapacity(gpu, node, pool, len(gpuList.Items))
+		if equality.Semantic.DeepEqual(gpu.Status.VirtualCapacity, virtualCapacity) {
+			continue
+		}
+		// patch only the virtual capacity, available resources are owned by the allocator
+		patch := client.MergeFrom(gpu.DeepCopy())
+		gpu.Status.VirtualCapacity = virtualCapacity
+		if err := k8sClient.Status().Patch(ctx, gpu, patch); err != nil {
+			return nil, fmt.Errorf("failed to update GPU virtual capacity: %w", err)
+		}
+	}
+
 	virtualVRAM, virtualTFlops := calculateVirtualCapacity(node, pool)
 	node.Status.VirtualTFlops = virtualTFlops
 	node.Status.VirtualVRAM = virtualVRAM
@@ -63,23 +80,46 @@ func RefreshGPUNodeCapacity(ctx context.Context, k8sClient client.Client, node *
 }
 
 func calculateVirtualCapacity(node *tfv1.GPUNode, pool *tfv1.GPUPool) (resource.Quantity, resource.Quantity) {
-	diskSize, _ := node.Status.NodeInfo.DataDiskSize.AsInt64()
-	ramSize, _ := node.Status.NodeInfo.RAMSize.AsInt64()
-
 	virtualVRAM := node.Status.TotalVRAM.DeepCopy()
 	if pool.Spec.CapacityConfig == nil || pool.Spec.CapacityConfig.Oversubscription == nil {
 		return virtualVRAM, node.Status.TotalTFlops.DeepCopy()
 	}
 	vTFlops := node.Status.TotalTFlops.AsApproximateFloat64() * (float64(pool.Spec.CapacityConfig.Oversubscription.TFlopsOversellRatio) / 100.0)
 
-	virtualVRAM.Add(*resource.NewQuantity(
-		int64(float64(float64(diskSize)*float64(pool.Spec.CapacityConfig.Oversubscription.VRAMExpandToHostDisk)/100.0)),
+	virtualVRAM.Add(hostExpandedVRAM(node, pool.Spec.CapacityConfig.Oversubscription))
+
+	return virtualVRAM, *resource.NewQuantity(int64(vTFlops), resource.DecimalSI)
+}
+
+// calculateGPUVirtualCapacity returns the oversold capacity of a single GPU,
+// host memory and disk expansion of the node is shared evenly by its GPUs
+func calculateGPUVirtualCapacity(gpu *tfv1.GPU, node *tfv1.GPUNode, pool *tfv1.GPUPool, gpuCount int) *tfv1.Resource {
+	virtualCapacity := gpu.Status.Capacity.DeepCopy()
+	if pool.Spec.CapacityConfig == nil || pool.Spec.CapacityConfig.Oversubscription == nil || gpuCount == 0 {
+		return virtualCapacity
+	}
+	oversubscription := pool.Spec.CapacityConfig.Oversubscription
+
+	vTFlops := gpu.Status.Capacity.Tflops.AsApproximateFloat64() * (float64(oversubscription.TFlopsOversellRatio) / 100.0)
+	virtualCapacity.Tflops = *resource.NewQuantity(int64(vTFlops), resource.DecimalSI)
+
+	expanded := hostExpandedVRAM(node, oversubscription)
+	virtualCapacity.Vram.Add(*resource.NewQuantity(expanded.Value()/int64(gpuCount), resource.DecimalSI))
+	return virtualCapacity
+}
+
+func hostExpandedVRAM(node *tfv1.GPUNode, oversubscription *tfv1.Oversubscription) resource.Quantity {
+	diskSize, _ := node.Status.NodeInfo.DataDiskSize.AsInt64()
+	ramSize, _ := node.Status.NodeInfo.RAMSize.AsInt64()
+
+	expanded := resource.Quantity{}
+	expanded.Add(*resource.NewQuantity(
+		int64(float64(float64(diskSize)*float64(oversubscription.VRAMExpandToHostDisk)/100.0)),
 		resource.DecimalSI),
 	)
-	virtualVRAM.Add(*resource.NewQuantity(
-		int64(float64(float64(ramSize)*float64(pool.Spec.CapacityConfig.Oversubscription.VRAMExpandToHostMem)/100.0)),
+	expanded.Add(*resource.NewQuantity(
+		int64(float64(float64(ramSize)*float64(oversubscription.VRAMExpandToHostMem)/100.0)),
 		resource.DecimalSI),
 	)
-
-	return virtualVRAM, *resource.NewQuantity(int64(vTFlops), resource.DecimalSI)
+	return expanded
 }
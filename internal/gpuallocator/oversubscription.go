@@ -0,0 +1,47 @@
+package gpuallocator
+
+import (
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+)
+
+// canUseOversoldCapacity returns whether workloads of the QoS level can be admitted against
+// oversold capacity, high and critical workloads only take physical capacity
+func canUseOversoldCapacity(qos tfv1.QoSLevel) bool {
+	return qos == tfv1.QoSLow || qos == tfv1.QoSMedium
+}
+
+// resolveQoS returns the QoS level of the request, falls back to pool's default QoS
+func resolveQoS(qos tfv1.QoSLevel, pool *tfv1.GPUPool) tfv1.QoSLevel {
+	if qos != "" {
+		return qos
+	}
+	if pool.Spec.QosConfig != nil && pool.Spec.QosConfig.DefaultQoS != "" {
+		return pool.Spec.QosConfig.DefaultQoS
+	}
+	return tfv1.QoSMedium
+}
+
+// refreshVirtualAvailable derives remaining oversold capacity of the GPU,
+// the allocated part (Capacity - Available) is subtracted from VirtualCapacity
+func refreshVirtualAvailable(gpu *tfv1.GPU) {
+	if gpu.Status.Capacity == nil || gpu.Status.Available == nil {
+		return
+	}
+	virtualCapacity := gpu.Status.VirtualCapacity
+	if virtualCapacity == nil {
+		virtualCapacity = gpu.Status.Capacity
+	}
+
+	tflops := virtualCapacity.Tflops.DeepCopy()
+	tflops.Sub(gpu.Status.Capacity.Tflops)
+	tflops.Add(gpu.Status.Available.Tflops)
+
+	vram := virtualCapacity.Vram.DeepCopy()
+	vram.Sub(gpu.Status.Capacity.Vram)
+	vram.Add(gpu.Status.Available.Vram)
+
+	gpu.Status.VirtualAvailable = &tfv1.Resource{
+		Tflops: tflops,
+		Vram:   vram,
+	}
+}
@@ -0,0 +1,67 @@
+package gpuallocator
+
+import (
+	"testing"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestGPUVirtualCapacity(t *testing.T) {
+	gpu := &tfv1.GPU{
+		Status: tfv1.GPUStatus{
+			Capacity: &tfv1.Resource{
+				Tflops: resource.MustParse("100"),
+				Vram:   resource.MustParse("10G"),
+			},
+			Available: &tfv1.Resource{
+				Tflops: resource.MustParse("20"),
+				Vram:   resource.MustParse("2G"),
+			},
+		},
+	}
+	node := &tfv1.GPUNode{
+		Status: tfv1.GPUNodeStatus{
+			NodeInfo: tfv1.GPUNodeInfo{
+				RAMSize:      resource.MustParse("40G"),
+				DataDiskSize: resource.MustParse("100G"),
+			},
+		},
+	}
+	pool := &tfv1.GPUPool{
+		Spec: tfv1.GPUPoolSpec{
+			CapacityConfig: &tfv1.CapacityConfig{
+				Oversubscription: &tfv1.Oversubscription{
+					VRAMExpandToHostMem:  50,
+					VRAMExpandToHostDisk: 20,
+					TFlopsOversellRatio:  300,
+				},
+			},
+		},
+	}
+
+	// Without oversubscription, virtual capacity equals physical capacity
+	virtualCapacity := calculateGPUVirtualCapacity(gpu, node, &tfv1.GPUPool{}, 2)
+	assert.True(t, virtualCapacity.Tflops.Equal(resource.MustParse("100")))
+	assert.True(t, virtualCapacity.Vram.Equal(resource.MustParse("10G")))
+
+	// Host expansion (20G RAM + 20G disk) is shared by 2 GPUs
+	virtualCapacity = calculateGPUVirtualCapacity(gpu, node, pool, 2)
+	assert.True(t, virtualCapacity.Tflops.Equal(resource.MustParse("300")))
+	assert.True(t, virtualCapacity.Vram.Equal(resource.MustParse("30G")))
+
+	gpu.Status.VirtualCapacity = virtualCapacity
+	refreshVirtualAvailable(gpu)
+	assert.True(t, gpu.Status.VirtualAvailable.Tflops.Equal(resource.MustParse("220")))
+	assert.True(t, gpu.Status.VirtualAvailable.Vram.Equal(resource.MustParse("22G")))
+}
+
+func TestCanUseOversoldCapacity(t *testing.T) {
+	assert.True(t, canUseOversoldCapacity(resolveQoS("", &tfv1.GPUPool{})))
+	assert.True(t, canUseOversoldCapacity(tfv1.QoSLow))
+	assert.False(t, canUseOversoldCapacity(tfv1.QoSHigh))
+	assert.False(t, canUseOversoldCapacity(resolveQoS("", &tfv1.GPUPool{
+		Spec: tfv1.GPUPoolSpec{QosConfig: &tfv1.QosConfig{DefaultQoS: tfv1.QoSCritical}},
+	})))
+}
@@ -0,0 +1,199 @@
+package gpuallocator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// default priorities when pool's QoS config does not define them, range from 1-100
+var defaultQoSPriorities = map[tfv1.QoSLevel]int{
+	tfv1.QoSLow:      25,
+	tfv1.QoSMedium:   50,
+	tfv1.QoSHigh:     75,
+	tfv1.QoSCritical: 100,
+}
+
+// PreemptionCandidate is a running worker which can be evicted to make room for a higher priority request
+type PreemptionCandidate struct {
+	// Worker pod
+	Worker types.NamespacedName
+	// Workload the worker belongs to
+	WorkloadNameNamespace tfv1.NameNamespace
+	// Priority of the workload's QoS level
+	Priority int
+	// Resource requested on each GPU of the worker
+	Request tfv1.Resource
+	// GPUs used by the worker
+	GPUs []types.NamespacedName
+}
+
+// QoSPriority returns the scheduling priority of the QoS level defined in pool's QoS config,
+// falls back to the built-in order low < medium < high < critical
+func QoSPriority(pool *tfv1.GPUPool, qos tfv1.QoSLevel) int {
+	qos = resolveQoS(qos, pool)
+	if pool.Spec.QosConfig != nil {
+		for _, definition := range pool.Spec.QosConfig.Definitions {
+			if definition.Name == qos && definition.Priority > 0 {
+				return definition.Priority
+			}
+		}
+	}
+	return defaultQoSPriorities[qos]
+}
+
+// CanPreempt returns whether workloads of the QoS level are allowed to preempt lower priority workers
+func CanPreempt(pool *tfv1.GPUPool, qos tfv1.QoSLevel) bool {
+	qos = resolveQoS(qos, pool)
+	return qos == tfv1.QoSHigh || qos == tfv1.QoSCritical
+}
+
+// PlanPreemption simulates evicting lower priority candidates and returns the minimal set of
+// workers to evict so that the request fits, the store is not modified
+func (s *GpuAllocator) PlanPreemption(ctx context.Context, req AllocRequest, candidates []PreemptionCandidate) ([]PreemptionCandidate, error) {
+	pool, filterRegistry, _, err := s.prepareAlloc(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	priority := QoSPriority(pool, req.QoS)
+	allowOversold := canUseOversoldCapacity(resolveQoS(req.QoS, pool))
+
+	// candidates on each GPU, lowest priority and biggest request are evicted first
+	candidatesByGPU := make(map[string][]*PreemptionCandidate)
+	for i := range candidates {
+		candidate := &candidates[i]
+		if candidate.Priority >= priority {
+			continue
+		}
+		for _, gpu := range candidate.GPUs {
+			candidatesByGPU[gpu.Name] = append(candidatesByGPU[gpu.Name], candidate)
+		}
+	}
+	for _, gpuCandidates := range candidatesByGPU {
+		sort.SliceStable(gpuCandidates, func(i, j int) bool {
+			if gpuCandidates[i].Priority != gpuCandidates[j].Priority {
+				return gpuCandidates[i].Priority < gpuCandidates[j].Priority
+			}
+			return gpuCandidates[i].Request.Tflops.Cmp(gpuCandidates[j].Request.Tflops) > 0
+		})
+	}
+
+	s.storeMutex.RLock()
+	poolGPUs := lo.Map(s.listGPUsFromPoolLocked(req.PoolName), func(gpu tfv1.GPU, _ int) tfv1.GPU {
+		return *gpu.DeepCopy()
+	})
+	s.storeMutex.RUnlock()
+
+	// GPUs which can hold the request after evicting every lower priority candidate on them
+	released := lo.Map(poolGPUs, func(gpu tfv1.GPU, _ int) tfv1.GPU {
+		for _, candidate := range candidatesByGPU[gpu.Name] {
+			releaseOnGPU(&gpu, candidate.Request)
+		}
+		return gpu
+	})
+	eligibleGPUs, err := filterRegistry.Apply(ctx, released)
+	if err != nil {
+		return nil, fmt.Errorf("apply filters: %w", err)
+	}
+	if len(eligibleGPUs) == 0 {
+		return nil, fmt.Errorf("no gpus in pool %s can hold the request even after preemption", req.PoolName)
+	}
+
+	// minimal victims on each eligible GPU
+	originalGPUs := lo.KeyBy(poolGPUs, func(gpu tfv1.GPU) string {
+		return gpu.Name
+	})
+	victimsByGPU := make(map[string][]*PreemptionCandidate, len(eligibleGPUs))
+	for _, eligible := range eligibleGPUs {
+		gpu := originalGPUs[eligible.Name]
+		victims := []*PreemptionCandidate{}
+		for _, candidate := range candidatesByGPU[gpu.Name] {
+			if fitsOnGPU(&gpu, req.Request, allowOversold) {
+				break
+			}
+			releaseOnGPU(&gpu, candidate.Request)
+			victims = append(victims, candidate)
+		}
+		victimsByGPU[gpu.Name] = victims
+	}
+
+	selected := selectPreemptionGPUs(eligibleGPUs, victimsByGPU, req)
+	if selected == nil {
+		return nil, fmt.Errorf("no node in pool %s has %d GPUs available after preemption", req.PoolName, req.Count)
+	}
+
+	victims := lo.UniqBy(lo.Flatten(lo.Map(selected, func(gpuName string, _ int) []*PreemptionCandidate {
+		return victimsByGPU[gpuName]
+	})), func(candidate *PreemptionCandidate) types.NamespacedName {
+		return candidate.Worker
+	})
+	return lo.Map(victims, func(candidate *PreemptionCandidate, _ int) PreemptionCandidate {
+		return *candidate
+	}), nil
+}
+
+// selectPreemptionGPUs picks GPUs needing the fewest victims, GPUs of the same node
+// are picked together unless the request allows spreading across nodes
+func selectPreemptionGPUs(eligibleGPUs []tfv1.GPU, victimsByGPU map[string][]*PreemptionCandidate, req AllocRequest) []string {
+	count := int(max(req.Count, 1))
+	byVictims := func(gpus []tfv1.GPU) []string {
+		names := lo.Map(gpus, func(gpu tfv1.GPU, _ int) string {
+			return gpu.Name
+		})
+		sort.SliceStable(names, func(i, j int) bool {
+			return len(victimsByGPU[names[i]]) < len(victimsByGPU[names[j]])
+		})
+		return names
+	}
+
+	if count == 1 || req.CrossNode {
+		names := byVictims(eligibleGPUs)
+		if len(names) < count {
+			return nil
+		}
+		return names[:count]
+	}
+
+	var best []string
+	bestCost := -1
+	for _, nodeGPUs := range lo.GroupBy(eligibleGPUs, func(gpu tfv1.GPU) string {
+		return gpu.Labels[constants.LabelKeyOwner]
+	}) {
+		if len(nodeGPUs) < count {
+			continue
+		}
+		names := byVictims(nodeGPUs)[:count]
+		cost := len(lo.Uniq(lo.Flatten(lo.Map(names, func(name string, _ int) []*PreemptionCandidate {
+			return victimsByGPU[name]
+		}))))
+		if bestCost == -1 || cost < bestCost {
+			best, bestCost = names, cost
+		}
+	}
+	return best
+}
+
+func releaseOnGPU(gpu *tfv1.GPU, request tfv1.Resource) {
+	if gpu.Status.Available == nil {
+		return
+	}
+	gpu.Status.Available.Tflops.Add(request.Tflops)
+	gpu.Status.Available.Vram.Add(request.Vram)
+	refreshVirtualAvailable(gpu)
+}
+
+func fitsOnGPU(gpu *tfv1.GPU, request tfv1.Resource, allowOversold bool) bool {
+	available := gpu.Status.Available
+	if allowOversold && gpu.Status.VirtualAvailable != nil {
+		available = gpu.Status.VirtualAvailable
+	}
+	if available == nil {
+		return false
+	}
+	return available.Tflops.Cmp(request.Tflops) >= 0 && available.Vram.Cmp(request.Vram) >= 0
+}
@@ -0,0 +1,78 @@
+package gpuallocator
+
+import (
+	"testing"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestQoSPriority(t *testing.T) {
+	pool := &tfv1.GPUPool{}
+	assert.Equal(t, 25, QoSPriority(pool, tfv1.QoSLow))
+	assert.Equal(t, 50, QoSPriority(pool, ""))
+	assert.Equal(t, 100, QoSPriority(pool, tfv1.QoSCritical))
+	assert.True(t, CanPreempt(pool, tfv1.QoSHigh))
+	assert.False(t, CanPreempt(pool, tfv1.QoSMedium))
+
+	pool.Spec.QosConfig = &tfv1.QosConfig{
+		DefaultQoS: tfv1.QoSHigh,
+		Definitions: []tfv1.QosDefinition{
+			{Name: tfv1.QoSHigh, Priority: 90},
+		},
+	}
+	assert.Equal(t, 90, QoSPriority(pool, ""))
+	assert.Equal(t, 25, QoSPriority(pool, tfv1.QoSLow))
+	assert.True(t, CanPreempt(pool, ""))
+}
+
+func TestSelectPreemptionGPUs(t *testing.T) {
+	newGPU := func(name, node string) tfv1.GPU {
+		return tfv1.GPU{ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{constants.LabelKeyOwner: node},
+		}}
+	}
+	newVictims := func(names ...string) []*PreemptionCandidate {
+		victims := []*PreemptionCandidate{}
+		for _, name := range names {
+			victims = append(victims, &PreemptionCandidate{Worker: types.NamespacedName{Name: name}})
+		}
+		return victims
+	}
+	victimA, victimB := newVictims("a")[0], newVictims("b")[0]
+	gpus := []tfv1.GPU{
+		newGPU("gpu-1", "node-1"),
+		newGPU("gpu-2", "node-1"),
+		newGPU("gpu-3", "node-2"),
+		newGPU("gpu-4", "node-2"),
+	}
+	victimsByGPU := map[string][]*PreemptionCandidate{
+		"gpu-1": {victimA},
+		"gpu-2": {victimA},
+		"gpu-3": {},
+		"gpu-4": {victimA, victimB},
+	}
+
+	assert.Equal(t, []string{"gpu-3"}, selectPreemptionGPUs(gpus, victimsByGPU, AllocRequest{Count: 1}))
+	// node-1 needs to evict only one worker shared by both GPUs
+	assert.ElementsMatch(t, []string{"gpu-1", "gpu-2"}, selectPreemptionGPUs(gpus, victimsByGPU, AllocRequest{Count: 2}))
+	assert.ElementsMatch(t, []string{"gpu-3", "gpu-1", "gpu-2"}, selectPreemptionGPUs(gpus, victimsByGPU, AllocRequest{Count: 3, CrossNode: true}))
+	assert.Nil(t, selectPreemptionGPUs(gpus, victimsByGPU, AllocRequest{Count: 3}))
+}
+
+func TestFitsOnGPUAfterRelease(t *testing.T) {
+	gpu := &tfv1.GPU{Status: tfv1.GPUStatus{
+		Capacity:  &tfv1.Resource{Tflops: resource.MustParse("100"), Vram: resource.MustParse("10Gi")},
+		Available: &tfv1.Resource{Tflops: resource.MustParse("10"), Vram: resource.MustParse("1Gi")},
+	}}
+	request := tfv1.Resource{Tflops: resource.MustParse("50"), Vram: resource.MustParse("4Gi")}
+	assert.False(t, fitsOnGPU(gpu, request, false))
+
+	releaseOnGPU(gpu, tfv1.Resource{Tflops: resource.MustParse("40"), Vram: resource.MustParse("3Gi")})
+	assert.True(t, fitsOnGPU(gpu, request, false))
+}
@@ -0,0 +1,233 @@
+package gpuallocator
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/gpuallocator/filter"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// size of the buffered channel notifying workloads with reserved GPUs, notifications are dropped when
+// it is full, the workload controller still picks the reservation up on its periodic requeue
+const queueEventsBufferSize = 1024
+
+// pending requests not retried by their workload for this long are dropped, the workload controller
+// retries every PendingRequeueDuration while queued, so only entries of deleted or stuck workloads expire
+const pendingRequestTTL = 10 * time.Minute
+
+// pendingRequest is an allocation request waiting for capacity in its pool
+type pendingRequest struct {
+	req        AllocRequest
+	priority   int
+	enqueuedAt time.Time
+	// number of replicas allocated in an all-or-nothing manner for gang scheduling, 0 for a single allocation
+	gangReplicas int
+	// last time the workload retried the request, entries expire pendingRequestTTL after it
+	refreshedAt time.Time
+	// filters are applied again on every drain with storeMutex held, they must not read the API server,
+	// nodes of the node affinity are resolved when the request is queued or retried
+	filterRegistry *filter.FilterRegistry
+	strategy       Strategy
+	quotas         []tfv1.GPUResourceQuota
+}
+
+// reservation holds GPUs allocated for a queued request until the workload controller takes them,
+// GPUs of each replica for a gang, or of the only replica otherwise
+type reservation struct {
+	req          AllocRequest
+	replicaGPUs  [][]*tfv1.GPU
+	gangReplicas int
+}
+
+// matches returns whether the reserved GPUs were allocated for the same request,
+// any change of the workload's GPU requirements makes the reservation stale
+func (r *reservation) matches(req AllocRequest) bool {
+	return r.req.PoolName == req.PoolName &&
+		r.req.Count == req.Count &&
+		r.req.GPUModel == req.GPUModel &&
+		r.req.QoS == req.QoS &&
+		r.req.CrossNode == req.CrossNode &&
+		r.req.LocalGPU == req.LocalGPU &&
+		r.req.Request.Tflops.Equal(req.Request.Tflops) &&
+		r.req.Request.Vram.Equal(req.Request.Vram) &&
+		r.req.Limit.Tflops.Equal(req.Limit.Tflops) &&
+		r.req.Limit.Vram.Equal(req.Limit.Vram) &&
+		equality.Semantic.DeepEqual(r.req.NodeAffinity, req.NodeAffinity)
+}
+
+// gpuKeys returns keys of all reserved GPUs
+func (r *reservation) gpuKeys() []types.NamespacedName {
+	return lo.FlatMap(r.replicaGPUs, func(gpus []*tfv1.GPU, _ int) []types.NamespacedName {
+		return lo.Map(gpus, func(gpu *tfv1.GPU, _ int) types.NamespacedName {
+			return client.ObjectKeyFromObject(gpu)
+		})
+	})
+}
+
+// expired returns whether the workload stopped retrying the request
+func (p *pendingRequest) expired(now time.Time) bool {
+	return now.Sub(p.refreshedAt) > pendingRequestTTL
+}
+
+// QueueEvents returns the channel notified with the workload when GPUs are reserved for its queued request
+func (s *GpuAllocator) QueueEvents() <-chan event.GenericEvent {
+	return s.queueEvents
+}
+
+// QueuePosition returns the position of the workload in its pool's pending queue starting from 1, 0 means not queued
+func (s *GpuAllocator) QueuePosition(workloadNameNamespace tfv1.NameNamespace) int32 {
+	s.storeMutex.RLock()
+	defer s.storeMutex.RUnlock()
+
+	now := time.Now()
+	for _, queue := range s.pendingQueues {
+		live := lo.Reject(queue, func(pending *pendingRequest, _ int) bool {
+			return pending.expired(now)
+		})
+		for i, pending := range orderPendingRequests(live) {
+			if pending.req.WorkloadNameNamespace == workloadNameNamespace {
+				return int32(i + 1)
+			}
+		}
+	}
+	return 0
+}
+
+// CancelPending removes the workload from pending queue and releases GPUs reserved for it but not taken yet
+func (s *GpuAllocator) CancelPending(ctx context.Context, workloadNameNamespace tfv1.NameNamespace) {
+	s.storeMutex.Lock()
+	defer s.storeMutex.Unlock()
+
+	s.dequeueLocked(workloadNameNamespace)
+	if reserved, ok := s.takeReservationLocked(workloadNameNamespace); ok {
+		s.deallocLocked(ctx, workloadNameNamespace, reserved.req.resources(), reserved.gpuKeys())
+	}
+}
+
+// enqueueLocked adds or refreshes the request in its pool's pending queue, the enqueue time is kept
+// when the workload is already queued so that it does not lose its place on retry,
+// expired entries of the pool are dropped at the same time
+func (s *GpuAllocator) enqueueLocked(pending *pendingRequest) {
+	now := time.Now()
+	pending.refreshedAt = now
+	queue := s.expireLocked(pending.req.PoolName, now)
+	for i, queued := range queue {
+		if queued.req.WorkloadNameNamespace == pending.req.WorkloadNameNamespace {
+			pending.enqueuedAt = queued.enqueuedAt
+			queue[i] = pending
+			return
+		}
+	}
+	s.pendingQueues[pending.req.PoolName] = append(queue, pending)
+}
+
+func (s *GpuAllocator) dequeueLocked(workloadNameNamespace tfv1.NameNamespace) {
+	for poolName, queue := range s.pendingQueues {
+		s.pendingQueues[poolName] = lo.Reject(queue, func(pending *pendingRequest, _ int) bool {
+			return pending.req.WorkloadNameNamespace == workloadNameNamespace
+		})
+	}
+}
+
+// expireLocked drops requests of the pool whose workload stopped retrying and returns the remaining queue
+func (s *GpuAllocator) expireLocked(poolName string, now time.Time) []*pendingRequest {
+	queue := lo.Reject(s.pendingQueues[poolName], func(pending *pendingRequest, _ int) bool {
+		return pending.expired(now)
+	})
+	s.pendingQueues[poolName] = queue
+	return queue
+}
+
+// takeReservationLocked returns GPUs reserved for the workload while draining the queue
+func (s *GpuAllocator) takeReservationLocked(workloadNameNamespace tfv1.NameNamespace) (*reservation, bool) {
+	reserved, ok := s.reservations[workloadNameNamespace]
+	if ok {
+		delete(s.reservations, workloadNameNamespace)
+	}
+	return reserved, ok
+}
+
+// drainQueueLocked reserves GPUs for queued requests of the pool in queue order after capacity is freed,
+// requests which still do not fit stay in the queue and smaller requests behind them can go first
+func (s *GpuAllocator) drainQueueLocked(ctx context.Context, poolName string) {
+	queue := s.expireLocked(poolName, time.Now())
+	if len(queue) == 0 {
+		return
+	}
+
+	remaining := []*pendingRequest{}
+	for _, pending := range orderPendingRequests(queue) {
+		var replicaGPUs [][]*tfv1.GPU
+		var err error
+		if pending.gangReplicas > 0 {
+			replicaGPUs, err = s.allocGangLocked(ctx, pending.req, pending.gangReplicas, pending.quotas, pending.filterRegistry, pending.strategy)
+		} else {
+			var gpus []*tfv1.GPU
+			gpus, err = s.allocLocked(ctx, pending.req, pending.quotas, pending.filterRegistry, pending.strategy)
+			replicaGPUs = [][]*tfv1.GPU{gpus}
+		}
+		if err != nil {
+			remaining = append(remaining, pending)
+			continue
+		}
+		s.reservations[pending.req.WorkloadNameNamespace] = &reservation{
+			req:          pending.req,
+			replicaGPUs:  replicaGPUs,
+			gangReplicas: pending.gangReplicas,
+		}
+		log.FromContext(ctx).Info("Reserved GPUs for queued workload", "pool", poolName,
+			"workload", pending.req.WorkloadNameNamespace.Name, "namespace", pending.req.WorkloadNameNamespace.Namespace)
+		s.notifyQueued(pending.req.WorkloadNameNamespace)
+	}
+	s.pendingQueues[poolName] = remaining
+}
+
+// notifyQueued triggers reconcile of the workload without blocking allocation when nobody listens
+func (s *GpuAllocator) notifyQueued(workloadNameNamespace tfv1.NameNamespace) {
+	select {
+	case s.queueEvents <- event.GenericEvent{Object: &tfv1.TensorFusionWorkload{
+		ObjectMeta: metav1.ObjectMeta{Name: workloadNameNamespace.Name, Namespace: workloadNameNamespace.Namespace},
+	}}:
+	default:
+	}
+}
+
+// orderPendingRequests orders requests by QoS priority, then shares the same priority fairly between
+// namespaces in a round-robin manner, the oldest request of each namespace goes first
+func orderPendingRequests(queue []*pendingRequest) []*pendingRequest {
+	ordered := make([]*pendingRequest, len(queue))
+	copy(ordered, queue)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].enqueuedAt.Before(ordered[j].enqueuedAt)
+	})
+
+	// rank of each request among requests of the same namespace and priority
+	type shareKey struct {
+		namespace string
+		priority  int
+	}
+	counts := make(map[shareKey]int)
+	ranks := make(map[*pendingRequest]int, len(ordered))
+	for _, pending := range ordered {
+		key := shareKey{namespace: pending.req.WorkloadNameNamespace.Namespace, priority: pending.priority}
+		ranks[pending] = counts[key]
+		counts[key]++
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].priority != ordered[j].priority {
+			return ordered[i].priority > ordered[j].priority
+		}
+		return ranks[ordered[i]] < ranks[ordered[j]]
+	})
+	return ordered
+}
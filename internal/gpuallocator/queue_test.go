@@ -0,0 +1,195 @@
+package gpuallocator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/NexusGPU/tensor-fusion/internal/gpuallocator/filter"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestOrderPendingRequests(t *testing.T) {
+	now := time.Now()
+	newPending := func(namespace, name string, priority int, age time.Duration) *pendingRequest {
+		return &pendingRequest{
+			req:        AllocRequest{WorkloadNameNamespace: tfv1.NameNamespace{Namespace: namespace, Name: name}},
+			priority:   priority,
+			enqueuedAt: now.Add(-age),
+		}
+	}
+	queue := []*pendingRequest{
+		newPending("team-a", "a1", 50, 4*time.Minute),
+		newPending("team-a", "a2", 50, 3*time.Minute),
+		newPending("team-a", "a3", 50, 2*time.Minute),
+		newPending("team-b", "b1", 50, 1*time.Minute),
+		newPending("team-b", "critical", 100, 0),
+	}
+
+	ordered := lo.Map(orderPendingRequests(queue), func(pending *pendingRequest, _ int) string {
+		return pending.req.WorkloadNameNamespace.Name
+	})
+	// higher priority first, then namespaces take turns
+	assert.Equal(t, []string{"critical", "a1", "b1", "a2", "a3"}, ordered)
+}
+
+func TestDrainQueueOnDealloc(t *testing.T) {
+	ctx := context.Background()
+	gpuKey := types.NamespacedName{Name: "gpu-1"}
+	gpu := &tfv1.GPU{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   gpuKey.Name,
+			Labels: map[string]string{constants.GpuPoolKey: "pool-a", constants.LabelKeyOwner: "node-1"},
+		},
+		Status: tfv1.GPUStatus{
+			Phase:     tfv1.TensorFusionGPUPhaseRunning,
+			Capacity:  &tfv1.Resource{Tflops: resource.MustParse("100"), Vram: resource.MustParse("10Gi")},
+			Available: &tfv1.Resource{Tflops: resource.MustParse("10"), Vram: resource.MustParse("1Gi")},
+		},
+	}
+	allocator := &GpuAllocator{
+		gpuStore:      map[types.NamespacedName]*tfv1.GPU{gpuKey: gpu},
+		dirtyQueue:    make(map[types.NamespacedName]struct{}),
+		pendingQueues: make(map[string][]*pendingRequest),
+		reservations:  make(map[tfv1.NameNamespace]*reservation),
+		queueEvents:   make(chan event.GenericEvent, 1),
+		quotaUsage:    make(map[quotaUsageKey]*tfv1.GPUResourceQuotaAmounts),
+	}
+
+	request := tfv1.Resource{Tflops: resource.MustParse("50"), Vram: resource.MustParse("4Gi")}
+	queued := AllocRequest{
+		PoolName:              "pool-a",
+		WorkloadNameNamespace: tfv1.NameNamespace{Namespace: "default", Name: "queued"},
+		Request:               request,
+		Count:                 1,
+	}
+	allocator.enqueueLocked(&pendingRequest{
+		req:            queued,
+		priority:       50,
+		enqueuedAt:     time.Now(),
+		filterRegistry: filter.NewFilterRegistry().With(filter.NewResourceFilter(request)),
+		strategy:       CompactFirst{},
+	})
+	assert.Equal(t, int32(1), allocator.QueuePosition(queued.WorkloadNameNamespace))
+
+	allocator.Dealloc(ctx, tfv1.NameNamespace{Namespace: "default", Name: "running"},
+		tfv1.Resources{Requests: tfv1.Resource{Tflops: resource.MustParse("40"), Vram: resource.MustParse("3Gi")}}, []types.NamespacedName{gpuKey})
+
+	assert.Equal(t, int32(0), allocator.QueuePosition(queued.WorkloadNameNamespace))
+	require.Contains(t, allocator.reservations, queued.WorkloadNameNamespace)
+	assert.Equal(t, "0", gpu.Status.Available.Tflops.String())
+	notified := <-allocator.QueueEvents()
+	assert.Equal(t, "queued", notified.Object.GetName())
+
+	// reserved GPUs are released when the workload no longer needs them
+	allocator.CancelPending(ctx, queued.WorkloadNameNamespace)
+	assert.Empty(t, allocator.reservations)
+	assert.Equal(t, "50", gpu.Status.Available.Tflops.String())
+}
+
+func TestReservationMatches(t *testing.T) {
+	req := AllocRequest{
+		PoolName: "pool-a",
+		Request:  tfv1.Resource{Tflops: resource.MustParse("50"), Vram: resource.MustParse("4Gi")},
+		Count:    1,
+		GPUModel: "A100",
+	}
+	reserved := &reservation{req: req}
+	assert.True(t, reserved.matches(req))
+
+	changed := req
+	changed.GPUModel = "H100"
+	assert.False(t, reserved.matches(changed))
+
+	changed = req
+	changed.QoS = tfv1.QoSCritical
+	assert.False(t, reserved.matches(changed))
+
+	changed = req
+	changed.NodeAffinity = &v1.NodeAffinity{}
+	assert.False(t, reserved.matches(changed))
+}
+
+func TestExpirePendingRequests(t *testing.T) {
+	allocator := &GpuAllocator{pendingQueues: make(map[string][]*pendingRequest)}
+	stale := &pendingRequest{
+		req:        AllocRequest{PoolName: "pool-a", WorkloadNameNamespace: tfv1.NameNamespace{Namespace: "default", Name: "stale"}},
+		enqueuedAt: time.Now().Add(-time.Hour),
+	}
+	allocator.enqueueLocked(stale)
+	stale.refreshedAt = time.Now().Add(-pendingRequestTTL - time.Minute)
+	assert.Equal(t, int32(0), allocator.QueuePosition(stale.req.WorkloadNameNamespace))
+
+	live := AllocRequest{PoolName: "pool-a", WorkloadNameNamespace: tfv1.NameNamespace{Namespace: "default", Name: "live"}}
+	allocator.enqueueLocked(&pendingRequest{req: live, enqueuedAt: time.Now()})
+	require.Len(t, allocator.pendingQueues["pool-a"], 1)
+	assert.Equal(t, int32(1), allocator.QueuePosition(live.WorkloadNameNamespace))
+}
+
+func TestDrainQueueReservesGang(t *testing.T) {
+	ctx := context.Background()
+	gpuKey := types.NamespacedName{Name: "gpu-1"}
+	gpu := &tfv1.GPU{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   gpuKey.Name,
+			Labels: map[string]string{constants.GpuPoolKey: "pool-a", constants.LabelKeyOwner: "node-1"},
+		},
+		Status: tfv1.GPUStatus{
+			Phase:     tfv1.TensorFusionGPUPhaseRunning,
+			Capacity:  &tfv1.Resource{Tflops: resource.MustParse("100"), Vram: resource.MustParse("10Gi")},
+			Available: &tfv1.Resource{Tflops: resource.MustParse("50"), Vram: resource.MustParse("5Gi")},
+		},
+	}
+	allocator := &GpuAllocator{
+		gpuStore:      map[types.NamespacedName]*tfv1.GPU{gpuKey: gpu},
+		dirtyQueue:    make(map[types.NamespacedName]struct{}),
+		pendingQueues: make(map[string][]*pendingRequest),
+		reservations:  make(map[tfv1.NameNamespace]*reservation),
+		queueEvents:   make(chan event.GenericEvent, 1),
+		quotaUsage:    make(map[quotaUsageKey]*tfv1.GPUResourceQuotaAmounts),
+	}
+
+	request := tfv1.Resource{Tflops: resource.MustParse("40"), Vram: resource.MustParse("2Gi")}
+	gang := AllocRequest{
+		PoolName:              "pool-a",
+		WorkloadNameNamespace: tfv1.NameNamespace{Namespace: "default", Name: "gang"},
+		Request:               request,
+		Count:                 1,
+	}
+	allocator.enqueueLocked(&pendingRequest{
+		req:            gang,
+		priority:       50,
+		enqueuedAt:     time.Now(),
+		gangReplicas:   2,
+		filterRegistry: filter.NewFilterRegistry().With(filter.NewResourceFilter(request)),
+		strategy:       CompactFirst{},
+	})
+
+	// only one replica fits, nothing is reserved
+	allocator.storeMutex.Lock()
+	allocator.drainQueueLocked(ctx, "pool-a")
+	allocator.storeMutex.Unlock()
+	assert.Empty(t, allocator.reservations)
+	assert.Equal(t, "50", gpu.Status.Available.Tflops.String())
+
+	allocator.Dealloc(ctx, tfv1.NameNamespace{Namespace: "default", Name: "running"},
+		tfv1.Resources{Requests: tfv1.Resource{Tflops: resource.MustParse("30"), Vram: resource.MustParse("1Gi")}}, []types.NamespacedName{gpuKey})
+
+	require.Contains(t, allocator.reservations, gang.WorkloadNameNamespace)
+	reserved := allocator.reservations[gang.WorkloadNameNamespace]
+	assert.Len(t, reserved.replicaGPUs, 2)
+	assert.Equal(t, 2, reserved.gangReplicas)
+	assert.Equal(t, "0", gpu.Status.Available.Tflops.String())
+
+	allocator.CancelPending(ctx, gang.WorkloadNameNamespace)
+	assert.Equal(t, "80", gpu.Status.Available.Tflops.String(), "GPUs of all replicas are released")
+}
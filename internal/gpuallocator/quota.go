@@ -0,0 +1,91 @@
+package gpuallocator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrQuotaExceeded is returned by Alloc when the request goes beyond a GPUResourceQuota of its namespace
+var ErrQuotaExceeded = errors.New("gpu resource quota exceeded")
+
+type quotaUsageKey struct {
+	namespace string
+	poolName  string
+}
+
+// QuotaUsage returns GPU resources consumed by workers of the namespace allocated from the pool,
+// the usage of all pools is returned when poolName is empty
+func (s *GpuAllocator) QuotaUsage(namespace string, poolName string) tfv1.GPUResourceQuotaAmounts {
+	s.storeMutex.RLock()
+	defer s.storeMutex.RUnlock()
+	return s.quotaUsageLocked(namespace, poolName)
+}
+
+func (s *GpuAllocator) quotaUsageLocked(namespace string, poolName string) tfv1.GPUResourceQuotaAmounts {
+	used := tfv1.NewGPUResourceQuotaAmounts(tfv1.Resources{}, 0, 0)
+	for key, usage := range s.quotaUsage {
+		if key.namespace == namespace && (poolName == "" || key.poolName == poolName) {
+			used.Add(*usage)
+		}
+	}
+	return used
+}
+
+// listQuotas returns quotas of the namespace which apply to the pool
+func (s *GpuAllocator) listQuotas(ctx context.Context, namespace string, poolName string) ([]tfv1.GPUResourceQuota, error) {
+	quotas := &tfv1.GPUResourceQuotaList{}
+	if err := s.List(ctx, quotas, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("list gpu resource quotas: %w", err)
+	}
+	return lo.Filter(quotas.Items, func(quota tfv1.GPUResourceQuota, _ int) bool {
+		return quota.AppliesTo(poolName)
+	}), nil
+}
+
+// quotaCharge returns the quota amounts taken by resources of the workload on the GPUs,
+// one worker is counted for each node the GPUs are on
+func quotaCharge(resources tfv1.Resources, gpus []*tfv1.GPU) tfv1.GPUResourceQuotaAmounts {
+	nodes := lo.Uniq(lo.Map(gpus, func(gpu *tfv1.GPU, _ int) string {
+		return gpu.Labels[constants.LabelKeyOwner]
+	}))
+	return tfv1.NewGPUResourceQuotaAmounts(resources, int64(len(gpus)), int32(len(nodes)))
+}
+
+// checkQuotaLocked returns ErrQuotaExceeded when the request allocated on the selected GPUs does not fit the quotas
+func (s *GpuAllocator) checkQuotaLocked(req AllocRequest, quotas []tfv1.GPUResourceQuota, gpus []*tfv1.GPU) error {
+	charge := quotaCharge(req.resources(), gpus)
+	for _, quota := range quotas {
+		used := s.quotaUsageLocked(quota.Namespace, quota.Spec.PoolName)
+		if exceeded := quota.Exceeded(used, charge); len(exceeded) > 0 {
+			return fmt.Errorf("%w %s: %s", ErrQuotaExceeded, quota.Name, strings.Join(exceeded, ", "))
+		}
+	}
+	return nil
+}
+
+// chargeQuotaLocked adds or removes resources of the workload on the GPUs to the quota usage
+func (s *GpuAllocator) chargeQuotaLocked(namespace string, resources tfv1.Resources, gpus []*tfv1.GPU, release bool) {
+	if len(gpus) == 0 {
+		return
+	}
+	key := quotaUsageKey{namespace: namespace, poolName: gpus[0].Labels[constants.GpuPoolKey]}
+	usage, ok := s.quotaUsage[key]
+	if !ok {
+		usage = &tfv1.GPUResourceQuotaAmounts{}
+		s.quotaUsage[key] = usage
+	}
+
+	charge := quotaCharge(resources, gpus)
+	if release {
+		usage.Sub(charge)
+	} else {
+		usage.Add(charge)
+	}
+}
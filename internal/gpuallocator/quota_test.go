@@ -0,0 +1,81 @@
+package gpuallocator
+
+import (
+	"errors"
+	"testing"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestCheckQuota(t *testing.T) {
+	allocator := &GpuAllocator{quotaUsage: make(map[quotaUsageKey]*tfv1.GPUResourceQuotaAmounts)}
+	newGPU := func(name, pool, node string) *tfv1.GPU {
+		return &tfv1.GPU{ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{constants.GpuPoolKey: pool, constants.LabelKeyOwner: node},
+		}}
+	}
+	resources := tfv1.Resources{
+		Requests: tfv1.Resource{Tflops: resource.MustParse("30"), Vram: resource.MustParse("4Gi")},
+		Limits:   tfv1.Resource{Tflops: resource.MustParse("60"), Vram: resource.MustParse("8Gi")},
+	}
+	req := AllocRequest{
+		PoolName:              "pool-a",
+		WorkloadNameNamespace: tfv1.NameNamespace{Namespace: "team-a", Name: "workload"},
+		Request:               resources.Requests,
+		Limit:                 resources.Limits,
+		Count:                 1,
+	}
+	quotas := []tfv1.GPUResourceQuota{{
+		ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: "team-a"},
+		Spec: tfv1.GPUResourceQuotaSpec{
+			PoolName: "pool-a",
+			Hard: tfv1.GPUResourceQuotaAmounts{
+				RequestsTflops: ptr.To(resource.MustParse("100")),
+				Workers:        ptr.To(int32(2)),
+			},
+		},
+	}}
+
+	single := []*tfv1.GPU{newGPU("gpu-1", "pool-a", "node-1")}
+	assert.NoError(t, allocator.checkQuotaLocked(req, quotas, single))
+
+	// a cross-node allocation runs one worker on each node
+	allocator.chargeQuotaLocked("team-a", resources, []*tfv1.GPU{
+		newGPU("gpu-1", "pool-a", "node-1"),
+		newGPU("gpu-2", "pool-a", "node-2"),
+	}, false)
+	// usage of other namespaces and pools is not counted
+	allocator.chargeQuotaLocked("team-b", resources, []*tfv1.GPU{newGPU("gpu-3", "pool-a", "node-1")}, false)
+	allocator.chargeQuotaLocked("team-a", resources, []*tfv1.GPU{newGPU("gpu-4", "pool-b", "node-3")}, false)
+
+	used := allocator.quotaUsageLocked("team-a", "pool-a")
+	assert.Equal(t, "60", used.RequestsTflops.String())
+	assert.Equal(t, "120", used.LimitsTflops.String())
+	assert.Equal(t, int32(2), *used.Workers)
+	assert.Equal(t, "90", allocator.quotaUsageLocked("team-a", "").RequestsTflops.String())
+
+	err := allocator.checkQuotaLocked(req, quotas, single)
+	assert.True(t, errors.Is(err, ErrQuotaExceeded))
+	assert.Contains(t, err.Error(), "workers: 3/2")
+
+	allocator.chargeQuotaLocked("team-a", resources, []*tfv1.GPU{newGPU("gpu-1", "pool-a", "node-1")}, true)
+	assert.NoError(t, allocator.checkQuotaLocked(req, quotas, single))
+
+	// a cross-node request is checked with one worker for each node, the same as it is charged
+	crossNode := req
+	crossNode.Count = 2
+	crossNode.CrossNode = true
+	crossNodeGPUs := []*tfv1.GPU{newGPU("gpu-5", "pool-a", "node-1"), newGPU("gpu-6", "pool-a", "node-2")}
+	err = allocator.checkQuotaLocked(crossNode, quotas, crossNodeGPUs)
+	assert.True(t, errors.Is(err, ErrQuotaExceeded))
+	assert.Contains(t, err.Error(), "workers: 3/2")
+	assert.NoError(t, allocator.checkQuotaLocked(crossNode, quotas, []*tfv1.GPU{
+		newGPU("gpu-5", "pool-a", "node-1"), newGPU("gpu-7", "pool-a", "node-1"),
+	}))
+}
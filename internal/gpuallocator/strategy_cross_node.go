@@ -0,0 +1,59 @@
+package gpuallocator
+
+import (
+	"fmt"
+	"sort"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+)
+
+// selectGPUsAcrossNodes selects GPUs spread over as few nodes as possible, nodes with more
+// eligible GPUs are filled first to reduce cross-node traffic, GPUs inside a node are picked by the strategy
+func selectGPUsAcrossNodes(strategy Strategy, gpus []tfv1.GPU, count uint) ([]*tfv1.GPU, error) {
+	if uint(len(gpus)) < count {
+		return nil, fmt.Errorf("only %d GPUs available across nodes, requested %d", len(gpus), count)
+	}
+
+	gpusByNode := make(map[string][]tfv1.GPU)
+	for _, gpu := range gpus {
+		nodeName, exists := gpu.Labels[constants.LabelKeyOwner]
+		if !exists {
+			continue
+		}
+		gpusByNode[nodeName] = append(gpusByNode[nodeName], gpu)
+	}
+
+	nodeNames := make([]string, 0, len(gpusByNode))
+	for nodeName := range gpusByNode {
+		nodeNames = append(nodeNames, nodeName)
+	}
+	sort.Slice(nodeNames, func(i, j int) bool {
+		countI, countJ := len(gpusByNode[nodeNames[i]]), len(gpusByNode[nodeNames[j]])
+		if countI != countJ {
+			return countI > countJ
+		}
+		return nodeNames[i] < nodeNames[j]
+	})
+
+	result := make([]*tfv1.GPU, 0, count)
+	remaining := count
+	for _, nodeName := range nodeNames {
+		if remaining == 0 {
+			break
+		}
+		nodeGPUs := gpusByNode[nodeName]
+		take := min(uint(len(nodeGPUs)), remaining)
+		selected, err := strategy.SelectGPUs(nodeGPUs, take)
+		if err != nil {
+			return nil, fmt.Errorf("select GPUs on node %s: %w", nodeName, err)
+		}
+		result = append(result, selected...)
+		remaining -= take
+	}
+
+	if remaining > 0 {
+		return nil, fmt.Errorf("not enough GPUs with node labels, requested %d, selected %d", count, len(result))
+	}
+	return result, nil
+}
@@ -0,0 +1,53 @@
+package gpuallocator
+
+import (
+	"testing"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSelectGPUsAcrossNodes(t *testing.T) {
+	newGPU := func(name, node string) tfv1.GPU {
+		return tfv1.GPU{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{constants.LabelKeyOwner: node},
+			},
+			Status: tfv1.GPUStatus{
+				Available: &tfv1.Resource{
+					Tflops: resource.MustParse("100"),
+					Vram:   resource.MustParse("40Gi"),
+				},
+			},
+		}
+	}
+	gpus := []tfv1.GPU{
+		newGPU("gpu-1-1", "node-1"),
+		newGPU("gpu-2-1", "node-2"),
+		newGPU("gpu-2-2", "node-2"),
+		newGPU("gpu-2-3", "node-2"),
+		newGPU("gpu-3-1", "node-3"),
+		newGPU("gpu-3-2", "node-3"),
+	}
+
+	t.Run("fill nodes with more GPUs first", func(t *testing.T) {
+		selected, err := selectGPUsAcrossNodes(CompactFirst{}, gpus, 5)
+		assert.NoError(t, err)
+		assert.Len(t, selected, 5)
+
+		nodes := map[string]int{}
+		for _, gpu := range selected {
+			nodes[gpu.Labels[constants.LabelKeyOwner]]++
+		}
+		assert.Equal(t, map[string]int{"node-2": 3, "node-3": 2}, nodes)
+	})
+
+	t.Run("not enough GPUs", func(t *testing.T) {
+		_, err := selectGPUsAcrossNodes(CompactFirst{}, gpus, 7)
+		assert.Error(t, err)
+	})
+}
@@ -3,7 +3,10 @@ package metrics
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
 	"github.com/NexusGPU/tensor-fusion/internal/constants"
 	"github.com/NexusGPU/tensor-fusion/internal/utils"
 	"gorm.io/driver/mysql"
@@ -124,3 +127,105 @@ func (t *TimeSeriesDB) FindRecentNodeMetrics() ([]NodeResourceMetrics, error) {
 	}).Error
 	return monitors, err
 }
+
+// WorkerUsageSummary is the usage of one worker aggregated over a time window
+type WorkerUsageSummary struct {
+	WorkerName    string  `gorm:"column:worker"`
+	ComputeTflops float64 `gorm:"column:compute_tflops"`
+	VRAMBytes     float64 `gorm:"column:memory_bytes"`
+	// Times the worker is throttled by its TFlops limit
+	ThrottledCount int64 `gorm:"column:compute_throttled_cnt"`
+}
+
+// workerUsageOf selects usage of workers of the workload in the pool since the given time. Rows written by
+// hypervisors not reporting namespace yet are matched by workload name only, so that usage is not lost
+// while hypervisors are upgraded, at the cost of mixing up workloads of the same name in other namespaces
+func workerUsageOf(workload tfv1.NameNamespace, pool string, since time.Time) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("workload = ? AND (namespace = ? OR namespace IS NULL OR namespace = '') AND pool = ? AND ts > ?",
+			workload.Name, workload.Namespace, pool, since)
+	}
+}
+
+// FindWorkerUsage returns the average usage of each worker of the workload since the given time
+func (t *TimeSeriesDB) FindWorkerUsage(workload tfv1.NameNamespace, pool string, since time.Time) ([]WorkerUsageSummary, error) {
+	var usages []WorkerUsageSummary
+	err := t.DB.Model(&HypervisorWorkerUsageMetrics{}).
+		Select("worker, avg(compute_tflops) AS compute_tflops, avg(memory_bytes) AS memory_bytes").
+		Scopes(workerUsageOf(workload, pool, since)).
+		Group("worker").
+		Scan(&usages).Error
+	return usages, err
+}
+
+// FindWorkerUsageSamples returns the usage of each worker of the workload since the given time,
+// aggregated by the given period, TFlops are averaged and VRAM takes the peak of each period
+func (t *TimeSeriesDB) FindWorkerUsageSamples(workload tfv1.NameNamespace, pool string, since time.Time, aggregation time.Duration) ([]WorkerUsageSummary, error) {
+	var samples []WorkerUsageSummary
+	bucket := fmt.Sprintf("date_bin(INTERVAL '%d seconds', ts)", int64(aggregation.Seconds()))
+	err := t.DB.Model(&HypervisorWorkerUsageMetrics{}).
+		Select("worker, " + bucket + " AS bucket, avg(compute_tflops) AS compute_tflops, max(memory_bytes) AS memory_bytes").
+		Scopes(workerUsageOf(workload, pool, since)).
+		Group("worker, bucket").
+		Scan(&samples).Error
+	return samples, err
+}
+
+// FindWorkerThrottling returns the peak usage and throttled times of each worker of the workload since the given time
+func (t *TimeSeriesDB) FindWorkerThrottling(workload tfv1.NameNamespace, pool string, since time.Time) ([]WorkerUsageSummary, error) {
+	var usages []WorkerUsageSummary
+	err := t.DB.Model(&HypervisorWorkerUsageMetrics{}).
+		Select("worker, max(compute_tflops) AS compute_tflops, max(memory_bytes) AS memory_bytes, sum(compute_throttled_cnt) AS compute_throttled_cnt").
+		Scopes(workerUsageOf(workload, pool, since)).
+		Group("worker").
+		Scan(&usages).Error
+	return usages, err
+}
+
+// WorkerActivity is the time range covered by usage samples of a worker and the last time it used any TFlops
+type WorkerActivity struct {
+	WorkerName  string    `gorm:"column:worker"`
+	FirstSample time.Time `gorm:"column:first_sample"`
+	LastSample  time.Time `gorm:"column:last_sample"`
+	// nil when the worker used no TFlops in any sample
+	LastActive *time.Time `gorm:"column:last_active"`
+}
+
+// FindWorkerActivity returns the usage samples range and the last time each worker of the workload used
+// any TFlops since the given time, workers without samples in the period are not returned
+func (t *TimeSeriesDB) FindWorkerActivity(workload tfv1.NameNamespace, pool string, since time.Time) ([]WorkerActivity, error) {
+	var activities []WorkerActivity
+	err := t.DB.Model(&HypervisorWorkerUsageMetrics{}).
+		Select("worker, min(ts) AS first_sample, max(ts) AS last_sample, max(CASE WHEN compute_tflops > 0 THEN ts END) AS last_active").
+		Scopes(workerUsageOf(workload, pool, since)).
+		Group("worker").
+		Scan(&activities).Error
+	return activities, err
+}
+
+// GPUUsageSummary is the usage of one GPU aggregated over a time window
+type GPUUsageSummary struct {
+	// lowercased to match UUIDs in GPU status reported by node discovery
+	UUID           string  `gorm:"column:uuid"`
+	NodeName       string  `gorm:"column:node_name"`
+	ComputePercent float64 `gorm:"column:compute_percentage"`
+	VRAMPercent    float64 `gorm:"column:memory_percentage"`
+	Temperature    float64 `gorm:"column:temperature"`
+}
+
+// FindGPUUsage returns the average usage of each GPU of the pool since the given time
+func (t *TimeSeriesDB) FindGPUUsage(pool string, since time.Time) ([]GPUUsageSummary, error) {
+	var usages []GPUUsageSummary
+	err := t.DB.Model(&HypervisorGPUUsageMetrics{}).
+		Select("uuid, node_name, avg(compute_percentage) AS compute_percentage, avg(memory_percentage) AS memory_percentage, avg(temperature) AS temperature").
+		Where("pool = ? AND ts > ?", pool, since).
+		Group("uuid, node_name").
+		Scan(&usages).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range usages {
+		usages[i].UUID = strings.ToLower(usages[i].UUID)
+	}
+	return usages, nil
+}
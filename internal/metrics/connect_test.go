@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestWorkerUsageOfMatchesRowsWithoutNamespace(t *testing.T) {
+	db, err := gorm.Open(mysql.New(mysql.Config{SkipInitializeWithVersion: true}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	require.NoError(t, err)
+
+	sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		var usages []HypervisorWorkerUsageMetrics
+		return tx.Scopes(workerUsageOf(tfv1.NameNamespace{Namespace: "team-a", Name: "llm"}, "pool-a", time.Unix(0, 0).UTC())).
+			Find(&usages)
+	})
+	assert.Contains(t, sql, "workload = 'llm' AND (namespace = 'team-a' OR namespace IS NULL OR namespace = '') AND pool = 'pool-a'")
+}
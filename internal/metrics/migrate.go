@@ -16,13 +16,20 @@ var TFVersionMigrationMap = []struct {
 
 		"CREATE TABLE IF NOT EXISTS tf_system_log (\n    `component` String NULL INVERTED INDEX,\n    `container` String NULL INVERTED INDEX,\n    `message` String NULL FULLTEXT INDEX WITH (analyzer = 'English' , case_sensitive = 'false'),\n    `namespace` String NULL INVERTED INDEX,\n    `pod` String NULL SKIPPING INDEX,\n    `stream` String NULL,\n    `timestamp` String NULL,\n    `greptime_timestamp` Timestamp_ms TIME INDEX,\n    PRIMARY KEY (`component`, `container`, `namespace`, `pod`))\n    ENGINE=mito WITH( ttl='30d', merge_mode = 'last_non_null')",
 
-		"CREATE TABLE IF NOT EXISTS tf_worker_usage (\n    `workload` String NULL INVERTED INDEX,\n    `worker` String NULL SKIPPING INDEX,\n    `pool` String NULL INVERTED INDEX,\n    `node_name` String NULL INVERTED INDEX,\n    `uuid` String NULL INVERTED INDEX,\n    `compute_percentage` Double NULL,\n    `memory_bytes` BigInt UNSIGNED NULL,\n    `compute_tflops` Double NULL,\n    `compute_throttled_cnt` BigInt NULL,\n    `vram_freezed_cnt` BigInt NULL,\n    `vram_resumed_cnt` BigInt NULL,\n    `ts` Timestamp_ns TIME INDEX,\n    PRIMARY KEY (`workload`, `worker`, `pool`, `node_name`, `uuid`))\n    ENGINE=mito WITH( ttl='30d', merge_mode = 'last_non_null')",
+		"CREATE TABLE IF NOT EXISTS tf_worker_usage (\n    `workload` String NULL INVERTED INDEX,\n    `worker` String NULL SKIPPING INDEX,\n    `pool` String NULL INVERTED INDEX,\n    `node_name` String NULL INVERTED INDEX,\n    `uuid` String NULL INVERTED INDEX,\n    `namespace` String NULL INVERTED INDEX,\n    `compute_percentage` Double NULL,\n    `memory_bytes` BigInt UNSIGNED NULL,\n    `compute_tflops` Double NULL,\n    `compute_throttled_cnt` BigInt NULL,\n    `vram_freezed_cnt` BigInt NULL,\n    `vram_resumed_cnt` BigInt NULL,\n    `ts` Timestamp_ns TIME INDEX,\n    PRIMARY KEY (`workload`, `worker`, `pool`, `node_name`, `uuid`, `namespace`))\n    ENGINE=mito WITH( ttl='30d', merge_mode = 'last_non_null')",
 
 		"CREATE TABLE IF NOT EXISTS tf_gpu_usage (\n    `node_name` String NULL INVERTED INDEX,\n    `pool` String NULL INVERTED INDEX,\n    `uuid` String NULL INVERTED INDEX,\n    `compute_percentage` Double NULL,\n    `memory_percentage` Double NULL,\n    `memory_bytes` BigInt UNSIGNED NULL,\n    `compute_tflops` Double NULL,\n    `rx` Double NULL,\n    `tx` Double NULL,\n    `temperature` Double NULL,\n    `ts` Timestamp_ns TIME INDEX,\n    PRIMARY KEY (`node_name`, `pool`, `uuid`))\n    ENGINE=mito WITH( ttl='30d', merge_mode = 'last_non_null')",
 	}},
 
+	// namespace of worker usage, so that workloads of the same name in different namespaces are not mixed up,
+	// tables created by the init SQL above already have it
+	{"1.1", []string{
+		"ALTER TABLE tf_worker_usage ADD COLUMN IF NOT EXISTS `namespace` String NULL PRIMARY KEY",
+		"ALTER TABLE tf_worker_usage MODIFY COLUMN `namespace` SET INVERTED INDEX",
+	}},
+
 	// add alter SQL in future
-	{"1.1", []string{}},
+	{"1.2", []string{}},
 }
 
-const CurrentAppSQLVersion = "1.0"
+const CurrentAppSQLVersion = "1.1"
@@ -3,6 +3,7 @@ package metrics
 import (
 	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
@@ -22,13 +23,16 @@ var workerMetricsMap = map[string]*WorkerResourceMetrics{}
 var nodeMetricsLock sync.RWMutex
 var nodeMetricsMap = map[string]*NodeResourceMetrics{}
 
+// System level metrics, updated by the allocator and the autoscaler from concurrent reconciles
+var systemMetricsLock sync.RWMutex
+
 var log = ctrl.Log.WithName("metrics-recorder")
 
 type MetricsRecorder struct {
 	MetricsOutputPath string
 
-	// Raw billing result for node and workers
-	HourlyUnitPriceMap map[string]float64
+	// Raw billing result for node and workers, replaced as a whole when GPU info config changes
+	HourlyUnitPriceMap *atomic.Pointer[map[string]float64]
 
 	// Worker level unit price map, key is pool name, second level key is QoS level
 	WorkerUnitPriceMap map[string]map[string]RawBillingPricing
@@ -137,6 +141,8 @@ func SetNodeMetrics(node *tfv1.GPUNode, poolObj *tfv1.GPUPool, gpuModels []strin
 }
 
 func SetSchedulerMetrics(poolName string, isSuccess bool) {
+	systemMetricsLock.Lock()
+	defer systemMetricsLock.Unlock()
 	if _, ok := TensorFusionSystemMetricsMap[poolName]; !ok {
 		TensorFusionSystemMetricsMap[poolName] = &TensorFusionSystemMetrics{
 			PoolName: poolName,
@@ -149,8 +155,9 @@ func SetSchedulerMetrics(poolName string, isSuccess bool) {
 	}
 }
 
-// TODO should record metrics after autoscaling feature added
 func SetAutoscalingMetrics(poolName string, isScaleUp bool) {
+	systemMetricsLock.Lock()
+	defer systemMetricsLock.Unlock()
 	if _, ok := TensorFusionSystemMetricsMap[poolName]; !ok {
 		TensorFusionSystemMetricsMap[poolName] = &TensorFusionSystemMetrics{
 			PoolName: poolName,
@@ -164,6 +171,8 @@ func SetAutoscalingMetrics(poolName string, isScaleUp bool) {
 }
 
 func getSchedulerMetricsByPool(poolName string) (int64, int64, int64, int64) {
+	systemMetricsLock.RLock()
+	defer systemMetricsLock.RUnlock()
 	if item, ok := TensorFusionSystemMetricsMap[poolName]; !ok {
 		return 0, 0, 0, 0
 	} else {
@@ -273,7 +282,7 @@ func (mr *MetricsRecorder) RecordMetrics(writer io.Writer) {
 	nodeMetricsLock.RLock()
 
 	for _, metrics := range nodeMetricsMap {
-		metrics.RawCost = mr.getNodeRawCost(metrics, now.Sub(metrics.LastRecordTime), mr.HourlyUnitPriceMap)
+		metrics.RawCost = mr.getNodeRawCost(metrics, now.Sub(metrics.LastRecordTime), *mr.HourlyUnitPriceMap.Load())
 		metrics.LastRecordTime = now
 
 		if _, ok := activeWorkerAndNodeByPool[metrics.PoolName]; !ok {
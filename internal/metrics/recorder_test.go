@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSystemMetricsConcurrentUpdates(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			SetAutoscalingMetrics("pool-concurrent", i%2 == 0)
+		}()
+		go func() {
+			defer wg.Done()
+			SetSchedulerMetrics("pool-concurrent", i%2 == 0)
+		}()
+	}
+	wg.Wait()
+
+	success, fail, scaleUp, scaleDown := getSchedulerMetricsByPool("pool-concurrent")
+	assert.Equal(t, []int64{50, 50, 50, 50}, []int64{success, fail, scaleUp, scaleDown})
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"strconv"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+)
+
+// HoursPerMonth projects hourly costs to monthly costs
+const HoursPerMonth = 730
+
+// CalculateSavings returns the costs per hour saved by sharing GPUs among workers, and the costs per hour of idle
+// capacity which could be saved by compacting workers. Without sharing, each worker would occupy dedicated GPUs.
+// GPUs are priced by their share of the node cost, or by the pricing of the GPU model when node cost is unknown
+func CalculateSavings(nodes []tfv1.GPUNode, gpus []tfv1.GPU, gpuPricingMap map[string]float64) (float64, float64) {
+	pricePerGPUByNode := make(map[string]float64, len(nodes))
+	for _, node := range nodes {
+		costPerHour, err := strconv.ParseFloat(node.Spec.CostPerHour, 64)
+		if err == nil && costPerHour > 0 && node.Status.TotalGPUs > 0 {
+			pricePerGPUByNode[node.Name] = costPerHour / float64(node.Status.TotalGPUs)
+		}
+	}
+
+	saved, potential := 0.0, 0.0
+	for _, gpu := range gpus {
+		price, ok := pricePerGPUByNode[gpu.Labels[constants.LabelKeyOwner]]
+		if !ok {
+			price = gpuPricingMap[gpu.Status.GPUModel]
+		}
+		if price <= 0 {
+			continue
+		}
+
+		workers := 0
+		for _, app := range gpu.Status.RunningApps {
+			if app != nil {
+				workers += app.Count
+			}
+		}
+		if workers > 1 {
+			saved += price * float64(workers-1)
+		}
+		potential += price * idleRatio(&gpu)
+	}
+	return saved, potential
+}
+
+// idleRatio returns the ratio of the GPU not allocated, the scarcer resource of TFlops and VRAM decides
+func idleRatio(gpu *tfv1.GPU) float64 {
+	if gpu.Status.Capacity == nil || gpu.Status.Available == nil {
+		return 0
+	}
+	ratio := 1.0
+	for _, pair := range [][2]float64{
+		{gpu.Status.Available.Tflops.AsApproximateFloat64(), gpu.Status.Capacity.Tflops.AsApproximateFloat64()},
+		{gpu.Status.Available.Vram.AsApproximateFloat64(), gpu.Status.Capacity.Vram.AsApproximateFloat64()},
+	} {
+		if pair[1] <= 0 {
+			return 0
+		}
+		ratio = min(ratio, max(pair[0]/pair[1], 0))
+	}
+	return ratio
+}
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"testing"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCalculateSavings(t *testing.T) {
+	newGPU := func(node, model string, availableTFlops, availableVRAM string, workers ...int) tfv1.GPU {
+		gpu := tfv1.GPU{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{constants.LabelKeyOwner: node}},
+			Status: tfv1.GPUStatus{
+				GPUModel: model,
+				Capacity: &tfv1.Resource{Tflops: resource.MustParse("100"), Vram: resource.MustParse("40Gi")},
+				Available: &tfv1.Resource{
+					Tflops: resource.MustParse(availableTFlops),
+					Vram:   resource.MustParse(availableVRAM),
+				},
+			},
+		}
+		for _, count := range workers {
+			gpu.Status.RunningApps = append(gpu.Status.RunningApps, &tfv1.RunningAppDetail{Count: count})
+		}
+		return gpu
+	}
+	nodes := []tfv1.GPUNode{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "provisioned"},
+			Spec:       tfv1.GPUNodeSpec{CostPerHour: "8"},
+			Status:     tfv1.GPUNodeStatus{TotalGPUs: 2},
+		},
+		{ObjectMeta: metav1.ObjectMeta{Name: "existing"}},
+	}
+	gpus := []tfv1.GPU{
+		// 3 workers of 2 workloads share the GPU priced 4 by node cost, half of VRAM is idle
+		newGPU("provisioned", "A100", "50", "20Gi", 2, 1),
+		// idle GPU
+		newGPU("provisioned", "A100", "100", "40Gi"),
+		// priced by GPU model, TFlops is the scarcer resource
+		newGPU("existing", "L4", "25", "30Gi", 2),
+		// unknown price
+		newGPU("existing", "T4", "100", "40Gi", 5),
+	}
+
+	saved, potential := CalculateSavings(nodes, gpus, map[string]float64{"L4": 1})
+	assert.InDelta(t, 4*2+1*1, saved, 1e-9)
+	assert.InDelta(t, 4*0.5+4*1+1*0.25, potential, 1e-9)
+}
@@ -123,6 +123,8 @@ type HypervisorWorkerUsageMetrics struct {
 	PoolName     string `json:"poolName" gorm:"column:pool;index:,class:INVERTED"`
 	NodeName     string `json:"nodeName" gorm:"column:node_name;index:,class:INVERTED"`
 	UUID         string `json:"uuid" gorm:"column:uuid;index:,class:INVERTED"`
+	// workloads of the same name in different namespaces are told apart by namespace, added in 1.1
+	Namespace string `json:"namespace" gorm:"column:namespace;index:,class:INVERTED"`
 
 	ComputePercent float64 `json:"computePercent" gorm:"column:compute_percentage"`
 	VRAMBytes      uint64  `json:"vramBytes" gorm:"column:memory_bytes"`
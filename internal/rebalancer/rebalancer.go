@@ -0,0 +1,321 @@
+// Package rebalancer moves workers off hot GPUs based on GPU usage in time series db
+package rebalancer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/NexusGPU/tensor-fusion/internal/metrics"
+	"github.com/NexusGPU/tensor-fusion/internal/utils"
+	"github.com/NexusGPU/tensor-fusion/internal/worker"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// interval of checking whether any pool is due for rebalancing
+	tickInterval = time.Minute
+
+	defaultReBalanceInterval     = 5 * time.Minute
+	defaultReBalanceCoolDownTime = 30 * time.Minute
+)
+
+// Thresholds of GPU usage, GPUs exceeding any of them are hot
+type Thresholds struct {
+	ComputePercent *float64 `json:"computePercent,omitempty"`
+	VRAMPercent    *float64 `json:"vramPercent,omitempty"`
+	Temperature    *float64 `json:"temperature,omitempty"`
+}
+
+// IsHot returns whether the usage exceeds any of the thresholds
+func (t *Thresholds) IsHot(usage metrics.GPUUsageSummary) bool {
+	exceeds := func(threshold *float64, value float64) bool {
+		return threshold != nil && value > *threshold
+	}
+	return exceeds(t.ComputePercent, usage.ComputePercent) ||
+		exceeds(t.VRAMPercent, usage.VRAMPercent) ||
+		exceeds(t.Temperature, usage.Temperature)
+}
+
+// ReBalancer periodically moves one worker off each hot GPU of pools with rebalancer enabled,
+// the worker is moved by a WorkerMigration so that it keeps serving until the new worker is ready
+// on GPUs other than the hot ones
+type ReBalancer struct {
+	client.Client
+	Recorder record.EventRecorder
+	DB       *metrics.TimeSeriesDB
+
+	lastRun map[string]time.Time
+	now     func() time.Time
+}
+
+func NewReBalancer(client client.Client, recorder record.EventRecorder) *ReBalancer {
+	return &ReBalancer{
+		Client:   client,
+		Recorder: recorder,
+		lastRun:  make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// Start rebalances pools until ctx is done, it should only run on the leader
+func (r *ReBalancer) Start(ctx context.Context, db *metrics.TimeSeriesDB) {
+	log := log.FromContext(ctx)
+	r.DB = db
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	log.Info("Starting rebalancer")
+	for {
+		select {
+		case <-ticker.C:
+			r.ReBalance(ctx)
+		case <-ctx.Done():
+			log.Info("Stopping rebalancer")
+			return
+		}
+	}
+}
+
+// ReBalance runs rebalancing of pools whose interval has passed since their last run
+func (r *ReBalancer) ReBalance(ctx context.Context) {
+	log := log.FromContext(ctx)
+	pools := &tfv1.GPUPoolList{}
+	if err := r.List(ctx, pools); err != nil {
+		log.Error(err, "failed to list pools for rebalancing")
+		return
+	}
+	for i := range pools.Items {
+		pool := &pools.Items[i]
+		config, err := r.config(ctx, pool)
+		if err != nil {
+			log.Error(err, "failed to get rebalancer config", "pool", pool.Name)
+			continue
+		}
+		if config == nil || !ptr.Deref(config.Enable, false) {
+			continue
+		}
+		interval, err := utils.ParseDuration(config.Interval, defaultReBalanceInterval)
+		if err != nil {
+			log.Error(err, "invalid rebalancer interval", "pool", pool.Name)
+			continue
+		}
+		now := r.now()
+		if lastRun, ok := r.lastRun[pool.Name]; ok && now.Sub(lastRun) < interval {
+			continue
+		}
+		r.lastRun[pool.Name] = now
+		if err := r.reBalancePool(ctx, pool, config, interval); err != nil {
+			log.Error(err, "failed to rebalance pool", "pool", pool.Name)
+		}
+	}
+}
+
+func (r *ReBalancer) config(ctx context.Context, pool *tfv1.GPUPool) (*tfv1.ReBalancerConfig, error) {
+	if pool.Spec.SchedulingConfigTemplate == nil {
+		return nil, nil
+	}
+	template := &tfv1.SchedulingConfigTemplate{}
+	if err := r.Get(ctx, client.ObjectKey{Name: *pool.Spec.SchedulingConfigTemplate}, template); err != nil {
+		return nil, fmt.Errorf("get scheduling config template %s: %w", *pool.Spec.SchedulingConfigTemplate, err)
+	}
+	return template.Spec.ReBalancer, nil
+}
+
+func (r *ReBalancer) reBalancePool(ctx context.Context, pool *tfv1.GPUPool, config *tfv1.ReBalancerConfig, interval time.Duration) error {
+	if r.DB == nil {
+		return nil
+	}
+	thresholds := &Thresholds{}
+	if len(config.Threshold.MatchAny.Raw) > 0 {
+		if err := json.Unmarshal(config.Threshold.MatchAny.Raw, thresholds); err != nil {
+			return fmt.Errorf("parse rebalance thresholds: %w", err)
+		}
+	}
+	coolDown, err := utils.ParseDuration(config.ReBalanceCoolDownTime, defaultReBalanceCoolDownTime)
+	if err != nil {
+		return err
+	}
+
+	migrations, err := r.cleanupMigrations(ctx, pool)
+	if err != nil {
+		return err
+	}
+
+	usages, err := r.DB.FindGPUUsage(pool.Name, r.now().Add(-interval))
+	if err != nil {
+		return fmt.Errorf("find gpu usage: %w", err)
+	}
+	hotUUIDs := lo.FilterMap(usages, func(usage metrics.GPUUsageSummary, _ int) (string, bool) {
+		return usage.UUID, thresholds.IsHot(usage)
+	})
+	if len(hotUUIDs) == 0 {
+		return nil
+	}
+
+	gpus := &tfv1.GPUList{}
+	if err := r.List(ctx, gpus, client.MatchingLabels{constants.GpuPoolKey: pool.Name}); err != nil {
+		return fmt.Errorf("list gpus: %w", err)
+	}
+	hotGPUs := lo.Filter(gpus.Items, func(gpu tfv1.GPU, _ int) bool {
+		return slices.Contains(hotUUIDs, gpu.Status.UUID)
+	})
+	hotGPUNames := lo.Map(hotGPUs, func(gpu tfv1.GPU, _ int) string {
+		return gpu.Name
+	})
+
+	workers := &corev1.PodList{}
+	if err := r.List(ctx, workers, client.MatchingLabels{constants.LabelComponent: constants.ComponentWorker}); err != nil {
+		return fmt.Errorf("list workers: %w", err)
+	}
+	// workers being moved by unfinished migrations are left to them
+	migratingWorkers := lo.FilterMap(migrations, func(migration tfv1.WorkerMigration, _ int) (string, bool) {
+		return migration.Namespace + "/" + migration.Spec.WorkerName, migration.DeletionTimestamp.IsZero()
+	})
+	moved := make(map[tfv1.NameNamespace]struct{})
+	for _, gpu := range hotGPUs {
+		if err := r.moveWorkerOff(ctx, pool, &gpu, workers.Items, hotGPUNames, migratingWorkers, coolDown, moved); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cleanupMigrations deletes finished rebalance migrations of the pool, returns the unfinished ones
+func (r *ReBalancer) cleanupMigrations(ctx context.Context, pool *tfv1.GPUPool) ([]tfv1.WorkerMigration, error) {
+	migrations := &tfv1.WorkerMigrationList{}
+	if err := r.List(ctx, migrations, client.MatchingLabels{
+		constants.GpuPoolKey:     pool.Name,
+		constants.LabelComponent: constants.ComponentReBalancer,
+	}); err != nil {
+		return nil, fmt.Errorf("list rebalance migrations: %w", err)
+	}
+	unfinished := make([]tfv1.WorkerMigration, 0, len(migrations.Items))
+	for i := range migrations.Items {
+		migration := &migrations.Items[i]
+		phase := migration.Status.Phase
+		if phase != tfv1.WorkerMigrationSucceeded && phase != tfv1.WorkerMigrationFailed {
+			unfinished = append(unfinished, *migration)
+			continue
+		}
+		if err := r.Delete(ctx, migration); err != nil && !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("delete rebalance migration %s/%s: %w", migration.Namespace, migration.Name, err)
+		}
+	}
+	return unfinished, nil
+}
+
+// moveWorkerOff moves the worker with the largest requests off the hot GPU, workers labeled do-not-disrupt,
+// frozen workers, workers of cross-node replicas, workers being migrated and workloads in rebalance cool down
+// are not moved
+func (r *ReBalancer) moveWorkerOff(
+	ctx context.Context,
+	pool *tfv1.GPUPool,
+	gpu *tfv1.GPU,
+	workers []corev1.Pod,
+	hotGPUNames []string,
+	migratingWorkers []string,
+	coolDown time.Duration,
+	moved map[tfv1.NameNamespace]struct{},
+) error {
+	log := log.FromContext(ctx)
+	candidates := lo.Filter(workers, func(pod corev1.Pod, _ int) bool {
+		return pod.DeletionTimestamp.IsZero() &&
+			pod.Labels[constants.SchedulingDoNotDisruptLabel] != constants.TrueStringValue &&
+			pod.Labels[constants.WorkerReplicaLabel] == "" &&
+			pod.Annotations[constants.WorkerFrozenAnnotation] == "" &&
+			pod.Annotations[constants.MigrationSourceAnnotation] == "" &&
+			!slices.Contains(migratingWorkers, pod.Namespace+"/"+pod.Name) &&
+			slices.Contains(strings.Split(pod.Annotations[constants.GpuKey], ","), gpu.Name)
+	})
+	slices.SortFunc(candidates, func(a, b corev1.Pod) int {
+		tflopsA, tflopsB := worker.WorkerResources(&a).Requests.Tflops, worker.WorkerResources(&b).Requests.Tflops
+		return tflopsB.Cmp(tflopsA)
+	})
+
+	for i := range candidates {
+		pod := &candidates[i]
+		workloadNameNs := tfv1.NameNamespace{Namespace: pod.Namespace, Name: pod.Labels[constants.WorkloadKey]}
+		if _, ok := moved[workloadNameNs]; ok {
+			continue
+		}
+		workload := &tfv1.TensorFusionWorkload{}
+		if err := r.Get(ctx, client.ObjectKey{Namespace: workloadNameNs.Namespace, Name: workloadNameNs.Name}, workload); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("get workload %s/%s: %w", workloadNameNs.Namespace, workloadNameNs.Name, err)
+		}
+		if workload.Labels[constants.SchedulingDoNotDisruptLabel] == constants.TrueStringValue || workload.Spec.GangScheduling ||
+			inCoolDown(workload, coolDown, r.now()) {
+			continue
+		}
+
+		// the new worker is started on GPUs other than the hot ones, the migration fails and the
+		// worker stays when no cooler GPU fits
+		migration := &tfv1.WorkerMigration{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      getReBalanceMigrationName(pod.Name),
+				Namespace: pod.Namespace,
+				Labels: map[string]string{
+					constants.GpuPoolKey:     pool.Name,
+					constants.LabelComponent: constants.ComponentReBalancer,
+				},
+			},
+			Spec: tfv1.WorkerMigrationSpec{
+				WorkerName:   pod.Name,
+				ExcludedGPUs: hotGPUNames,
+			},
+		}
+		if err := r.Create(ctx, migration); err != nil {
+			if errors.IsAlreadyExists(err) {
+				continue
+			}
+			return fmt.Errorf("create migration for worker %s: %w", pod.Name, err)
+		}
+		patch := client.MergeFrom(workload.DeepCopy())
+		if workload.Annotations == nil {
+			workload.Annotations = map[string]string{}
+		}
+		workload.Annotations[constants.LastReBalanceTimeAnnotation] = r.now().Format(time.RFC3339)
+		if err := r.Patch(ctx, workload, patch); err != nil {
+			return fmt.Errorf("update rebalance time of workload %s: %w", workload.Name, err)
+		}
+		moved[workloadNameNs] = struct{}{}
+
+		r.Recorder.Eventf(workload, corev1.EventTypeNormal, "ReBalanced",
+			"Migrating worker %s off hot GPU %s by %s", pod.Name, gpu.Name, migration.Name)
+		log.Info("migrating worker off hot gpu", "worker", pod.Name, "namespace", pod.Namespace,
+			"gpu", gpu.Name, "migration", migration.Name)
+		return nil
+	}
+	return nil
+}
+
+func getReBalanceMigrationName(workerName string) string {
+	return fmt.Sprintf("rebalance-%s", workerName)
+}
+
+func inCoolDown(workload *tfv1.TensorFusionWorkload, coolDown time.Duration, now time.Time) bool {
+	value, ok := workload.Annotations[constants.LastReBalanceTimeAnnotation]
+	if !ok {
+		return false
+	}
+	lastReBalance, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return false
+	}
+	return now.Sub(lastReBalance) < coolDown
+}
@@ -0,0 +1,91 @@
+package rebalancer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/NexusGPU/tensor-fusion/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestThresholdsIsHot(t *testing.T) {
+	thresholds := &Thresholds{}
+	require.NoError(t, json.Unmarshal([]byte(`{"computePercent": 90, "temperature": 85}`), thresholds))
+
+	tests := []struct {
+		name     string
+		usage    metrics.GPUUsageSummary
+		expected bool
+	}{
+		{name: "cool", usage: metrics.GPUUsageSummary{ComputePercent: 50, VRAMPercent: 99, Temperature: 60}},
+		{name: "compute exceeded", usage: metrics.GPUUsageSummary{ComputePercent: 95}, expected: true},
+		{name: "temperature exceeded", usage: metrics.GPUUsageSummary{Temperature: 90}, expected: true},
+		{name: "equal to threshold", usage: metrics.GPUUsageSummary{ComputePercent: 90, Temperature: 85}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, thresholds.IsHot(tt.usage))
+		})
+	}
+}
+
+func TestInCoolDown(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	workload := func(annotations map[string]string) *tfv1.TensorFusionWorkload {
+		return &tfv1.TensorFusionWorkload{ObjectMeta: metav1.ObjectMeta{Annotations: annotations}}
+	}
+
+	assert.False(t, inCoolDown(workload(nil), 30*time.Minute, now))
+	assert.True(t, inCoolDown(workload(map[string]string{
+		constants.LastReBalanceTimeAnnotation: now.Add(-10 * time.Minute).Format(time.RFC3339),
+	}), 30*time.Minute, now))
+	assert.False(t, inCoolDown(workload(map[string]string{
+		constants.LastReBalanceTimeAnnotation: now.Add(-time.Hour).Format(time.RFC3339),
+	}), 30*time.Minute, now))
+}
+
+func TestCleanupMigrations(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, tfv1.AddToScheme(scheme))
+	pool := &tfv1.GPUPool{ObjectMeta: metav1.ObjectMeta{Name: "pool-a"}}
+	newMigration := func(name string, phase tfv1.WorkerMigrationPhase) *tfv1.WorkerMigration {
+		return &tfv1.WorkerMigration{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      getReBalanceMigrationName(name),
+				Namespace: "default",
+				Labels: map[string]string{
+					constants.GpuPoolKey:     pool.Name,
+					constants.LabelComponent: constants.ComponentReBalancer,
+				},
+			},
+			Spec:   tfv1.WorkerMigrationSpec{WorkerName: name},
+			Status: tfv1.WorkerMigrationStatus{Phase: phase},
+		}
+	}
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(
+			newMigration("succeeded", tfv1.WorkerMigrationSucceeded),
+			newMigration("failed", tfv1.WorkerMigrationFailed),
+			newMigration("starting", tfv1.WorkerMigrationStartingWorker),
+		).
+		Build()
+	r := NewReBalancer(k8sClient, record.NewFakeRecorder(10))
+
+	unfinished, err := r.cleanupMigrations(context.Background(), pool)
+	require.NoError(t, err)
+	require.Len(t, unfinished, 1)
+	assert.Equal(t, "starting", unfinished[0].Spec.WorkerName)
+
+	migrations := &tfv1.WorkerMigrationList{}
+	require.NoError(t, k8sClient.List(context.Background(), migrations))
+	assert.Len(t, migrations.Items, 1)
+}
@@ -4,9 +4,15 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/NexusGPU/tensor-fusion/internal/worker"
 	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -44,6 +50,13 @@ func (cr *ConnectionRouter) Get(ctx *gin.Context) {
 	ch, cancelFunc := cr.watcher.subscribe(req)
 	defer cancelFunc()
 
+	if conn.Status.Phase == tfv1.WorkerFrozenToMem || conn.Status.Phase == tfv1.WorkerFrozenToDisk {
+		if err := cr.watcher.wakeUp(ctx, conn); err != nil {
+			ctx.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	// Wait for connection updates
 	for conn := range ch {
 		if conn.Status.Phase == tfv1.WorkerRunning {
@@ -85,6 +98,31 @@ func (cw *connectionWatcher) get(ctx context.Context, req types.NamespacedName)
 	return conn
 }
 
+// wakeUp requests the workload controller to allocate GPUs again for frozen workers of the connection
+func (cw *connectionWatcher) wakeUp(ctx context.Context, conn *tfv1.TensorFusionConnection) error {
+	workload := &tfv1.TensorFusionWorkload{}
+	if err := cw.client.Get(ctx, client.ObjectKey{Namespace: conn.Namespace, Name: conn.Labels[constants.WorkloadKey]}, workload); err != nil {
+		return fmt.Errorf("get workload: %w", err)
+	}
+	workerStatus, ok := lo.Find(workload.Status.WorkerStatuses, func(status tfv1.WorkerStatus) bool {
+		return status.WorkerName == conn.Status.WorkerName
+	})
+	if !ok {
+		return nil
+	}
+	for _, status := range worker.ReplicaWorkers(workload, workerStatus) {
+		if status.WorkerPhase != tfv1.WorkerFrozenToMem && status.WorkerPhase != tfv1.WorkerFrozenToDisk {
+			continue
+		}
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: conn.Namespace, Name: status.WorkerName}}
+		patch := fmt.Appendf(nil, `{"metadata":{"annotations":{%q:%q}}}`, constants.WorkerWakeUpAnnotation, time.Now().Format(time.RFC3339))
+		if err := cw.client.Patch(ctx, pod, client.RawPatch(types.MergePatchType, patch)); err != nil {
+			return fmt.Errorf("wake up worker %s: %w", status.WorkerName, err)
+		}
+	}
+	return nil
+}
+
 // Subscribe returns a channel that will be closed when the connection is deleted
 func (cw *connectionWatcher) subscribe(req types.NamespacedName) (connectionChannel, func()) {
 	ch := make(connectionChannel, 1)
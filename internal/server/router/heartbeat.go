@@ -0,0 +1,184 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/NexusGPU/tensor-fusion/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/lo"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// GPUStateRunning is reported for GPUs the hypervisor is serving
+const GPUStateRunning = "Running"
+
+// condition reason of GPUs the hypervisor is not serving, the state reported by the hypervisor is free-form
+// and goes to the message, it may not be a valid condition reason
+const reasonHypervisorReportedNotReady = "HypervisorReportedNotReady"
+
+// extra info of service account tokens bound to a Pod, the node the Pod is scheduled to
+const nodeNameUserExtra = "authentication.kubernetes.io/node-name"
+
+// HypervisorHeartbeat is posted by hypervisors periodically, more often than the heartbeat timeout
+type HypervisorHeartbeat struct {
+	// name of the GPUNode the hypervisor runs on
+	NodeName string         `json:"nodeName" binding:"required"`
+	State    string         `json:"state"`
+	Version  string         `json:"version"`
+	GPUs     []GPUHeartbeat `json:"gpus,omitempty"`
+}
+
+type GPUHeartbeat struct {
+	UUID string `json:"uuid" binding:"required"`
+	// Running when the hypervisor is serving the GPU, otherwise the reason why it's not
+	State   string `json:"state"`
+	Message string `json:"message,omitempty"`
+}
+
+type HeartbeatRouter struct {
+	client client.Client
+	// user of hypervisor Pods authenticated by their service account token
+	hypervisorUser string
+}
+
+func NewHeartbeatRouter(ctx context.Context, client client.Client) (*HeartbeatRouter, error) {
+	return &HeartbeatRouter{
+		client:         client,
+		hypervisorUser: fmt.Sprintf("system:serviceaccount:%s:%s", utils.CurrentNamespace(), constants.HypervisorServiceAccountName),
+	}, nil
+}
+
+// Heartbeat records hypervisor state in GPUNode status and per-GPU state in GPU conditions,
+// GPUNodes with stale heartbeats are marked Unknown by the GPUNode controller.
+// Requests must carry the service account token of hypervisor Pods as bearer token
+func (r *HeartbeatRouter) Heartbeat(ctx *gin.Context) {
+	user, code, err := r.authenticate(ctx, ctx.GetHeader("Authorization"))
+	if err != nil {
+		ctx.JSON(code, gin.H{"error": err.Error()})
+		return
+	}
+	heartbeat := &HypervisorHeartbeat{}
+	if err := ctx.ShouldBindJSON(heartbeat); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	node := &tfv1.GPUNode{}
+	if err := r.client.Get(ctx, client.ObjectKey{Name: heartbeat.NodeName}, node); err != nil {
+		if errors.IsNotFound(err) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "gpu node not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// hypervisors may only report the node they run on
+	if err := authorizeNode(user, node); err != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	// GPU states go first, the GPUNode controller syncs GPU phases from them when the node status changes
+	if err := r.updateGPUStates(ctx, node, heartbeat.GPUs); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	patch := client.MergeFrom(node.DeepCopy())
+	node.Status.HypervisorStatus.HypervisorState = heartbeat.State
+	node.Status.HypervisorStatus.HypervisorVersion = heartbeat.Version
+	node.Status.HypervisorStatus.LastHeartbeatTime = metav1.Now()
+	if err := r.client.Status().Patch(ctx, node, patch); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	log.FromContext(ctx).V(4).Info("hypervisor heartbeat received", "node", node.Name, "state", heartbeat.State)
+	ctx.Status(http.StatusNoContent)
+}
+
+// authenticate verifies the bearer token with a TokenReview and returns the authenticated user,
+// or the status code to reply when it does not belong to the hypervisor service account
+func (r *HeartbeatRouter) authenticate(ctx context.Context, authorization string) (authenticationv1.UserInfo, int, error) {
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok || token == "" {
+		return authenticationv1.UserInfo{}, http.StatusUnauthorized, fmt.Errorf("missing bearer token")
+	}
+	review := &authenticationv1.TokenReview{Spec: authenticationv1.TokenReviewSpec{Token: token}}
+	if err := r.client.Create(ctx, review); err != nil {
+		return authenticationv1.UserInfo{}, http.StatusInternalServerError, fmt.Errorf("review token: %w", err)
+	}
+	if !review.Status.Authenticated {
+		return authenticationv1.UserInfo{}, http.StatusUnauthorized, fmt.Errorf("invalid token: %s", review.Status.Error)
+	}
+	if review.Status.User.Username != r.hypervisorUser {
+		return authenticationv1.UserInfo{}, http.StatusForbidden,
+			fmt.Errorf("user %s is not allowed to send heartbeats", review.Status.User.Username)
+	}
+	return review.Status.User, http.StatusOK, nil
+}
+
+// authorizeNode checks that the token is bound to a Pod on the Kubernetes node of the GPUNode,
+// GPUNode name is the Kubernetes node name until the GPUNode controller records it
+func authorizeNode(user authenticationv1.UserInfo, node *tfv1.GPUNode) error {
+	k8sNodeName := lo.Ternary(node.Status.KubernetesNodeName != "", node.Status.KubernetesNodeName, node.Name)
+	nodeNames := user.Extra[nodeNameUserExtra]
+	if len(nodeNames) != 1 || nodeNames[0] != k8sNodeName {
+		return fmt.Errorf("token is not bound to a pod on node %s", k8sNodeName)
+	}
+	return nil
+}
+
+func (r *HeartbeatRouter) updateGPUStates(ctx context.Context, node *tfv1.GPUNode, states []GPUHeartbeat) error {
+	if len(states) == 0 {
+		return nil
+	}
+	gpuList := &tfv1.GPUList{}
+	if err := r.client.List(ctx, gpuList, client.MatchingLabels{constants.LabelKeyOwner: node.Name}); err != nil {
+		return err
+	}
+	for i := range gpuList.Items {
+		gpu := &gpuList.Items[i]
+		state, ok := lo.Find(states, func(state GPUHeartbeat) bool {
+			return strings.EqualFold(state.UUID, gpu.Status.UUID)
+		})
+		if !ok {
+			continue
+		}
+
+		condition := metav1.Condition{
+			Type:    constants.ConditionStatusTypeGPUHypervisorReady,
+			Status:  metav1.ConditionTrue,
+			Reason:  GPUStateRunning,
+			Message: state.Message,
+		}
+		if state.State != GPUStateRunning {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = reasonHypervisorReportedNotReady
+			condition.Message = lo.Ternary(state.State == "", "state not reported", state.State)
+			if state.Message != "" {
+				condition.Message += ": " + state.Message
+			}
+		}
+		existing := meta.FindStatusCondition(gpu.Status.Conditions, condition.Type)
+		if existing != nil && existing.Status == condition.Status &&
+			existing.Reason == condition.Reason && existing.Message == condition.Message {
+			continue
+		}
+		// conditions are replaced as a whole, avoid overwriting health conditions reported meanwhile
+		patch := client.MergeFromWithOptions(gpu.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		meta.SetStatusCondition(&gpu.Status.Conditions, condition)
+		if err := r.client.Status().Patch(ctx, gpu, patch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
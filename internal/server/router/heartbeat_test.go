@@ -0,0 +1,101 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestHeartbeat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	scheme := runtime.NewScheme()
+	_ = tfv1.AddToScheme(scheme)
+	node := &tfv1.GPUNode{
+		ObjectMeta: metav1.ObjectMeta{Name: "node"},
+		Status:     tfv1.GPUNodeStatus{KubernetesNodeName: "k8s-node"},
+	}
+	newGPU := func(uuid string) *tfv1.GPU {
+		return &tfv1.GPU{
+			ObjectMeta: metav1.ObjectMeta{Name: uuid, Labels: map[string]string{constants.LabelKeyOwner: node.Name}},
+			Status:     tfv1.GPUStatus{UUID: strings.ToUpper(uuid)},
+		}
+	}
+	// tokens are reviewed by the API server, the token is "<user>@<node>" here
+	k8sClient := fake.NewClientBuilder().WithScheme(scheme).
+		WithStatusSubresource(&tfv1.GPUNode{}, &tfv1.GPU{}).
+		WithObjects(node, newGPU("gpu-1"), newGPU("gpu-2")).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				if review, ok := obj.(*authenticationv1.TokenReview); ok {
+					review.Status.Authenticated = review.Spec.Token != "invalid"
+					user, nodeName, _ := strings.Cut(review.Spec.Token, "@")
+					review.Status.User.Username = user
+					if nodeName != "" {
+						review.Status.User.Extra = map[string]authenticationv1.ExtraValue{nodeNameUserExtra: {nodeName}}
+					}
+					return nil
+				}
+				return c.Create(ctx, obj, opts...)
+			},
+		}).Build()
+	hb, err := NewHeartbeatRouter(context.Background(), k8sClient)
+	require.NoError(t, err)
+	r := gin.New()
+	r.POST("/api/heartbeat", hb.Heartbeat)
+
+	postWithToken := func(token string, body string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/heartbeat", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	post := func(body string) int {
+		return postWithToken(hb.hypervisorUser+"@k8s-node", body)
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, postWithToken("", `{"nodeName":"node"}`))
+	assert.Equal(t, http.StatusUnauthorized, postWithToken("invalid", `{"nodeName":"node"}`))
+	assert.Equal(t, http.StatusForbidden, postWithToken("system:serviceaccount:default:default", `{"nodeName":"node"}`))
+	assert.Equal(t, http.StatusBadRequest, post(`{"state":"Running"}`))
+	assert.Equal(t, http.StatusNotFound, post(`{"nodeName":"missing"}`))
+	assert.Equal(t, http.StatusForbidden, postWithToken(hb.hypervisorUser, `{"nodeName":"node"}`))
+	assert.Equal(t, http.StatusForbidden, postWithToken(hb.hypervisorUser+"@other-node", `{"nodeName":"node"}`))
+	assert.Equal(t, http.StatusNoContent, post(`{"nodeName":"node","state":"Running","version":"1.2.0",
+		"gpus":[{"uuid":"gpu-1","state":"Running"},{"uuid":"GPU-2","state":"DriverError","message":"driver not loaded"}]}`))
+
+	require.NoError(t, k8sClient.Get(context.Background(), client.ObjectKeyFromObject(node), node))
+	assert.Equal(t, "Running", node.Status.HypervisorStatus.HypervisorState)
+	assert.Equal(t, "1.2.0", node.Status.HypervisorStatus.HypervisorVersion)
+	assert.False(t, node.Status.HypervisorStatus.LastHeartbeatTime.IsZero())
+
+	gpu := &tfv1.GPU{}
+	require.NoError(t, k8sClient.Get(context.Background(), client.ObjectKey{Name: "gpu-1"}, gpu))
+	assert.True(t, meta.IsStatusConditionTrue(gpu.Status.Conditions, constants.ConditionStatusTypeGPUHypervisorReady))
+	assert.False(t, gpu.IsHypervisorNotReady())
+	require.NoError(t, k8sClient.Get(context.Background(), client.ObjectKey{Name: "gpu-2"}, gpu))
+	condition := meta.FindStatusCondition(gpu.Status.Conditions, constants.ConditionStatusTypeGPUHypervisorReady)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, "HypervisorReportedNotReady", condition.Reason)
+	assert.Equal(t, "DriverError: driver not loaded", condition.Message)
+	assert.True(t, gpu.IsHypervisorNotReady(), "GPUs the hypervisor does not serve are excluded from allocation")
+}
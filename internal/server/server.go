@@ -9,6 +9,7 @@ import (
 func NewHTTPServer(
 	cr *router.ConnectionRouter,
 	ahp *router.AssignHostPortRouter,
+	hb *router.HeartbeatRouter,
 ) *gin.Engine {
 
 	r := gin.New()
@@ -19,5 +20,6 @@ func NewHTTPServer(
 	apiGroup := r.Group("/api")
 	apiGroup.GET("/connection", cr.Get)
 	apiGroup.POST("/assign-host-port", ahp.AssignHostPort)
+	apiGroup.POST("/heartbeat", hb.Heartbeat)
 	return r
 }
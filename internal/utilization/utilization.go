@@ -0,0 +1,195 @@
+// Package utilization writes GPU usage reported by hypervisors in time series db to GPUNode and GPUPool status
+package utilization
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/NexusGPU/tensor-fusion/internal/metrics"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	updateInterval = time.Minute
+
+	// GPU usage is averaged over the window
+	usageWindow = 5 * time.Minute
+)
+
+// Updater periodically aggregates GPU usage per node and pool, weighted by GPU capacity. Allocated percentage is
+// used instead when time series db is unreachable or GPUs have no usage reported, cluster utilization is
+// aggregated from pools by the cluster controller
+type Updater struct {
+	client.Client
+	DB *metrics.TimeSeriesDB
+
+	now func() time.Time
+}
+
+func NewUpdater(client client.Client) *Updater {
+	return &Updater{
+		Client: client,
+		now:    time.Now,
+	}
+}
+
+// Start updates utilization until ctx is done, it should only run on the leader, db could be nil when
+// time series db is not available
+func (u *Updater) Start(ctx context.Context, db *metrics.TimeSeriesDB) {
+	log := log.FromContext(ctx)
+	u.DB = db
+	ticker := time.NewTicker(updateInterval)
+	defer ticker.Stop()
+
+	log.Info("Starting utilization updater")
+	for {
+		select {
+		case <-ticker.C:
+			u.Update(ctx)
+		case <-ctx.Done():
+			log.Info("Stopping utilization updater")
+			return
+		}
+	}
+}
+
+// Update refreshes utilization of all pools and their nodes
+func (u *Updater) Update(ctx context.Context) {
+	log := log.FromContext(ctx)
+	pools := &tfv1.GPUPoolList{}
+	if err := u.List(ctx, pools); err != nil {
+		log.Error(err, "failed to list pools for utilization update")
+		return
+	}
+	for i := range pools.Items {
+		if err := u.updatePool(ctx, &pools.Items[i]); err != nil {
+			log.Error(err, "failed to update utilization", "pool", pools.Items[i].Name)
+		}
+	}
+}
+
+func (u *Updater) updatePool(ctx context.Context, pool *tfv1.GPUPool) error {
+	nodes := &tfv1.GPUNodeList{}
+	if err := u.List(ctx, nodes, client.MatchingLabels{constants.LabelKeyOwner: pool.Name}); err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	gpus := &tfv1.GPUList{}
+	if err := u.List(ctx, gpus, client.MatchingLabels{constants.GpuPoolKey: pool.Name}); err != nil {
+		return fmt.Errorf("failed to list GPUs: %w", err)
+	}
+
+	var usages []metrics.GPUUsageSummary
+	if u.DB != nil {
+		var err error
+		usages, err = u.DB.FindGPUUsage(pool.Name, u.now().Add(-usageWindow))
+		if err != nil {
+			log.FromContext(ctx).Error(err, "failed to query GPU usage, fall back to allocated percentage", "pool", pool.Name)
+			usages = nil
+		}
+	}
+
+	nodeUtilization, poolUtilization := aggregate(nodes.Items, gpus.Items, usages)
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		utilization, ok := nodeUtilization[node.Name]
+		if !ok {
+			utilization = Utilization{
+				TFlopsPercent: tfv1.AllocatedPercent(node.Status.TotalTFlops, node.Status.AvailableTFlops),
+				VRAMPercent:   tfv1.AllocatedPercent(node.Status.TotalVRAM, node.Status.AvailableVRAM),
+			}
+		}
+		if node.Status.UtilizedTFlopsPercent == utilization.TFlopsPercent && node.Status.UtilizedVRAMPercent == utilization.VRAMPercent {
+			continue
+		}
+		patch := client.MergeFrom(node.DeepCopy())
+		node.Status.UtilizedTFlopsPercent = utilization.TFlopsPercent
+		node.Status.UtilizedVRAMPercent = utilization.VRAMPercent
+		if err := u.Status().Patch(ctx, node, patch); err != nil {
+			return fmt.Errorf("failed to patch node %s status: %w", node.Name, err)
+		}
+	}
+
+	if poolUtilization == nil {
+		poolUtilization = &Utilization{
+			TFlopsPercent: tfv1.AllocatedPercent(pool.Status.TotalTFlops, pool.Status.AvailableTFlops),
+			VRAMPercent:   tfv1.AllocatedPercent(pool.Status.TotalVRAM, pool.Status.AvailableVRAM),
+		}
+	}
+	if pool.Status.UtilizedTFlopsPercent == poolUtilization.TFlopsPercent && pool.Status.UtilizedVRAMPercent == poolUtilization.VRAMPercent {
+		return nil
+	}
+	patch := client.MergeFrom(pool.DeepCopy())
+	pool.Status.UtilizedTFlopsPercent = poolUtilization.TFlopsPercent
+	pool.Status.UtilizedVRAMPercent = poolUtilization.VRAMPercent
+	if err := u.Status().Patch(ctx, pool, patch); err != nil {
+		return fmt.Errorf("failed to patch pool status: %w", err)
+	}
+	return nil
+}
+
+// Utilization is the usage percentage of TFlops and VRAM, formatted with 2 decimals
+type Utilization struct {
+	TFlopsPercent string
+	VRAMPercent   string
+}
+
+type weightedUsage struct {
+	tflops, tflopsCapacity float64
+	vram, vramCapacity     float64
+}
+
+func (w *weightedUsage) add(capacity *tfv1.Resource, usage metrics.GPUUsageSummary) {
+	tflopsCapacity := capacity.Tflops.AsApproximateFloat64()
+	vramCapacity := capacity.Vram.AsApproximateFloat64()
+	w.tflops += usage.ComputePercent * tflopsCapacity
+	w.tflopsCapacity += tflopsCapacity
+	w.vram += usage.VRAMPercent * vramCapacity
+	w.vramCapacity += vramCapacity
+}
+
+func (w *weightedUsage) utilization() *Utilization {
+	if w.tflopsCapacity <= 0 || w.vramCapacity <= 0 {
+		return nil
+	}
+	return &Utilization{
+		TFlopsPercent: tfv1.FormatFloat(w.tflops / w.tflopsCapacity),
+		VRAMPercent:   tfv1.FormatFloat(w.vram / w.vramCapacity),
+	}
+}
+
+// aggregate returns utilization of nodes and the pool weighted by GPU capacity, nodes and the pool are left out
+// when none of their GPUs has usage reported
+func aggregate(nodes []tfv1.GPUNode, gpus []tfv1.GPU, usages []metrics.GPUUsageSummary) (map[string]Utilization, *Utilization) {
+	usageByUUID := lo.KeyBy(usages, func(usage metrics.GPUUsageSummary) string {
+		return usage.UUID
+	})
+
+	poolUsage := &weightedUsage{}
+	nodeUsages := make(map[string]*weightedUsage, len(nodes))
+	for _, node := range nodes {
+		nodeUsages[node.Name] = &weightedUsage{}
+	}
+	for _, gpu := range gpus {
+		usage, ok := usageByUUID[gpu.Status.UUID]
+		if !ok || gpu.Status.Capacity == nil {
+			continue
+		}
+		poolUsage.add(gpu.Status.Capacity, usage)
+		if nodeUsage, ok := nodeUsages[gpu.Labels[constants.LabelKeyOwner]]; ok {
+			nodeUsage.add(gpu.Status.Capacity, usage)
+		}
+	}
+
+	nodeUtilization := make(map[string]Utilization, len(nodes))
+	for name, nodeUsage := range nodeUsages {
+		if utilization := nodeUsage.utilization(); utilization != nil {
+			nodeUtilization[name] = *utilization
+		}
+	}
+	return nodeUtilization, poolUsage.utilization()
+}
@@ -0,0 +1,50 @@
+package utilization
+
+import (
+	"testing"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/NexusGPU/tensor-fusion/internal/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAggregate(t *testing.T) {
+	newGPU := func(node, uuid, tflops, vram string) tfv1.GPU {
+		return tfv1.GPU{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{constants.LabelKeyOwner: node}},
+			Status: tfv1.GPUStatus{
+				UUID:     uuid,
+				Capacity: &tfv1.Resource{Tflops: resource.MustParse(tflops), Vram: resource.MustParse(vram)},
+			},
+		}
+	}
+	nodes := []tfv1.GPUNode{
+		{ObjectMeta: metav1.ObjectMeta{Name: "a"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "b"}},
+	}
+	gpus := []tfv1.GPU{
+		newGPU("a", "gpu-1", "300", "80Gi"),
+		newGPU("a", "gpu-2", "100", "40Gi"),
+		// no usage reported
+		newGPU("b", "gpu-3", "100", "40Gi"),
+	}
+	usages := []metrics.GPUUsageSummary{
+		{UUID: "gpu-1", ComputePercent: 40, VRAMPercent: 50},
+		{UUID: "gpu-2", ComputePercent: 80, VRAMPercent: 20},
+	}
+
+	nodeUtilization, poolUtilization := aggregate(nodes, gpus, usages)
+	assert.Equal(t, map[string]Utilization{
+		"a": {TFlopsPercent: "50.00", VRAMPercent: "40.00"},
+	}, nodeUtilization)
+	require.NotNil(t, poolUtilization)
+	assert.Equal(t, Utilization{TFlopsPercent: "50.00", VRAMPercent: "40.00"}, *poolUtilization)
+
+	nodeUtilization, poolUtilization = aggregate(nodes, gpus, nil)
+	assert.Empty(t, nodeUtilization)
+	assert.Nil(t, poolUtilization, "falls back to allocation without usage")
+}
@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDuration(t *testing.T) {
+	duration, err := ParseDuration("7d", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "168h0m0s", duration.String())
+
+	duration, err = ParseDuration("", 5*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, duration)
+
+	_, err = ParseDuration("7days", 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,137 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	goErrors "errors"
+	"fmt"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/component"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/NexusGPU/tensor-fusion/internal/worker"
+	"github.com/samber/lo"
+	"gomodules.xyz/jsonpatch/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// embeddedWorkerAnnotations are the allocation annotations of worker pods carried over to client pods
+// with an embedded worker, so that their GPUs are released and recounted the same way
+var embeddedWorkerAnnotations = []string{
+	constants.TFLOPSRequestAnnotation,
+	constants.TFLOPSLimitAnnotation,
+	constants.VRAMRequestAnnotation,
+	constants.VRAMLimitAnnotation,
+}
+
+// localNodeAffinity requires the client to run on the given node
+func localNodeAffinity(hostname string) *corev1.NodeAffinity {
+	return &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{
+				{
+					MatchExpressions: []corev1.NodeSelectorRequirement{
+						{
+							Key:      constants.KubernetesHostNameLabel,
+							Operator: corev1.NodeSelectorOpIn,
+							Values:   []string{hostname},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// localWorkerAffinity pins the client to the node of the least used worker of the workload, when no worker
+// is started yet, the client is co-located with workers of the workload by pod affinity instead,
+// so that the scheduler waits for the worker rather than the admission
+func localWorkerAffinity(ctx context.Context, c client.Client, workload *tfv1.TensorFusionWorkload) (*corev1.Affinity, error) {
+	workerStatus, err := worker.SelectWorker(ctx, c, workload, 1)
+	if goErrors.Is(err, worker.ErrNoAvailableWorker) {
+		return &corev1.Affinity{
+			PodAffinity: &corev1.PodAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+					{
+						LabelSelector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{
+								constants.LabelComponent: constants.ComponentWorker,
+								constants.WorkloadKey:    workload.Name,
+							},
+						},
+						TopologyKey: constants.KubernetesHostNameLabel,
+					},
+				},
+			},
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.Affinity{NodeAffinity: localNodeAffinity(workerStatus.NodeSelector[constants.KubernetesHostNameLabel])}, nil
+}
+
+// patchEmbeddedWorker injects the vGPU worker as a native sidecar of the client pod, the pod is held by
+// a scheduling gate until the pod controller allocates GPUs for it, which are released by finalizer
+func patchEmbeddedWorker(
+	pod *corev1.Pod,
+	pool *tfv1.GPUPool,
+	workload *tfv1.TensorFusionWorkload,
+) ([]jsonpatch.JsonPatchOperation, error) {
+	currentBytes, err := json.Marshal(pod)
+	if err != nil {
+		return nil, fmt.Errorf("marshal current pod: %w", err)
+	}
+
+	workerGenerator := &worker.WorkerGenerator{WorkerConfig: component.ActiveWorkerConfig(pool)}
+	// the sidecar listens in the network of the client pod, no host port is needed
+	workerPod, err := workerGenerator.GenerateEmbeddedWorkerPod(workload.Name, pod.Namespace, constants.EmbeddedWorkerPort,
+		workload.Spec.Resources.Requests, workload.Spec.Resources.Limits)
+	if err != nil {
+		return nil, fmt.Errorf("generate embedded worker: %w", err)
+	}
+
+	sidecar := workerPod.Spec.Containers[0]
+	sidecar.Name = constants.EmbeddedWorkerContainerName
+	sidecar.RestartPolicy = ptr.To(corev1.ContainerRestartPolicyAlways)
+	pod.Spec.InitContainers = append(pod.Spec.InitContainers, sidecar)
+	for _, volume := range workerPod.Spec.Volumes {
+		if !lo.ContainsBy(pod.Spec.Volumes, func(v corev1.Volume) bool { return v.Name == volume.Name }) {
+			pod.Spec.Volumes = append(pod.Spec.Volumes, volume)
+		}
+	}
+	for _, toleration := range workerPod.Spec.Tolerations {
+		if !lo.Contains(pod.Spec.Tolerations, toleration) {
+			pod.Spec.Tolerations = append(pod.Spec.Tolerations, toleration)
+		}
+	}
+	pod.Spec.SchedulingGates = append(pod.Spec.SchedulingGates, corev1.PodSchedulingGate{
+		Name: constants.EmbeddedWorkerSchedulingGate,
+	})
+
+	if pod.Labels == nil {
+		pod.Labels = map[string]string{}
+	}
+	pod.Labels[constants.EmbeddedWorkerLabel] = constants.TrueStringValue
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	for _, key := range embeddedWorkerAnnotations {
+		pod.Annotations[key] = workerPod.Annotations[key]
+	}
+	controllerutil.AddFinalizer(pod, constants.Finalizer)
+
+	patchedBytes, err := json.Marshal(pod)
+	if err != nil {
+		return nil, fmt.Errorf("marshal patched pod: %w", err)
+	}
+	patches, err := jsonpatch.CreatePatch(currentBytes, patchedBytes)
+	if err != nil {
+		return nil, fmt.Errorf("patch embedded worker: %w", err)
+	}
+	return patches, nil
+}
@@ -0,0 +1,106 @@
+package v1
+
+import (
+	"context"
+	"testing"
+
+	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/config"
+	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gomodules.xyz/jsonpatch/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestLocalWorkerAffinity(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, tfv1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	workload := &tfv1.TensorFusionWorkload{ObjectMeta: metav1.ObjectMeta{Name: "workload", Namespace: "default"}}
+
+	// no worker yet, the client waits in the scheduler for a worker of the workload
+	affinity, err := localWorkerAffinity(context.Background(), c, workload)
+	require.NoError(t, err)
+	assert.Nil(t, affinity.NodeAffinity)
+	require.Len(t, affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution, 1)
+	term := affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0]
+	assert.Equal(t, constants.KubernetesHostNameLabel, term.TopologyKey)
+	assert.Equal(t, map[string]string{
+		constants.LabelComponent: constants.ComponentWorker,
+		constants.WorkloadKey:    "workload",
+	}, term.LabelSelector.MatchLabels)
+
+	// pinned to the node of the started worker
+	workload.Status.WorkerStatuses = []tfv1.WorkerStatus{{
+		WorkerName:   "worker",
+		WorkerPhase:  tfv1.WorkerRunning,
+		NodeSelector: map[string]string{constants.KubernetesHostNameLabel: "node-a"},
+	}}
+	affinity, err = localWorkerAffinity(context.Background(), c, workload)
+	require.NoError(t, err)
+	assert.Nil(t, affinity.PodAffinity)
+	requirement := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions[0]
+	assert.Equal(t, []string{"node-a"}, requirement.Values)
+}
+
+func TestPatchEmbeddedWorker(t *testing.T) {
+	pool := &tfv1.GPUPool{ObjectMeta: metav1.ObjectMeta{Name: "mock"}, Spec: *config.MockGPUPoolSpec}
+	workload := &tfv1.TensorFusionWorkload{
+		ObjectMeta: metav1.ObjectMeta{Name: "workload", Namespace: "default"},
+		Spec: tfv1.WorkloadProfileSpec{
+			IsLocalGPU: true,
+			Resources: tfv1.Resources{
+				Requests: tfv1.Resource{Tflops: resource.MustParse("10"), Vram: resource.MustParse("1Gi")},
+				Limits:   tfv1.Resource{Tflops: resource.MustParse("20"), Vram: resource.MustParse("2Gi")},
+			},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "client-",
+			Namespace:    "default",
+			Labels:       map[string]string{constants.TensorFusionEnabledLabelKey: constants.TrueStringValue},
+			Annotations:  map[string]string{constants.WorkloadKey: "workload"},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "main", Image: "client"}}},
+	}
+	patches, err := patchEmbeddedWorker(pod, pool, workload)
+	require.NoError(t, err)
+
+	// the sidecar reads env depending on GPUs from annotations set once GPUs are allocated
+	require.Len(t, pod.Spec.InitContainers, 1)
+	sidecar := pod.Spec.InitContainers[0]
+	assert.Equal(t, constants.EmbeddedWorkerContainerName, sidecar.Name)
+	assert.Equal(t, corev1.ContainerRestartPolicyAlways, *sidecar.RestartPolicy)
+	visibleDevices, ok := lo.Find(sidecar.Env, func(env corev1.EnvVar) bool {
+		return env.Name == constants.NvidiaVisibleDevicesEnv
+	})
+	require.True(t, ok)
+	assert.Equal(t, "metadata.annotations['tensor-fusion.ai/env-NVIDIA_VISIBLE_DEVICES']", visibleDevices.ValueFrom.FieldRef.FieldPath)
+	assert.Contains(t, sidecar.Env, corev1.EnvVar{Name: constants.WorkerPortEnv, Value: "39900"})
+	assert.Len(t, pod.Spec.Containers, 1)
+
+	// the pod waits for the pod controller to allocate GPUs, which are released by finalizer
+	assert.Equal(t, []corev1.PodSchedulingGate{{Name: constants.EmbeddedWorkerSchedulingGate}}, pod.Spec.SchedulingGates)
+	assert.Empty(t, pod.Spec.NodeSelector)
+	assert.Equal(t, constants.TrueStringValue, pod.Labels[constants.EmbeddedWorkerLabel])
+	assert.NotContains(t, pod.Annotations, constants.GpuKey)
+	assert.Equal(t, "10", pod.Annotations[constants.TFLOPSRequestAnnotation])
+	assert.Equal(t, "2Gi", pod.Annotations[constants.VRAMLimitAnnotation])
+	assert.NotContains(t, pod.Annotations, constants.GenPortNumberAnnotation)
+	assert.Contains(t, pod.Finalizers, constants.Finalizer)
+
+	paths := lo.Map(patches, func(patch jsonpatch.JsonPatchOperation, _ int) string {
+		return patch.Path
+	})
+	assert.Contains(t, paths, "/spec/initContainers")
+	assert.Contains(t, paths, "/spec/schedulingGates")
+	assert.Contains(t, paths, "/metadata/finalizers")
+}
@@ -19,11 +19,11 @@ package v1
 import (
 	"context"
 	"encoding/json"
-	goErrors "errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"gomodules.xyz/jsonpatch/v2"
@@ -39,10 +39,10 @@ import (
 
 	"al.essio.dev/pkg/shellescape"
 	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/component"
 	"github.com/NexusGPU/tensor-fusion/internal/constants"
 	"github.com/NexusGPU/tensor-fusion/internal/portallocator"
 	"github.com/NexusGPU/tensor-fusion/internal/utils"
-	"github.com/NexusGPU/tensor-fusion/internal/worker"
 	"github.com/lithammer/shortuuid/v4"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -113,12 +113,19 @@ func (m *TensorFusionPodMutator) Handle(ctx context.Context, req admission.Reque
 
 	workload := &tfv1.TensorFusionWorkload{}
 	if tfInfo.GenWorkload {
+		reason, err := m.checkQuota(ctx, pod, &tfInfo)
+		if err != nil {
+			return admission.Errored(http.StatusInternalServerError, fmt.Errorf("check gpu resource quota: %w", err))
+		}
+		if reason != "" {
+			return admission.Denied(reason)
+		}
 		if err := m.createOrUpdateWorkload(ctx, pod, &tfInfo, workload, pool); err != nil {
 			return admission.Errored(http.StatusInternalServerError, fmt.Errorf("create tf workload: %w", err))
 		}
 	}
 
-	var nodeSelector map[string]string
+	var localAffinity *corev1.Affinity
 	if tfInfo.Profile.IsLocalGPU {
 		if !tfInfo.GenWorkload {
 			if err := m.Client.Get(ctx, client.ObjectKey{Name: tfInfo.WorkloadName, Namespace: pod.Namespace}, workload); err != nil {
@@ -126,29 +133,24 @@ func (m *TensorFusionPodMutator) Handle(ctx context.Context, req admission.Reque
 			}
 		}
 
-		workerFound := false
-		for i := 0; i < 25; i++ {
-			workloadStatus, err := worker.SelectWorker(ctx, m.Client, workload, 1)
+		// pods with an embedded worker are pinned by the pod controller once GPUs are allocated for them
+		if !workload.Spec.EmbeddedWorker() {
+			affinity, err := localWorkerAffinity(ctx, m.Client, workload)
 			if err != nil {
-				if goErrors.Is(err, worker.ErrNoAvailableWorker) {
-					time.Sleep(time.Second)
-					continue
-				}
 				log.Error(err, "failed to select worker for pod", "pod", req.Name, "namespace", req.Namespace)
 				return admission.Errored(http.StatusInternalServerError, fmt.Errorf("select worker: %w", err))
 			}
-			nodeSelector = workloadStatus.NodeSelector
-			workerFound = true
-			break
-		}
-
-		if !workerFound {
-			return admission.Errored(http.StatusInternalServerError, fmt.Errorf("no available worker for pod: %s", req.Name))
+			localAffinity = affinity
 		}
 	}
 
 	// Inject initContainer and env variables
-	patches, err := m.patchTFClient(pod, pool, tfInfo.ContainerNames, nodeSelector)
+	patches, err := m.patchTFClient(pod, pool, tfInfo.ContainerNames, localAffinity)
+	if err == nil && workload.Spec.EmbeddedWorker() {
+		var embeddedPatches []jsonpatch.JsonPatchOperation
+		embeddedPatches, err = patchEmbeddedWorker(pod, pool, workload)
+		patches = append(patches, embeddedPatches...)
+	}
 	if err != nil {
 		log.Error(err, "failed to patch tf client", "pod", req.Name, "namespace", req.Namespace)
 		return admission.Errored(http.StatusInternalServerError, err)
@@ -176,6 +178,45 @@ func (m *TensorFusionPodMutator) InjectDecoder(d admission.Decoder) error {
 	return nil
 }
 
+// checkQuota returns the reason when workers the pod adds to its workload go beyond
+// GPU resource quotas of the namespace, empty when the workers fit
+func (m *TensorFusionPodMutator) checkQuota(ctx context.Context, pod *corev1.Pod, tfInfo *TensorFusionInfo) (string, error) {
+	quotas := &tfv1.GPUResourceQuotaList{}
+	if err := m.Client.List(ctx, quotas, client.InNamespace(pod.Namespace)); err != nil {
+		return "", fmt.Errorf("list gpu resource quotas: %w", err)
+	}
+	if len(quotas.Items) == 0 {
+		return "", nil
+	}
+
+	// only replicas added to an existing workload are charged
+	newReplicas := tfInfo.Replicas
+	workload := &tfv1.TensorFusionWorkload{}
+	if err := m.Client.Get(ctx, client.ObjectKey{Name: tfInfo.WorkloadName, Namespace: pod.Namespace}, workload); err != nil {
+		if !errors.IsNotFound(err) {
+			return "", fmt.Errorf("get workload: %w", err)
+		}
+	} else if workload.Spec.Replicas != nil {
+		newReplicas -= *workload.Spec.Replicas
+	}
+	if newReplicas <= 0 {
+		return "", nil
+	}
+
+	gpuCount := int64(max(tfInfo.Profile.GPUCount, 1)) * int64(newReplicas)
+	charge := tfv1.NewGPUResourceQuotaAmounts(tfInfo.Profile.Resources, gpuCount, newReplicas)
+	for i := range quotas.Items {
+		quota := &quotas.Items[i]
+		if !quota.AppliesTo(tfInfo.Profile.PoolName) {
+			continue
+		}
+		if exceeded := quota.Exceeded(quota.Status.Used, charge); len(exceeded) > 0 {
+			return fmt.Sprintf("gpu resource quota %s exceeded: %s", quota.Name, strings.Join(exceeded, ", ")), nil
+		}
+	}
+	return "", nil
+}
+
 func (m *TensorFusionPodMutator) createOrUpdateWorkload(ctx context.Context, pod *corev1.Pod, tfInfo *TensorFusionInfo, workload *tfv1.TensorFusionWorkload, pool *tfv1.GPUPool) error {
 	// Check if workload exists
 	err := m.Client.Get(ctx, client.ObjectKey{Name: tfInfo.WorkloadName, Namespace: pod.Namespace}, workload)
@@ -194,7 +235,7 @@ func (m *TensorFusionPodMutator) createOrUpdateWorkload(ctx context.Context, pod
 
 		// Create a new workload
 		replicas := tfInfo.Replicas
-		workload = &tfv1.TensorFusionWorkload{
+		*workload = tfv1.TensorFusionWorkload{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      tfInfo.WorkloadName,
 				Namespace: pod.Namespace,
@@ -203,13 +244,18 @@ func (m *TensorFusionPodMutator) createOrUpdateWorkload(ctx context.Context, pod
 				},
 			},
 			Spec: tfv1.WorkloadProfileSpec{
-				Replicas:   &replicas,
-				PoolName:   tfInfo.Profile.PoolName,
-				Resources:  tfInfo.Profile.Resources,
-				GPUCount:   tfInfo.Profile.GPUCount,
-				Qos:        qos,
-				GPUModel:   tfInfo.Profile.GPUModel,
-				IsLocalGPU: tfInfo.Profile.IsLocalGPU,
+				Replicas:       &replicas,
+				PoolName:       tfInfo.Profile.PoolName,
+				Resources:      tfInfo.Profile.Resources,
+				GPUCount:       tfInfo.Profile.GPUCount,
+				Qos:            qos,
+				GPUModel:       tfInfo.Profile.GPUModel,
+				IsLocalGPU:     tfInfo.Profile.IsLocalGPU,
+				CrossNodeGPUs:  tfInfo.Profile.CrossNodeGPUs,
+				GangScheduling: tfInfo.Profile.GangScheduling,
+
+				StandaloneWorkerMode: tfInfo.Profile.StandaloneWorkerMode,
+				AutoScalingConfig:    tfInfo.Profile.AutoScalingConfig,
 			},
 		}
 
@@ -231,18 +277,43 @@ func (m *TensorFusionPodMutator) createOrUpdateWorkload(ctx context.Context, pod
 
 	// Create the desired spec for comparison
 	replicas := tfInfo.Replicas
+	// Keep replicas and requests decided by the autoscaler, otherwise every new pod would revert them
+	if isAutoScaled(workload) && workload.Spec.Replicas != nil {
+		replicas = *workload.Spec.Replicas
+	}
+	// Keep requests recommended by the autoscaler only while auto set requests is enabled,
+	// resources of the pod take effect again once it's disabled
+	resources := tfInfo.Profile.Resources
+	_, requestsAutoSet := workload.Annotations[constants.LastRequestsUpdateTimeAnnotation]
+	if requestsAutoSet {
+		enabled, err := m.autoSetRequestsEnabled(ctx, tfInfo.Profile, pool)
+		if err != nil {
+			return err
+		}
+		if enabled {
+			resources = workload.Spec.Resources
+		} else {
+			delete(workload.Annotations, constants.LastRequestsUpdateTimeAnnotation)
+		}
+	}
 	desiredSpec := tfv1.WorkloadProfileSpec{
-		Replicas:   &replicas,
-		PoolName:   tfInfo.Profile.PoolName,
-		Resources:  tfInfo.Profile.Resources,
-		Qos:        qos,
-		IsLocalGPU: tfInfo.Profile.IsLocalGPU,
-		GPUCount:   tfInfo.Profile.GPUCount,
-		GPUModel:   tfInfo.Profile.GPUModel,
+		Replicas:       &replicas,
+		PoolName:       tfInfo.Profile.PoolName,
+		Resources:      resources,
+		Qos:            qos,
+		IsLocalGPU:     tfInfo.Profile.IsLocalGPU,
+		GPUCount:       tfInfo.Profile.GPUCount,
+		GPUModel:       tfInfo.Profile.GPUModel,
+		CrossNodeGPUs:  tfInfo.Profile.CrossNodeGPUs,
+		GangScheduling: tfInfo.Profile.GangScheduling,
+
+		StandaloneWorkerMode: tfInfo.Profile.StandaloneWorkerMode,
+		AutoScalingConfig:    tfInfo.Profile.AutoScalingConfig,
 	}
 
 	// Compare the entire spec at once
-	if !equality.Semantic.DeepEqual(workload.Spec, desiredSpec) {
+	_, stillAutoSet := workload.Annotations[constants.LastRequestsUpdateTimeAnnotation]
+	if !equality.Semantic.DeepEqual(workload.Spec, desiredSpec) || requestsAutoSet != stillAutoSet {
 		workload.Spec = desiredSpec
 		// TODO retry on conflict
 		if err := m.Client.Update(ctx, workload); err != nil {
@@ -252,11 +323,33 @@ func (m *TensorFusionPodMutator) createOrUpdateWorkload(ctx context.Context, pod
 	return nil
 }
 
+// autoSetRequestsEnabled returns whether requests of the workload are set by the autoscaler,
+// enabled in the workload profile or in the scheduling config template of the pool
+func (m *TensorFusionPodMutator) autoSetRequestsEnabled(ctx context.Context, profile *tfv1.WorkloadProfileSpec, pool *tfv1.GPUPool) (bool, error) {
+	if profile.AutoScalingConfig.AutoSetRequests.Enable {
+		return true, nil
+	}
+	if pool.Spec.SchedulingConfigTemplate == nil {
+		return false, nil
+	}
+	template := &tfv1.SchedulingConfigTemplate{}
+	if err := m.Client.Get(ctx, client.ObjectKey{Name: *pool.Spec.SchedulingConfigTemplate}, template); err != nil {
+		return false, fmt.Errorf("get scheduling config template %s: %w", *pool.Spec.SchedulingConfigTemplate, err)
+	}
+	return template.Spec.AutoScaling != nil && template.Spec.AutoScaling.AutoSetRequests.Enable, nil
+}
+
+func isAutoScaled(workload *tfv1.TensorFusionWorkload) bool {
+	_, scaledUp := workload.Annotations[constants.LastScaleUpTimeAnnotation]
+	_, scaledDown := workload.Annotations[constants.LastScaleDownTimeAnnotation]
+	return scaledUp || scaledDown
+}
+
 func (m *TensorFusionPodMutator) patchTFClient(
 	pod *corev1.Pod,
 	pool *tfv1.GPUPool,
 	containerNames []string,
-	nodeSelector map[string]string,
+	localAffinity *corev1.Affinity,
 ) ([]jsonpatch.JsonPatchOperation, error) {
 	// Convert the current pod to JSON
 	currentBytes, err := json.Marshal(pod)
@@ -264,29 +357,20 @@ func (m *TensorFusionPodMutator) patchTFClient(
 		return nil, fmt.Errorf("marshal current pod: %w", err)
 	}
 
-	if nodeSelector != nil {
+	if localAffinity != nil {
 		// Local GPU Mode
 		if pod.Spec.Affinity == nil {
 			pod.Spec.Affinity = &corev1.Affinity{}
 		}
-		pod.Spec.Affinity.NodeAffinity = &corev1.NodeAffinity{
-			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
-				NodeSelectorTerms: []corev1.NodeSelectorTerm{
-					{
-						MatchExpressions: []corev1.NodeSelectorRequirement{
-							{
-								Key:      "kubernetes.io/hostname",
-								Operator: corev1.NodeSelectorOpIn,
-								Values:   []string{nodeSelector["kubernetes.io/hostname"]},
-							},
-						},
-					},
-				},
-			},
+		if localAffinity.NodeAffinity != nil {
+			pod.Spec.Affinity.NodeAffinity = localAffinity.NodeAffinity
+		}
+		if localAffinity.PodAffinity != nil {
+			pod.Spec.Affinity.PodAffinity = localAffinity.PodAffinity
 		}
 	}
 
-	clientConfig := pool.Spec.ComponentConfig.Client
+	clientConfig := component.ActiveClientConfig(pool)
 
 	if pod.Labels == nil {
 		pod.Labels = map[string]string{}
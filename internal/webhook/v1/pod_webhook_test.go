@@ -35,6 +35,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
@@ -537,6 +538,51 @@ var _ = Describe("TensorFusionPodMutator", func() {
 		})
 	})
 
+	Context("createOrUpdateWorkload", func() {
+		It("should keep recommended requests only while auto set requests is enabled", func() {
+			recommended := tfv1.Resources{
+				Requests: tfv1.Resource{Tflops: resource.MustParse("5"), Vram: resource.MustParse("512Mi")},
+				Limits:   tfv1.Resource{Tflops: resource.MustParse("100"), Vram: resource.MustParse("16Gi")},
+			}
+			workload := &tfv1.TensorFusionWorkload{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "auto-set-requests",
+					Namespace:   "default",
+					Annotations: map[string]string{constants.LastRequestsUpdateTimeAnnotation: "2025-01-01T00:00:00Z"},
+				},
+				Spec: tfv1.WorkloadProfileSpec{PoolName: "mock", Resources: recommended},
+			}
+			fakeMutator := &TensorFusionPodMutator{
+				Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(workload).Build(),
+			}
+			pool := &tfv1.GPUPool{ObjectMeta: metav1.ObjectMeta{Name: "mock"}}
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "client", Namespace: "default"}}
+			tfInfo := &TensorFusionInfo{
+				WorkloadName: workload.Name,
+				Replicas:     1,
+				Profile: &tfv1.WorkloadProfileSpec{
+					PoolName: "mock",
+					Resources: tfv1.Resources{
+						Requests: tfv1.Resource{Tflops: resource.MustParse("10"), Vram: resource.MustParse("1Gi")},
+						Limits:   recommended.Limits,
+					},
+				},
+			}
+
+			tfInfo.Profile.AutoScalingConfig.AutoSetRequests.Enable = true
+			updated := &tfv1.TensorFusionWorkload{}
+			Expect(fakeMutator.createOrUpdateWorkload(ctx, pod, tfInfo, updated, pool)).To(Succeed())
+			Expect(updated.Spec.Resources.Requests.Tflops.String()).To(Equal("5"))
+			Expect(updated.Annotations).To(HaveKey(constants.LastRequestsUpdateTimeAnnotation))
+
+			tfInfo.Profile.AutoScalingConfig.AutoSetRequests.Enable = false
+			updated = &tfv1.TensorFusionWorkload{}
+			Expect(fakeMutator.createOrUpdateWorkload(ctx, pod, tfInfo, updated, pool)).To(Succeed())
+			Expect(updated.Spec.Resources.Requests.Tflops.String()).To(Equal("10"))
+			Expect(updated.Annotations).NotTo(HaveKey(constants.LastRequestsUpdateTimeAnnotation))
+		})
+	})
+
 	Context("patchTFClient", func() {
 		It("should apply the patch to the pod", func() {
 			pod := &corev1.Pod{
@@ -592,11 +638,10 @@ var _ = Describe("TensorFusionPodMutator", func() {
 				Spec: *config.MockGPUPoolSpec,
 			}
 			containerNames := []string{"bash-container", "zsh-container", "other-container"}
-			nodeSelector := map[string]string{}
 
 			// Call the function that includes the command transformation
 			mutator := &TensorFusionPodMutator{}
-			patches, err := mutator.patchTFClient(pod, pool, containerNames, nodeSelector)
+			patches, err := mutator.patchTFClient(pod, pool, containerNames, nil)
 
 			// Verify results
 			Expect(err).NotTo(HaveOccurred())
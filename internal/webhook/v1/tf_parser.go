@@ -110,16 +110,23 @@ func ParseTensorFusionInfo(ctx context.Context, k8sClient client.Client, pod *co
 		workloadProfile.Spec.GPUCount = uint(val)
 	}
 
+	crossNodeGPUs, ok := pod.Annotations[constants.CrossNodeGPUsAnnotation]
+	if ok && crossNodeGPUs == constants.TrueStringValue {
+		workloadProfile.Spec.CrossNodeGPUs = true
+	}
+	gangScheduling, ok := pod.Annotations[constants.GangSchedulingAnnotation]
+	if ok && gangScheduling == constants.TrueStringValue {
+		workloadProfile.Spec.GangScheduling = true
+	}
+
 	localGPU, ok := pod.Annotations[constants.IsLocalGPUAnnotation]
 	if ok && localGPU == constants.TrueStringValue {
 		workloadProfile.Spec.IsLocalGPU = true
-		// default to no-standalone-worker, namely embedded worker when it's local GPU mode
-		standaloneWorkerMode := pod.Annotations[constants.StandaloneWorkerModeAnnotation]
-		workloadProfile.Spec.StandaloneWorkerMode = standaloneWorkerMode == constants.TrueStringValue
-	} else {
-		// default to standalone worker mode, ignore standalone-worker-mode annotation when it's not local GPU mode
-		workloadProfile.Spec.StandaloneWorkerMode = true
 	}
+	// default to standalone worker mode, the worker is embedded into the client pod only when
+	// no-standalone-worker-mode is set in local GPU mode, the annotation is ignored otherwise
+	noStandaloneWorker := pod.Annotations[constants.StandaloneWorkerModeAnnotation] == constants.TrueStringValue
+	workloadProfile.Spec.StandaloneWorkerMode = !workloadProfile.Spec.IsLocalGPU || !noStandaloneWorker
 
 	// Parse auto-scaling annotations
 	autoLimits, ok := pod.Annotations[constants.AutoScaleLimitsAnnotation]
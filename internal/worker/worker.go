@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"maps"
 	"math"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -16,6 +17,7 @@ import (
 	"github.com/NexusGPU/tensor-fusion/internal/utils"
 	"github.com/samber/lo"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -64,54 +66,79 @@ func (wg *WorkerGenerator) GenerateWorkerPod(
 	limits tfv1.Resource,
 	podTemplateHash string,
 ) (*corev1.Pod, string, error) {
-	podTmpl := &corev1.PodTemplate{}
-	err := json.Unmarshal(wg.WorkerConfig.PodTemplate.Raw, podTmpl)
+	pod, err := wg.generateWorkerPod(workloadName, namespace, port, requests, limits)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to unmarshal pod template: %w", err)
+		return nil, "", err
 	}
-	spec := podTmpl.Template.Spec
 
 	// all the gpus are on the same node
-	spec.NodeSelector = gpus[0].Status.NodeSelector
+	pod.Spec.NodeSelector = gpus[0].Status.NodeSelector
 
-	spec.Volumes = append(spec.Volumes, corev1.Volume{
-		Name: constants.DataVolumeName,
-		VolumeSource: corev1.VolumeSource{
-			HostPath: &corev1.HostPathVolumeSource{
-				Path: constants.TFDataPath,
-				Type: ptr.To(corev1.HostPathDirectoryOrCreate),
+	gpuEnv, err := wg.GPUEnv(gpus, limits)
+	if err != nil {
+		return nil, "", err
+	}
+	pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, gpuEnv...)
+	return pod, podTemplateHash, nil
+}
+
+// GenerateEmbeddedWorkerPod generates a worker pod before its GPUs are allocated, env depending on GPUs
+// is read from pod annotations, which are set once GPUs are allocated and before the pod is scheduled
+func (wg *WorkerGenerator) GenerateEmbeddedWorkerPod(
+	workloadName string,
+	namespace string,
+	port int,
+	requests tfv1.Resource,
+	limits tfv1.Resource,
+) (*corev1.Pod, error) {
+	pod, err := wg.generateWorkerPod(workloadName, namespace, port, requests, limits)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range gpuEnvNames {
+		pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{
+					FieldPath: fmt.Sprintf("metadata.annotations['%s']", GPUEnvAnnotation(name)),
+				},
 			},
-		},
-	})
+		})
+	}
+	return pod, nil
+}
 
-	// performance optimization, service link will cause high CPU usage when service number is large
-	spec.EnableServiceLinks = ptr.To(false)
+// gpuEnvNames are env of the worker depending on the GPUs it runs on
+var gpuEnvNames = []string{
+	constants.NvidiaVisibleDevicesEnv,
+	constants.WorkerCudaUpLimitTflopsEnv,
+	constants.WorkerCudaUpLimitEnv,
+	constants.WorkerCudaMemLimitEnv,
+}
 
-	spec.Containers[0].VolumeMounts = append(spec.Containers[0].VolumeMounts, corev1.VolumeMount{
-		Name:        constants.DataVolumeName,
-		MountPath:   constants.TFDataPath,
-		SubPathExpr: fmt.Sprintf("${%s}", constants.PodNameEnv),
-	})
+// GPUEnvAnnotation returns the annotation holding the value of the GPU env for embedded workers
+func GPUEnvAnnotation(name string) string {
+	return constants.GPUEnvAnnotationPrefix + name
+}
 
+// GPUEnv returns env of the worker running on the GPUs with the limits applied to each GPU
+func (wg *WorkerGenerator) GPUEnv(gpus []*tfv1.GPU, limits tfv1.Resource) ([]corev1.EnvVar, error) {
 	firstGPU := gpus[0]
 	info, ok := lo.Find(*wg.GpuInfos, func(info config.GpuInfo) bool {
 		return info.FullModelName == firstGPU.Status.GPUModel
 	})
 	if !ok {
-		return nil, "", fmt.Errorf("gpu info(%s) not found", firstGPU.Status.GPUModel)
+		return nil, fmt.Errorf("gpu info(%s) not found", firstGPU.Status.GPUModel)
 	}
 
 	gpuUUIDs := lo.Map(gpus, func(gpu *tfv1.GPU, _ int) string {
 		return gpu.Status.UUID
 	})
 
-	spec.Containers[0].Env = append(spec.Containers[0].Env, corev1.EnvVar{
-		Name:  "NVIDIA_VISIBLE_DEVICES",
+	return []corev1.EnvVar{{
+		Name:  constants.NvidiaVisibleDevicesEnv,
 		Value: strings.Join(gpuUUIDs, ","),
-	}, corev1.EnvVar{
-		Name:  constants.WorkerPortEnv,
-		Value: strconv.Itoa(port),
-	}, corev1.EnvVar{
+	}, {
 		Name: constants.WorkerCudaUpLimitTflopsEnv,
 		Value: func() string {
 			tflopsMap := make(map[string]int64)
@@ -121,7 +148,7 @@ func (wg *WorkerGenerator) GenerateWorkerPod(
 			jsonBytes, _ := json.Marshal(tflopsMap)
 			return string(jsonBytes)
 		}(),
-	}, corev1.EnvVar{
+	}, {
 		Name: constants.WorkerCudaUpLimitEnv,
 		Value: func() string {
 			upLimitMap := make(map[string]int64)
@@ -131,7 +158,7 @@ func (wg *WorkerGenerator) GenerateWorkerPod(
 			jsonBytes, _ := json.Marshal(upLimitMap)
 			return string(jsonBytes)
 		}(),
-	}, corev1.EnvVar{
+	}, {
 		Name: constants.WorkerCudaMemLimitEnv,
 		// bytesize
 		Value: func() string {
@@ -142,6 +169,85 @@ func (wg *WorkerGenerator) GenerateWorkerPod(
 			jsonBytes, _ := json.Marshal(memLimitMap)
 			return string(jsonBytes)
 		}(),
+	}}, nil
+}
+
+// AssignEmbeddedWorkerGPUs records GPUs allocated for the embedded worker on the client pod, pins the pod
+// to their node and removes the scheduling gate, the node selector can only be added while the pod is gated
+func AssignEmbeddedWorkerGPUs(pod *corev1.Pod, gpus []*tfv1.GPU, gpuEnv []corev1.EnvVar) {
+	if pod.Annotations == nil {
+		pod.Annotations = make(map[string]string)
+	}
+	pod.Annotations[constants.GpuKey] = strings.Join(lo.Map(gpus, func(gpu *tfv1.GPU, _ int) string {
+		return gpu.Name
+	}), ",")
+	for _, env := range gpuEnv {
+		pod.Annotations[GPUEnvAnnotation(env.Name)] = env.Value
+	}
+
+	// all the gpus are on the same node
+	if pod.Spec.NodeSelector == nil {
+		pod.Spec.NodeSelector = make(map[string]string)
+	}
+	maps.Copy(pod.Spec.NodeSelector, gpus[0].Status.NodeSelector)
+	pod.Spec.SchedulingGates = lo.Reject(pod.Spec.SchedulingGates, func(gate corev1.PodSchedulingGate, _ int) bool {
+		return gate.Name == constants.EmbeddedWorkerSchedulingGate
+	})
+}
+
+// generateWorkerPod generates the worker pod from the worker template without anything depending on GPUs
+func (wg *WorkerGenerator) generateWorkerPod(
+	workloadName string,
+	namespace string,
+	port int,
+	requests tfv1.Resource,
+	limits tfv1.Resource,
+) (*corev1.Pod, error) {
+	podTmpl := &corev1.PodTemplate{}
+	err := json.Unmarshal(wg.WorkerConfig.PodTemplate.Raw, podTmpl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pod template: %w", err)
+	}
+	spec := podTmpl.Template.Spec
+
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name: constants.DataVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{
+				Path: constants.TFDataPath,
+				Type: ptr.To(corev1.HostPathDirectoryOrCreate),
+			},
+		},
+	})
+
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name: constants.PodInfoVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			DownwardAPI: &corev1.DownwardAPIVolumeSource{
+				Items: []corev1.DownwardAPIVolumeFile{{
+					Path:     "annotations",
+					FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.annotations"},
+				}},
+			},
+		},
+	})
+
+	// performance optimization, service link will cause high CPU usage when service number is large
+	spec.EnableServiceLinks = ptr.To(false)
+
+	spec.Containers[0].VolumeMounts = append(spec.Containers[0].VolumeMounts, corev1.VolumeMount{
+		Name:        constants.DataVolumeName,
+		MountPath:   constants.TFDataPath,
+		SubPathExpr: fmt.Sprintf("${%s}", constants.PodNameEnv),
+	}, corev1.VolumeMount{
+		Name:      constants.PodInfoVolumeName,
+		MountPath: constants.TFPodInfoPath,
+		ReadOnly:  true,
+	})
+
+	spec.Containers[0].Env = append(spec.Containers[0].Env, corev1.EnvVar{
+		Name:  constants.WorkerPortEnv,
+		Value: strconv.Itoa(port),
 	}, corev1.EnvVar{
 		Name: constants.PodNameEnv,
 		ValueFrom: &corev1.EnvVarSource{
@@ -152,6 +258,14 @@ func (wg *WorkerGenerator) GenerateWorkerPod(
 	}, corev1.EnvVar{
 		Name:  constants.WorkloadNameEnv,
 		Value: workloadName,
+	}, corev1.EnvVar{
+		// reported by the hypervisor with worker usage along with the workload name
+		Name: constants.WorkloadNamespaceEnv,
+		ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{
+				FieldPath: "metadata.namespace",
+			},
+		},
 	})
 	workerLabels := map[string]string{
 		constants.LabelComponent: constants.ComponentWorker,
@@ -179,7 +293,19 @@ func (wg *WorkerGenerator) GenerateWorkerPod(
 			Annotations:  workerAnnotations,
 		},
 		Spec: spec,
-	}, podTemplateHash, nil
+	}, nil
+}
+
+// WorkerResources returns the resources a worker pod was started with
+func WorkerResources(pod *corev1.Pod) tfv1.Resources {
+	tflopsRequest, _ := resource.ParseQuantity(pod.Annotations[constants.TFLOPSRequestAnnotation])
+	vramRequest, _ := resource.ParseQuantity(pod.Annotations[constants.VRAMRequestAnnotation])
+	tflopsLimit, _ := resource.ParseQuantity(pod.Annotations[constants.TFLOPSLimitAnnotation])
+	vramLimit, _ := resource.ParseQuantity(pod.Annotations[constants.VRAMLimitAnnotation])
+	return tfv1.Resources{
+		Requests: tfv1.Resource{Tflops: tflopsRequest, Vram: vramRequest},
+		Limits:   tfv1.Resource{Tflops: tflopsLimit, Vram: vramLimit},
+	}
 }
 
 func SelectWorker(
@@ -207,9 +333,10 @@ func SelectWorker(
 		}
 	})
 
-	// filter out failed workers and get the usage of available workers
+	// filter out failed workers and get the usage of available workers,
+	// connections to a cross-node replica are always made through its first rank
 	activeWorkers := lo.Filter(workload.Status.WorkerStatuses, func(status tfv1.WorkerStatus, _ int) bool {
-		return status.WorkerPhase != tfv1.WorkerFailed
+		return status.WorkerPhase != tfv1.WorkerFailed && status.Rank == 0
 	})
 
 	if len(activeWorkers) == 0 {
@@ -234,3 +361,17 @@ func SelectWorker(
 
 	return &selectedWorker, nil
 }
+
+// ReplicaWorkers returns all workers of the replica the given worker belongs to, ordered by rank
+func ReplicaWorkers(workload *tfv1.TensorFusionWorkload, workerStatus tfv1.WorkerStatus) []tfv1.WorkerStatus {
+	if workerStatus.ReplicaID == "" {
+		return []tfv1.WorkerStatus{workerStatus}
+	}
+	workers := lo.Filter(workload.Status.WorkerStatuses, func(status tfv1.WorkerStatus, _ int) bool {
+		return status.ReplicaID == workerStatus.ReplicaID
+	})
+	slices.SortFunc(workers, func(a, b tfv1.WorkerStatus) int {
+		return a.Rank - b.Rank
+	})
+	return workers
+}
@@ -5,9 +5,12 @@ import (
 	"testing"
 
 	tfv1 "github.com/NexusGPU/tensor-fusion/api/v1"
+	"github.com/NexusGPU/tensor-fusion/internal/config"
 	"github.com/NexusGPU/tensor-fusion/internal/constants"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -402,6 +405,34 @@ func TestSelectWorker(t *testing.T) {
 			expectedWorker: "worker-3", // Worker-3 has 0, Worker-2 has 1, Worker-1 has 2, all within maxSkew=2
 			expectError:    false,
 		},
+		{
+			name:    "only the first rank of a cross-node replica is selected",
+			maxSkew: 1,
+			workload: &tfv1.TensorFusionWorkload{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-workload",
+					Namespace: "default",
+				},
+				Status: tfv1.TensorFusionWorkloadStatus{
+					WorkerStatuses: []tfv1.WorkerStatus{
+						{
+							WorkerName:  "worker-1",
+							WorkerPhase: tfv1.WorkerRunning,
+							ReplicaID:   "replica-a",
+							Rank:        1,
+						},
+						{
+							WorkerName:  "worker-2",
+							WorkerPhase: tfv1.WorkerRunning,
+							ReplicaID:   "replica-a",
+						},
+					},
+				},
+			},
+			connections:    []tfv1.TensorFusionConnection{},
+			expectedWorker: "worker-2",
+			expectError:    false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -439,3 +470,65 @@ func TestSelectWorker(t *testing.T) {
 		})
 	}
 }
+
+func TestReplicaWorkers(t *testing.T) {
+	workload := &tfv1.TensorFusionWorkload{
+		Status: tfv1.TensorFusionWorkloadStatus{
+			WorkerStatuses: []tfv1.WorkerStatus{
+				{WorkerName: "worker-1", ReplicaID: "replica-a", Rank: 1},
+				{WorkerName: "worker-2"},
+				{WorkerName: "worker-3", ReplicaID: "replica-a"},
+				{WorkerName: "worker-4", ReplicaID: "replica-b"},
+			},
+		},
+	}
+
+	workers := ReplicaWorkers(workload, workload.Status.WorkerStatuses[2])
+	assert.Equal(t, []string{"worker-3", "worker-1"}, lo.Map(workers, func(status tfv1.WorkerStatus, _ int) string {
+		return status.WorkerName
+	}))
+
+	workers = ReplicaWorkers(workload, workload.Status.WorkerStatuses[1])
+	assert.Len(t, workers, 1)
+	assert.Equal(t, "worker-2", workers[0].WorkerName)
+}
+
+func TestAssignEmbeddedWorkerGPUs(t *testing.T) {
+	gpus := []*tfv1.GPU{{
+		ObjectMeta: metav1.ObjectMeta{Name: "gpu-1"},
+		Status: tfv1.GPUStatus{
+			UUID:         "GPU-1",
+			GPUModel:     "mock",
+			NodeSelector: map[string]string{constants.KubernetesHostNameLabel: "node-a"},
+		},
+	}}
+	limits := tfv1.Resource{Tflops: resource.MustParse("20"), Vram: resource.MustParse("2Gi")}
+	workerGenerator := &WorkerGenerator{GpuInfos: config.MockGpuInfo()}
+	gpuEnv, err := workerGenerator.GPUEnv(gpus, limits)
+	assert.NoError(t, err)
+
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			NodeSelector: map[string]string{"zone": "a"},
+			SchedulingGates: []corev1.PodSchedulingGate{
+				{Name: "other"},
+				{Name: constants.EmbeddedWorkerSchedulingGate},
+			},
+		},
+	}
+	AssignEmbeddedWorkerGPUs(pod, gpus, gpuEnv)
+
+	assert.Equal(t, "gpu-1", pod.Annotations[constants.GpuKey])
+	assert.Equal(t, "GPU-1", pod.Annotations[GPUEnvAnnotation(constants.NvidiaVisibleDevicesEnv)])
+	assert.Equal(t, `{"GPU-1":2}`, pod.Annotations[GPUEnvAnnotation(constants.WorkerCudaUpLimitEnv)])
+	assert.Equal(t, map[string]string{"zone": "a", constants.KubernetesHostNameLabel: "node-a"}, pod.Spec.NodeSelector)
+	assert.Equal(t, []corev1.PodSchedulingGate{{Name: "other"}}, pod.Spec.SchedulingGates)
+
+	// every env depending on GPUs of the embedded worker is read from the annotations set above
+	for _, env := range gpuEnv {
+		assert.Contains(t, pod.Annotations, GPUEnvAnnotation(env.Name))
+	}
+	assert.ElementsMatch(t, gpuEnvNames, lo.Map(gpuEnv, func(env corev1.EnvVar, _ int) string {
+		return env.Name
+	}))
+}